@@ -48,7 +48,7 @@ func (b board) pieceByColor(sq Square, c Color) Piece {
 }
 
 // makeMove makes and unmakes a move on the board.
-func (b *board) makeMove(m Move, cf [2]File) {
+func (b *board) makeMove(m Move, cf castleFiles) {
 	p1, p2 := m.P1(), m.P2()
 	s1, s2 := m.S1(), m.S2()
 	c := p1.Color()
@@ -87,26 +87,26 @@ func (b *board) makeMove(m Move, cf [2]File) {
 		b.bbPieces[Pawn] ^= bb
 		b.bbColors[White] ^= bb
 	case c == Black && m.HasTag(ASideCastle): // black A side castle
-		bb := newSquare(cf[aSide], Rank8).bitboard() | D8.bitboard()
+		bb := newSquare(cf[Black][aSide], Rank8).bitboard() ^ D8.bitboard()
 		b.bbPieces[Rook] ^= bb
 		b.bbColors[Black] ^= bb
 	case c == Black && m.HasTag(HSideCastle): // black H side castle
-		bb := newSquare(cf[hSide], Rank8).bitboard() | F8.bitboard()
+		bb := newSquare(cf[Black][hSide], Rank8).bitboard() ^ F8.bitboard()
 		b.bbPieces[Rook] ^= bb
 		b.bbColors[Black] ^= bb
 	case c == White && m.HasTag(ASideCastle): // white A side castle
-		bb := newSquare(cf[aSide], Rank1).bitboard() | D1.bitboard()
+		bb := newSquare(cf[White][aSide], Rank1).bitboard() ^ D1.bitboard()
 		b.bbPieces[Rook] ^= bb
 		b.bbColors[White] ^= bb
 	case c == White && m.HasTag(HSideCastle): // white H side castle
-		bb := newSquare(cf[hSide], Rank1).bitboard() | F1.bitboard()
+		bb := newSquare(cf[White][hSide], Rank1).bitboard() ^ F1.bitboard()
 		b.bbPieces[Rook] ^= bb
 		b.bbColors[White] ^= bb
 	}
 }
 
 // unmakeMove unmakes a move on the board.
-func (b *board) unmakeMove(m Move, cf [2]File) {
+func (b *board) unmakeMove(m Move, cf castleFiles) {
 	p1, p2 := m.P1(), m.P2()
 	s1, s2 := m.S1(), m.S2()
 	c := p1.Color()
@@ -145,19 +145,19 @@ func (b *board) unmakeMove(m Move, cf [2]File) {
 		b.bbPieces[Pawn] ^= bb
 		b.bbColors[White] ^= bb
 	case c == Black && m.HasTag(ASideCastle): // black A side castle
-		bb := newSquare(cf[aSide], Rank8).bitboard() | D8.bitboard()
+		bb := newSquare(cf[Black][aSide], Rank8).bitboard() ^ D8.bitboard()
 		b.bbPieces[Rook] ^= bb
 		b.bbColors[Black] ^= bb
 	case c == Black && m.HasTag(HSideCastle): // black H side castle
-		bb := newSquare(cf[hSide], Rank8).bitboard() | F8.bitboard()
+		bb := newSquare(cf[Black][hSide], Rank8).bitboard() ^ F8.bitboard()
 		b.bbPieces[Rook] ^= bb
 		b.bbColors[Black] ^= bb
 	case c == White && m.HasTag(ASideCastle): // white A side castle
-		bb := newSquare(cf[aSide], Rank1).bitboard() | D1.bitboard()
+		bb := newSquare(cf[White][aSide], Rank1).bitboard() ^ D1.bitboard()
 		b.bbPieces[Rook] ^= bb
 		b.bbColors[White] ^= bb
 	case c == White && m.HasTag(HSideCastle): // white H side castle
-		bb := newSquare(cf[hSide], Rank1).bitboard() | F1.bitboard()
+		bb := newSquare(cf[White][hSide], Rank1).bitboard() ^ F1.bitboard()
 		b.bbPieces[Rook] ^= bb
 		b.bbColors[White] ^= bb
 	}
@@ -2,15 +2,30 @@ package chess
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
+	"math"
+	"os"
 	"sort"
+
+	"github.com/leonhfr/orca/internal/mmap"
 )
 
+// bookEntrySize is the size in bytes of a Polyglot entry.
+const bookEntrySize = 16
+
 // Book holds the opening book data.
+//
+// Entries are either parsed into a map by Init or kept as sorted Polyglot
+// data that is binary searched, see NewSortedBook, OpenBook and OpenWritableBook.
 type Book struct {
-	m map[Hash][]openingMove
+	m       map[Hash][]openingMove
+	entries []byte       // sorted Polyglot entries
+	release func() error // releases the sorted entries
+	file    *os.File     // file updated by Learn, nil if the book is read-only
 }
 
 // openingMove represent a single move openingMove.
@@ -18,6 +33,7 @@ type openingMove struct {
 	s1, s2 Square
 	promo  PieceType
 	weight int
+	learn  uint32
 }
 
 // WeightedMove is a single weighted move.
@@ -25,8 +41,28 @@ type openingMove struct {
 type WeightedMove struct {
 	Move   Move
 	Weight int
+	Learn  uint32 // Polyglot learning data, see Learned.
+}
+
+// Learned returns the number of games learned for the move and their total
+// score in half points, 2 for a win and 1 for a draw.
+//
+// As in Polyglot, the learning data holds the number of games
+// in its high 16 bits and the score in its low 16 bits.
+func (wm WeightedMove) Learned() (games, points int) {
+	return int(wm.Learn >> 16), int(wm.Learn & 0xffff)
 }
 
+// LearnResult represents the result of a game from the point of view
+// of the player of the book moves.
+type LearnResult int8
+
+const (
+	LearnLoss LearnResult = -1 // LearnLoss represents a lost game.
+	LearnDraw LearnResult = 0  // LearnDraw represents a drawn game.
+	LearnWin  LearnResult = 1  // LearnWin represents a won game.
+)
+
 // NewBook returns a new empty book.
 func NewBook() *Book {
 	return &Book{
@@ -34,6 +70,157 @@ func NewBook() *Book {
 	}
 }
 
+// NewSortedBook returns a new book that binary searches the Polyglot data.
+//
+// The data is used as is and must not be modified. Unlike Init, entries
+// are only parsed when looked up. Books whose entries are not sorted by key,
+// as the Polyglot format requires, are parsed into a map as Init does.
+func NewSortedBook(data []byte) (*Book, error) {
+	if len(data)%bookEntrySize != 0 {
+		return nil, errBookSize
+	}
+
+	if !sortedEntries(data) {
+		book := NewBook()
+		if err := book.Init(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+		return book, nil
+	}
+
+	return &Book{
+		m:       make(map[Hash][]openingMove),
+		entries: data,
+	}, nil
+}
+
+// OpenBook returns a new book that binary searches the memory-mapped Polyglot file.
+//
+// The book should be closed when no longer used. On platforms that
+// do not support memory mapping, the file is read in memory. Unsorted
+// books are parsed into a map and the file is released at once.
+func OpenBook(path string) (*Book, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, release, err := mmap.Map(f)
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := NewSortedBook(data)
+	if err != nil {
+		_ = release()
+		return nil, err
+	}
+
+	if book.entries == nil {
+		return book, release()
+	}
+
+	book.release = release
+	return book, nil
+}
+
+// OpenWritableBook returns a new book that binary searches the Polyglot file
+// and writes the updates of Learn back to it.
+//
+// The file is read in memory. The book should be closed when no longer used.
+// Returns an error if the entries are not sorted by key, since Learn
+// updates them in place.
+func OpenWritableBook(path string) (*Book, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	if !sortedEntries(data) {
+		_ = f.Close()
+		return nil, errBookUnsorted
+	}
+
+	book, err := NewSortedBook(data)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	book.file, book.release = f, f.Close
+	return book, nil
+}
+
+// Close releases the memory-mapped data or the file of the book.
+//
+// The book must not be used afterwards.
+func (b *Book) Close() error {
+	if b.release == nil {
+		return nil
+	}
+
+	release := b.release
+	b.entries, b.release, b.file = nil, nil, nil
+	return release()
+}
+
+// Writable returns whether the book entries can be updated by Learn.
+func (b *Book) Writable() bool {
+	return b.file != nil
+}
+
+// Learn updates the entry of the book move played in the position
+// according to the result of the game for the side that played it.
+//
+// The learning data records the game and its score as Polyglot does.
+// The weight of the move grows by an eighth after a win and shrinks
+// by an eighth after a loss, never falling below 1 so that the move
+// stays in the book. The entry is written back to the file immediately.
+//
+// Returns an error if the book is not writable or if the move is not in the book.
+func (b *Book) Learn(pos *Position, m Move, result LearnResult) error {
+	if b.file == nil {
+		return errBookReadOnly
+	}
+
+	i, ok := b.find(pos.hash, polyglotMove(pos, m))
+	if !ok {
+		return errBookMove
+	}
+
+	data := b.entries[i*bookEntrySize : (i+1)*bookEntrySize]
+	weight := binary.BigEndian.Uint16(data[10:12])
+	games := binary.BigEndian.Uint16(data[12:14])
+	points := binary.BigEndian.Uint16(data[14:16])
+
+	binary.BigEndian.PutUint16(data[10:12], learnWeight(weight, result))
+	binary.BigEndian.PutUint16(data[12:14], min(games, math.MaxUint16-1)+1)
+	binary.BigEndian.PutUint16(data[14:16], uint16(min(int(points)+int(result)+1, math.MaxUint16)))
+
+	_, err := b.file.WriteAt(data[10:], int64(i*bookEntrySize+10))
+	return err
+}
+
+// learnWeight returns the weight of a book move updated with the result.
+func learnWeight(weight uint16, result LearnResult) uint16 {
+	delta := max(int(weight)/8, 1)
+	switch result {
+	case LearnWin:
+		return uint16(min(int(weight)+delta, math.MaxUint16))
+	case LearnLoss:
+		return uint16(max(int(weight)-delta, 1))
+	default:
+		return weight
+	}
+}
+
 // Init takes a reader to binary data (Polyglot files .bin) and initializes
 // the opening book. It may be called several times with data from
 // different books and data will be merged. Weights will be returned as is
@@ -42,17 +229,16 @@ func (b *Book) Init(r io.Reader) error {
 	scanner := bufio.NewScanner(r)
 	scanner.Split(scanEntries)
 
-	for scanner.Scan() {
+	for entry := 0; scanner.Scan(); entry++ {
 		data := scanner.Bytes()
 		hash := Hash(binary.BigEndian.Uint64(data[0:8]))
-		move := binary.BigEndian.Uint16(data[8:10])
-		weight := binary.BigEndian.Uint16(data[10:12])
 
-		if moves, ok := b.m[hash]; ok {
-			b.m[hash] = append(moves, parseRawMove(move, weight))
-		} else {
-			b.m[hash] = []openingMove{parseRawMove(move, weight)}
+		om, err := parseEntry(data)
+		if err != nil {
+			return fmt.Errorf("invalid book entry (%d): %w", entry, err)
 		}
+
+		b.m[hash] = append(b.m[hash], om)
 	}
 
 	return scanner.Err()
@@ -61,8 +247,13 @@ func (b *Book) Init(r io.Reader) error {
 // Lookup takes a position and returns a sorted list of weighted moves.
 // If the position is not found, nil is returned.
 func (b *Book) Lookup(pos *Position) []WeightedMove {
-	moves, ok := b.m[pos.hash]
-	if !ok {
+	moves := b.m[pos.hash]
+	if len(b.entries) > 0 {
+		// full slice expression so that the map entry is never appended to
+		moves = append(moves[:len(moves):len(moves)], b.search(pos.hash)...)
+	}
+
+	if len(moves) == 0 {
 		return nil
 	}
 
@@ -80,16 +271,76 @@ func (b *Book) Lookup(pos *Position) []WeightedMove {
 		weightedMoves = append(weightedMoves, WeightedMove{
 			Move:   newMove(p1, p2, s1, s2, NoSquare, promo),
 			Weight: move.weight,
+			Learn:  move.learn,
 		})
 	}
 
-	sort.Slice(weightedMoves, func(i, j int) bool {
+	sort.SliceStable(weightedMoves, func(i, j int) bool {
 		return weightedMoves[i].Weight > weightedMoves[j].Weight
 	})
 
 	return weightedMoves
 }
 
+// search binary searches the sorted entries and returns the moves of the position.
+//
+// Malformed entries are skipped.
+func (b *Book) search(hash Hash) []openingMove {
+	n := len(b.entries) / bookEntrySize
+	i := sort.Search(n, func(i int) bool {
+		return b.entryKey(i) >= hash
+	})
+
+	var moves []openingMove
+	for ; i < n && b.entryKey(i) == hash; i++ {
+		if om, err := parseEntry(b.entries[i*bookEntrySize:]); err == nil {
+			moves = append(moves, om)
+		}
+	}
+
+	return moves
+}
+
+// find binary searches the sorted entries and returns the index of the move of the position.
+func (b *Book) find(hash Hash, move uint16) (int, bool) {
+	n := len(b.entries) / bookEntrySize
+	i := sort.Search(n, func(i int) bool {
+		return b.entryKey(i) >= hash
+	})
+
+	for ; i < n && b.entryKey(i) == hash; i++ {
+		if binary.BigEndian.Uint16(b.entries[i*bookEntrySize+8:]) == move {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// sortedEntries returns whether the Polyglot entries are sorted by key.
+func sortedEntries(data []byte) bool {
+	for i := bookEntrySize; i+bookEntrySize <= len(data); i += bookEntrySize {
+		if binary.BigEndian.Uint64(data[i-bookEntrySize:]) > binary.BigEndian.Uint64(data[i:]) {
+			return false
+		}
+	}
+	return true
+}
+
+// entryKey returns the key of the i-th sorted entry.
+func (b *Book) entryKey(i int) Hash {
+	return Hash(binary.BigEndian.Uint64(b.entries[i*bookEntrySize:]))
+}
+
+// parseEntry parses the move, weight and learning data of a Polyglot entry.
+func parseEntry(data []byte) (openingMove, error) {
+	move := binary.BigEndian.Uint16(data[8:10])
+	weight := binary.BigEndian.Uint16(data[10:12])
+	om, err := parseRawMove(move, weight)
+	om.learn = binary.BigEndian.Uint32(data[12:16])
+	return om, err
+}
+
 // parseRawMove parses a raw move.
 //
 // A raw move is a bit field with the following meaning (bit 0 is the least
@@ -101,21 +352,35 @@ func (b *Book) Lookup(pos *Position) []WeightedMove {
 //	6,7,8               from file
 //	9,10,11             from row
 //	12,13,14            promotion piece
-func parseRawMove(move, weight uint16) openingMove {
+//
+// Returns an error if the promotion code is unknown.
+func parseRawMove(move, weight uint16) (openingMove, error) {
 	file2 := move & 7
 	rank2 := (move >> 3) & 7
 	file1 := (move >> 6) & 7
 	rank1 := (move >> 9) & 7
 	promo := (move >> 12) & 7
 
+	if int(promo) >= len(promotionCodes) {
+		return openingMove{}, errPromotionCode
+	}
+
 	return openingMove{
 		s1:     Square(8*rank1 + file1),
 		s2:     Square(8*rank2 + file2),
 		promo:  promotionCodes[promo],
 		weight: int(weight),
-	}
+	}, nil
 }
 
+var (
+	errBookSize      = errors.New("expected data to be multiple of 16 bytes")
+	errPromotionCode = errors.New("unknown promotion code")
+	errBookReadOnly  = errors.New("book is not writable")
+	errBookMove      = errors.New("move not in book")
+	errBookUnsorted  = errors.New("book entries are not sorted by key")
+)
+
 // promotionCodes is an array of piece types indexed by promotion codes,
 // determined as follow:
 //
@@ -142,9 +407,9 @@ func castlingDestination(from, to Square, fromPiece Piece) Square {
 		return G1
 	case from == E1 && to == A1 && fromPiece == WhiteKing:
 		return C1
-	case from == E8 && to == H8 && fromPiece == WhiteKing:
+	case from == E8 && to == H8 && fromPiece == BlackKing:
 		return G8
-	case from == E8 && to == A8 && fromPiece == WhiteKing:
+	case from == E8 && to == A8 && fromPiece == BlackKing:
 		return C8
 	default:
 		return to
@@ -159,12 +424,12 @@ func scanEntries(data []byte, atEOF bool) (advance int, token []byte, err error)
 	case atEOF && len(data) == 0:
 		// expected EOF
 		return 0, nil, nil
-	case len(data) >= 16:
+	case len(data) >= bookEntrySize:
 		// return raw entry bytes
-		return 16, data[0:16], nil
+		return bookEntrySize, data[0:bookEntrySize], nil
 	case atEOF:
 		// if at EOF and we still have data, the data is malformed
-		return len(data), data, errors.New("expected data to be multiple of 16 bytes")
+		return len(data), data, errBookSize
 	default:
 		// request more data
 		return 0, nil, nil
@@ -0,0 +1,180 @@
+package chess
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// BookEntry represents a raw Polyglot book entry.
+type BookEntry struct {
+	Key    Hash   // Polyglot Zobrist hash of the position.
+	Move   uint16 // Move in the Polyglot encoding.
+	Weight uint16 // Weight of the move.
+	Learn  uint32 // Learning data.
+}
+
+// String implements the Stringer interface.
+//
+// Returns the key in hexadecimal, the move in the Polyglot convention
+// where castling moves are encoded as the king taking its own rook,
+// the weight and the learning data.
+func (e BookEntry) String() string {
+	om, err := parseRawMove(e.Move, e.Weight)
+	move := om.s1.String() + om.s2.String()
+	switch {
+	case err != nil:
+		move = "????"
+	case om.promo != NoPieceType:
+		move += om.promo.String()
+	}
+
+	return fmt.Sprintf("%016x %s %d %d", uint64(e.Key), move, e.Weight, e.Learn)
+}
+
+// ReadBookEntries reads the raw entries of a Polyglot book.
+func ReadBookEntries(r io.Reader) ([]BookEntry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(scanEntries)
+
+	var entries []BookEntry
+	for scanner.Scan() {
+		data := scanner.Bytes()
+		entries = append(entries, BookEntry{
+			Key:    Hash(binary.BigEndian.Uint64(data[0:8])),
+			Move:   binary.BigEndian.Uint16(data[8:10]),
+			Weight: binary.BigEndian.Uint16(data[10:12]),
+			Learn:  binary.BigEndian.Uint32(data[12:16]),
+		})
+	}
+
+	return entries, scanner.Err()
+}
+
+// BookBuilder accumulates the weights of moves and builds Polyglot books.
+type BookBuilder struct {
+	weights map[bookKey]uint64
+}
+
+// bookKey represents a move in a position.
+type bookKey struct {
+	hash Hash
+	move uint16
+}
+
+// NewBookBuilder returns a new empty book builder.
+func NewBookBuilder() *BookBuilder {
+	return &BookBuilder{
+		weights: make(map[bookKey]uint64),
+	}
+}
+
+// Len returns the number of entries of the book, the moves with a positive weight.
+func (bb *BookBuilder) Len() int {
+	var n int
+	for _, w := range bb.weights {
+		if w > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// Add adds the weight to the move played in the position.
+//
+// Moves are added with a zero weight if the weight is not positive.
+func (bb *BookBuilder) Add(pos *Position, m Move, weight int) {
+	bb.weights[bookKey{pos.hash, polyglotMove(pos, m)}] += uint64(max(weight, 0))
+}
+
+// AddEntry adds the weight of a raw entry, for example to merge books.
+//
+// The learning data is not kept.
+func (bb *BookBuilder) AddEntry(e BookEntry) {
+	bb.weights[bookKey{e.Key, e.Move}] += uint64(e.Weight)
+}
+
+// Entries returns the entries of the book sorted by key,
+// then by decreasing weight.
+//
+// Moves with a zero weight are removed. Weights are scaled down
+// to fit in 16 bits if needed, keeping a minimum weight of 1.
+func (bb *BookBuilder) Entries() []BookEntry {
+	var maxWeight uint64
+	for _, w := range bb.weights {
+		maxWeight = max(maxWeight, w)
+	}
+
+	entries := make([]BookEntry, 0, len(bb.weights))
+	for k, w := range bb.weights {
+		if w == 0 {
+			continue
+		}
+
+		if maxWeight > math.MaxUint16 {
+			w = max(w*math.MaxUint16/maxWeight, 1)
+		}
+
+		entries = append(entries, BookEntry{Key: k.hash, Move: k.move, Weight: uint16(w)})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		switch a, b := entries[i], entries[j]; {
+		case a.Key != b.Key:
+			return a.Key < b.Key
+		case a.Weight != b.Weight:
+			return a.Weight > b.Weight
+		default:
+			return a.Move < b.Move
+		}
+	})
+
+	return entries
+}
+
+// WriteTo writes the book in the Polyglot format.
+//
+// Implements the io.WriterTo interface.
+func (bb *BookBuilder) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+
+	var n int64
+	var data [bookEntrySize]byte
+	for _, e := range bb.Entries() {
+		binary.BigEndian.PutUint64(data[0:8], uint64(e.Key))
+		binary.BigEndian.PutUint16(data[8:10], e.Move)
+		binary.BigEndian.PutUint16(data[10:12], e.Weight)
+		binary.BigEndian.PutUint32(data[12:16], e.Learn)
+
+		written, err := bw.Write(data[:])
+		n += int64(written)
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return n, bw.Flush()
+}
+
+// polyglotMove returns the Polyglot encoding of the move.
+//
+// Castling moves are encoded as the king taking its own rook.
+func polyglotMove(pos *Position, m Move) uint16 {
+	s2 := m.S2()
+	switch c := m.P1().Color(); {
+	case m.HasTag(ASideCastle):
+		s2 = newSquare(pos.castling.files[c][aSide], s2.Rank())
+	case m.HasTag(HSideCastle):
+		s2 = newSquare(pos.castling.files[c][hSide], s2.Rank())
+	}
+
+	var promo uint16
+	if m.HasTag(Promotion) {
+		promo = uint16(m.Promo().Type()) // promotion codes match the piece types
+	}
+
+	return uint16(s2) | uint16(m.S1())<<6 | promo<<12
+}
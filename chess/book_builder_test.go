@@ -0,0 +1,111 @@
+package chess
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	data "github.com/leonhfr/orca/test/data"
+)
+
+func TestBookBuilder(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		fen   string
+		moves []string
+		want  []string
+	}{
+		{startFEN, []string{"e2e4", "d2d4", "e2e4", "g1f3"}, []string{"e2e4 2", "g1f3 1", "d2d4 1"}},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"e1g1", "e1c1", "e1g1"}, []string{"e1g1 2", "e1c1 1"}},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", []string{"e8g8"}, []string{"e8g8 1"}},
+		{"7k/4P3/8/8/8/8/8/K7 w - - 0 1", []string{"e7e8q", "e7e8n", "e7e8q"}, []string{"e7e8q 2", "e7e8n 1"}},
+	}
+
+	bb := NewBookBuilder()
+	for _, tt := range tests {
+		pos := unsafeFEN(tt.fen)
+		for _, move := range tt.moves {
+			m, err := UCI{}.Decode(pos, move)
+			assert.NoError(t, err)
+			bb.Add(pos, m, 1)
+		}
+	}
+	assert.Equal(t, 8, bb.Len())
+
+	var buf bytes.Buffer
+	n, err := bb.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(8*bookEntrySize), n)
+
+	entries, err := ReadBookEntries(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, bb.Entries(), entries)
+
+	book, err := NewSortedBook(buf.Bytes())
+	assert.NoError(t, err)
+
+	for _, tt := range tests {
+		var got []string
+		for _, wm := range book.Lookup(unsafeFEN(tt.fen)) {
+			got = append(got, fmt.Sprintf("%v %d", wm.Move, wm.Weight))
+		}
+		assert.Equal(t, tt.want, got, tt.fen)
+	}
+}
+
+func TestBookBuilderEntries(t *testing.T) {
+	t.Parallel()
+	bb := NewBookBuilder()
+	bb.AddEntry(BookEntry{Key: 2, Move: 1, Weight: math.MaxUint16})
+	bb.AddEntry(BookEntry{Key: 2, Move: 1, Weight: math.MaxUint16})
+	bb.AddEntry(BookEntry{Key: 1, Move: 2, Weight: 1})
+	bb.AddEntry(BookEntry{Key: 1, Move: 3, Weight: 4})
+	bb.AddEntry(BookEntry{Key: 1, Move: 4, Weight: 0})
+
+	assert.Equal(t, []BookEntry{
+		{Key: 1, Move: 3, Weight: 2},
+		{Key: 1, Move: 2, Weight: 1},
+		{Key: 2, Move: 1, Weight: math.MaxUint16},
+	}, bb.Entries())
+}
+
+func TestBookBuilderMerge(t *testing.T) {
+	t.Parallel()
+	entries, err := ReadBookEntries(bytes.NewReader(data.LaskerTrap))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 7)
+
+	bb := NewBookBuilder()
+	for _, e := range entries {
+		bb.AddEntry(e)
+		bb.AddEntry(e)
+	}
+
+	for i, e := range bb.Entries() {
+		assert.Equal(t, entries[i].Key, e.Key)
+		assert.Equal(t, entries[i].Move, e.Move)
+		assert.Equal(t, 2*entries[i].Weight, e.Weight)
+	}
+}
+
+func TestBookEntryString(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		args BookEntry
+		want string
+	}{
+		{BookEntry{0x463b96181691fc9c, uint16(E4) | uint16(E2)<<6, 2, 0}, "463b96181691fc9c e2e4 2 0"},
+		{BookEntry{1, uint16(E8) | uint16(E7)<<6 | 4<<12, 1, 3}, "0000000000000001 e7e8q 1 3"},
+		{BookEntry{1, 7 << 12, 1, 0}, "0000000000000001 ???? 1 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, tt.args.String())
+		})
+	}
+}
@@ -2,10 +2,14 @@ package chess
 
 import (
 	"bytes"
+	"math"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/leonhfr/orca/data/books"
 	data "github.com/leonhfr/orca/test/data"
 )
 
@@ -41,3 +45,266 @@ func TestLookup(t *testing.T) {
 		}
 	}
 }
+
+func TestInitMalformed(t *testing.T) {
+	t.Parallel()
+	// promotion code 7 in the move field
+	entry := []byte{0, 0, 0, 0, 0, 0, 0, 1, 0x70, 0, 0, 1, 0, 0, 0, 0}
+
+	tests := []struct {
+		name string
+		args []byte
+		want string
+	}{
+		{"truncated", data.LaskerTrap[:20], "expected data to be multiple of 16 bytes"},
+		{"promotion code", entry, "invalid book entry (0): unknown promotion code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := NewBook().Init(bytes.NewReader(tt.args))
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+func TestNewSortedBook(t *testing.T) {
+	t.Parallel()
+	_, err := NewSortedBook(data.LaskerTrap[:20])
+	assert.Equal(t, errBookSize, err)
+
+	book, err := NewSortedBook(data.LaskerTrap)
+	if ok := assert.NoError(t, err); ok {
+		pos := unsafeFEN("rnbqk1nr/ppp2ppp/8/4P3/1bPp4/4P3/PP1B1PPP/RN1QKBNR b KQkq - 0 1")
+		moves := book.Lookup(pos)
+		if ok := assert.Len(t, moves, 1); ok {
+			assert.Equal(t, "d4e3", moves[0].Move.String())
+			assert.Equal(t, 2, moves[0].Weight)
+		}
+		assert.Nil(t, book.Lookup(StartingPosition()))
+		assert.NoError(t, book.Close())
+	}
+}
+
+func TestNewSortedBook_Unsorted(t *testing.T) {
+	t.Parallel()
+	unsorted := reverseEntries(data.LaskerTrap)
+	assert.False(t, sortedEntries(unsorted))
+
+	path := filepath.Join(t.TempDir(), "unsorted.bin")
+	assert.NoError(t, os.WriteFile(path, unsorted, 0o600))
+
+	parsed := NewBook()
+	assert.NoError(t, parsed.Init(bytes.NewReader(data.LaskerTrap)))
+
+	sorted, err := NewSortedBook(unsorted)
+	assert.NoError(t, err)
+	mapped, err := OpenBook(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer mapped.Close()
+
+	// every entry is found although binary search would miss some
+	assert.Equal(t, parsed.m, sorted.m)
+	assert.Equal(t, parsed.m, mapped.m)
+
+	_, err = OpenWritableBook(path)
+	assert.Equal(t, errBookUnsorted, err)
+}
+
+// reverseEntries returns a copy of the Polyglot data with the entries in reverse order.
+func reverseEntries(data []byte) []byte {
+	reversed := make([]byte, 0, len(data))
+	for i := len(data) - bookEntrySize; i >= 0; i -= bookEntrySize {
+		reversed = append(reversed, data[i:i+bookEntrySize]...)
+	}
+	return reversed
+}
+
+func TestOpenBook(t *testing.T) {
+	t.Parallel()
+	_, err := OpenBook("missing.bin")
+	assert.Error(t, err)
+
+	mapped, err := OpenBook("../data/books/performance.bin")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer mapped.Close()
+
+	sorted, err := NewSortedBook(books.Performance)
+	assert.NoError(t, err)
+
+	parsed := NewBook()
+	assert.NoError(t, parsed.Init(bytes.NewReader(books.Performance)))
+
+	// every backend returns the same legal moves for the positions of the book
+	var walk func(pos *Position, depth int)
+	walk = func(pos *Position, depth int) {
+		want := parsed.Lookup(pos)
+		assert.Equal(t, want, sorted.Lookup(pos), pos.String())
+		assert.Equal(t, want, mapped.Lookup(pos), pos.String())
+
+		if depth == 0 {
+			return
+		}
+
+		checkData, _ := pos.InCheck()
+		legal := pos.AppendLegalMoves(nil, checkData)
+		meta, hash, pawnHash := pos.Metadata(), pos.Hash(), pos.PawnHash()
+		for _, wm := range want {
+			if !assert.Contains(t, legal, wm.Move, pos.String()) {
+				continue
+			}
+			_ = pos.MakeMove(wm.Move)
+			walk(pos, depth-1)
+			pos.UnmakeMove(wm.Move, meta, hash, pawnHash)
+		}
+	}
+
+	walk(StartingPosition(), 3)
+}
+
+func TestOpenWritableBook(t *testing.T) {
+	t.Parallel()
+	_, err := OpenWritableBook("missing.bin")
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "book.bin")
+	pos := StartingPosition()
+	e2e4, _ := UCI{}.Decode(pos, "e2e4")
+	d2d4, _ := UCI{}.Decode(pos, "d2d4")
+	bb := NewBookBuilder()
+	bb.Add(pos, e2e4, 16)
+	bb.Add(pos, d2d4, 1)
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	_, err = bb.WriteTo(f)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	read, err := OpenBook(path)
+	assert.NoError(t, err)
+	assert.False(t, read.Writable())
+	assert.Equal(t, errBookReadOnly, read.Learn(pos, e2e4, LearnWin))
+	assert.NoError(t, read.Close())
+
+	book, err := OpenWritableBook(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, book.Writable())
+	assert.NoError(t, book.Learn(pos, e2e4, LearnWin))
+	assert.NoError(t, book.Learn(pos, e2e4, LearnDraw))
+	assert.NoError(t, book.Learn(pos, d2d4, LearnLoss))
+	g2g4, _ := UCI{}.Decode(pos, "g2g4")
+	assert.Equal(t, errBookMove, book.Learn(pos, g2g4, LearnWin))
+	assert.NoError(t, book.Close())
+
+	// the updates are written to the file
+	reopened, err := OpenBook(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer reopened.Close()
+
+	moves := reopened.Lookup(pos)
+	if assert.Len(t, moves, 2) {
+		assert.Equal(t, WeightedMove{Move: e2e4, Weight: 18, Learn: 2<<16 | 3}, moves[0])
+		assert.Equal(t, WeightedMove{Move: d2d4, Weight: 1, Learn: 1 << 16}, moves[1])
+		games, points := moves[0].Learned()
+		assert.Equal(t, 2, games)
+		assert.Equal(t, 3, points)
+	}
+}
+
+func TestLearnWeight(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		weight uint16
+		result LearnResult
+		want   uint16
+	}{
+		{16, LearnWin, 18},
+		{16, LearnDraw, 16},
+		{16, LearnLoss, 14},
+		{1, LearnWin, 2},
+		{1, LearnLoss, 1},
+		{math.MaxUint16, LearnWin, math.MaxUint16},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, learnWeight(tt.weight, tt.result))
+	}
+}
+
+func TestCastlingDestination(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		from, to Square
+		piece    Piece
+		want     Square
+	}{
+		{E1, H1, WhiteKing, G1},
+		{E1, A1, WhiteKing, C1},
+		{E8, H8, BlackKing, G8},
+		{E8, A8, BlackKing, C8},
+		{E8, H8, BlackRook, H8},
+		{E1, H1, WhiteQueen, H1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from.String()+tt.to.String(), func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, castlingDestination(tt.from, tt.to, tt.piece))
+		})
+	}
+}
+
+func BenchmarkBookLoad(b *testing.B) {
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			_ = NewBook().Init(bytes.NewReader(books.Performance))
+		}
+	})
+
+	b.Run("sorted", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			_, _ = NewSortedBook(books.Performance)
+		}
+	})
+
+	b.Run("mmap", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			book, _ := OpenBook("../data/books/performance.bin")
+			_ = book.Close()
+		}
+	})
+}
+
+func BenchmarkBookLookup(b *testing.B) {
+	parsed := NewBook()
+	_ = parsed.Init(bytes.NewReader(books.Performance))
+	sorted, _ := NewSortedBook(books.Performance)
+	pos := unsafeFEN("rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2")
+
+	for _, bb := range []struct {
+		name string
+		book *Book
+	}{
+		{"map", parsed},
+		{"sorted", sorted},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				_ = bb.book.Lookup(pos)
+			}
+		})
+	}
+}
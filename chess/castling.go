@@ -46,10 +46,34 @@ func (cr castlingRights) canCastle(c Color, s side) bool {
 
 // castling represents the rook files and the castling rights.
 type castling struct {
-	files  [2]File
+	files  castleFiles
 	rights castlingRights
 }
 
+// castleFiles represents the files of the castling rooks, indexed by color then side.
+//
+// Both colors share the same files, except in Double Fischer Random chess.
+type castleFiles [2][2]File
+
+// newCastleFiles returns the same castling rook files for both colors.
+func newCastleFiles(a, h File) castleFiles {
+	return castleFiles{{a, h}, {a, h}}
+}
+
+// chess960Setup returns, by color, whether the king or a castling rook
+// with castling rights stands off its file in classic chess.
+func (c castling) chess960Setup(kings [2]Square) [2]bool {
+	var setup [2]bool
+	for col := Black; col <= White; col++ {
+		for s := aSide; s <= hSide; s++ {
+			if c.rights.canCastle(col, s) && (kings[col].File() != FileE || c.files[col][s] != rookStartFile[s]) {
+				setup[col] = true
+			}
+		}
+	}
+	return setup
+}
+
 // String implements the Stringer interface.
 //
 // Returns a FEN compatible representation.
@@ -89,14 +113,14 @@ type castleCheck struct {
 }
 
 // newCastleCheck creates a new castle check.
-func newCastleCheck(c Color, s side, kings [2]Square, cf [2]File, cr castlingRights) castleCheck {
+func newCastleCheck(c Color, s side, kings [2]Square, cf castleFiles, cr castlingRights) castleCheck {
 	if !cr.canCastle(c, s) {
 		return castleCheck{}
 	}
 
 	king1 := kings[c]
 	king2 := newSquare(kingFinalFile[s], castleRank[c])
-	rook1 := newSquare(cf[s], castleRank[c])
+	rook1 := newSquare(cf[c][s], castleRank[c])
 	rook2 := newSquare(rookFinalFile[s], castleRank[c])
 
 	bbKingTravel := (bbInBetweens[king1][king2] | (king2.bitboard() & ^king1.bitboard())) & ^rook1.bitboard()
@@ -127,6 +151,7 @@ func newCastleCheck(c Color, s side, kings [2]Square, cf [2]File, cr castlingRig
 
 var (
 	kingFinalFile = [2]File{FileC, FileG} // indexed by side.
+	rookStartFile = [2]File{FileA, FileH} // indexed by side, in classic chess.
 	rookFinalFile = [2]File{FileD, FileF} // indexed by side.
 	castleRank    = [2]Rank{Rank8, Rank1} // indexed by color.
 )
@@ -13,9 +13,9 @@ func TestCastling_String(t *testing.T) {
 		args castling
 		want string
 	}{
-		{castling{[2]File{FileA, FileH}, 0}, "-"},
-		{castling{[2]File{FileA, FileH}, castleWhiteH | castleWhiteA}, "KQ"},
-		{castling{[2]File{FileA, FileH}, castleWhiteH | castleWhiteA | castleBlackH | castleBlackA}, "KQkq"},
+		{castling{newCastleFiles(FileA, FileH), 0}, "-"},
+		{castling{newCastleFiles(FileA, FileH), castleWhiteH | castleWhiteA}, "KQ"},
+		{castling{newCastleFiles(FileA, FileH), castleWhiteH | castleWhiteA | castleBlackH | castleBlackA}, "KQkq"},
 	}
 
 	for _, tt := range tests {
@@ -32,7 +32,7 @@ func TestNewCastleCheck(t *testing.T) {
 		c     Color
 		s     side
 		kings [2]Square
-		cf    [2]File
+		cf    castleFiles
 		cr    castlingRights
 	}
 
@@ -47,7 +47,7 @@ func TestNewCastleCheck(t *testing.T) {
 		{
 			"Chess960 580",
 			"qbb1rkrn/1ppppppp/p7/7n/8/P2P4/1PP1PPPP/QBBRNKRN w Gg - 0 9",
-			args{White, hSide, [2]Square{F8, F1}, [2]File{FileD, FileG}, castleWhiteH},
+			args{White, hSide, [2]Square{F8, F1}, newCastleFiles(FileD, FileG), castleWhiteH},
 			castleCheck{
 				bbKingTravel:    bbEmpty,
 				bbRookTravel:    bbEmpty,
@@ -64,7 +64,7 @@ func TestNewCastleCheck(t *testing.T) {
 		{
 			"Chess960 865",
 			"bqkr1rnn/1ppp1ppp/p4b2/4p3/P7/3PP2N/1PP2PPP/BQRBKR1N w FC - 3 9",
-			args{White, hSide, [2]Square{C8, E1}, [2]File{FileC, FileF}, castleWhiteA | castleWhiteH},
+			args{White, hSide, [2]Square{C8, E1}, newCastleFiles(FileC, FileF), castleWhiteA | castleWhiteH},
 			castleCheck{
 				bbKingTravel:    G1.bitboard(),
 				bbRookTravel:    bbEmpty,
@@ -81,7 +81,7 @@ func TestNewCastleCheck(t *testing.T) {
 		{
 			"Chess960 877",
 			"qrk1rnb1/p1pp1ppp/1p2Bbn1/8/4P3/6P1/PPPP1P1P/QRK1RNBN w EBeb - 1 9",
-			args{White, aSide, [2]Square{C8, C1}, [2]File{FileB, FileE}, all},
+			args{White, aSide, [2]Square{C8, C1}, newCastleFiles(FileB, FileE), all},
 			castleCheck{
 				bbKingTravel:    bbEmpty,
 				bbRookTravel:    D1.bitboard(),
@@ -98,7 +98,7 @@ func TestNewCastleCheck(t *testing.T) {
 		{
 			"Chess960 944",
 			"b2krn1q/p1rppppp/1Q3n2/2p1b3/1P4P1/8/P1PPPP1P/BBRKRNN1 w - - 3 9",
-			args{White, aSide, [2]Square{D8, D1}, [2]File{FileC, FileE}, castleBlackH | castleWhiteA | castleWhiteH},
+			args{White, aSide, [2]Square{D8, D1}, newCastleFiles(FileC, FileE), castleBlackH | castleWhiteA | castleWhiteH},
 			castleCheck{
 				bbKingTravel:    bbEmpty,
 				bbRookTravel:    bbEmpty,
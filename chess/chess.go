@@ -46,28 +46,187 @@ func (pos *Position) HasInsufficientMaterial() bool {
 //
 // Some moves may be putting the moving player's king in check and therefore be illegal.
 func (pos *Position) PseudoMoves(data CheckData) []Move {
+	return pos.AppendPseudoMoves(make([]Move, 0, 50), data)
+}
+
+// AppendPseudoMoves appends the pseudo moves to moves and returns the extended slice.
+//
+// Does not allocate when moves has enough capacity, use a MoveList to hold them.
+//
+// Some moves may be putting the moving player's king in check and therefore be illegal.
+func (pos *Position) AppendPseudoMoves(moves []Move, data CheckData) []Move {
+	if data > 0 {
+		return pos.AppendEvasionMoves(moves, data)
+	}
+	return pos.pseudoMoves(moves, bbFull, false, genAll)
+}
+
+// LoudMoves returns the list of pseudo loud moves.
+// Loud moves are moves that capture an opponent piece.
+//
+// Some moves may be putting the moving player's king in check and therefore be illegal.
+func (pos *Position) LoudMoves() []Move {
+	return pos.AppendLoudMoves(make([]Move, 0, 20))
+}
+
+// AppendLoudMoves appends the pseudo loud moves to moves and returns the extended slice.
+//
+// Does not allocate when moves has enough capacity, use a MoveList to hold them.
+func (pos *Position) AppendLoudMoves(moves []Move) []Move {
+	return pos.pseudoMoves(moves, bbFull, false, genCaptures)
+}
+
+// CaptureMoves returns the list of pseudo captures and queen promotions.
+//
+// Expects the king not to be in check, use EvasionMoves otherwise.
+// Together with QuietMoves, it generates the same moves as PseudoMoves.
+//
+// Some moves may be putting the moving player's king in check and therefore be illegal.
+func (pos *Position) CaptureMoves() []Move {
+	return pos.AppendCaptureMoves(make([]Move, 0, 20))
+}
+
+// AppendCaptureMoves appends the pseudo captures and queen promotions to moves
+// and returns the extended slice.
+//
+// Does not allocate when moves has enough capacity, use a MoveList to hold them.
+func (pos *Position) AppendCaptureMoves(moves []Move) []Move {
+	return pos.pseudoMoves(moves, bbFull, false, genCaptures|genPromotions)
+}
+
+// QuietMoves returns the list of pseudo quiet moves, castles, and under promotions
+// that do not capture an opponent piece.
+//
+// Expects the king not to be in check, use EvasionMoves otherwise.
+// Together with CaptureMoves, it generates the same moves as PseudoMoves.
+//
+// Some moves may be putting the moving player's king in check and therefore be illegal.
+func (pos *Position) QuietMoves() []Move {
+	return pos.AppendQuietMoves(make([]Move, 0, 50))
+}
+
+// AppendQuietMoves appends the pseudo quiet moves, castles, and under promotions
+// to moves and returns the extended slice.
+//
+// Does not allocate when moves has enough capacity, use a MoveList to hold them.
+func (pos *Position) AppendQuietMoves(moves []Move) []Move {
+	return pos.pseudoMoves(moves, bbFull, false, genQuiets)
+}
+
+// EvasionMoves returns the list of pseudo moves that may get the king out of check.
+//
+// Expects the king to be in check.
+//
+// Some moves may be putting the moving player's king in check and therefore be illegal.
+func (pos *Position) EvasionMoves(data CheckData) []Move {
+	return pos.AppendEvasionMoves(make([]Move, 0, 20), data)
+}
+
+// AppendEvasionMoves appends the pseudo moves that may get the king out of check
+// to moves and returns the extended slice.
+//
+// Does not allocate when moves has enough capacity, use a MoveList to hold them.
+func (pos *Position) AppendEvasionMoves(moves []Move, data CheckData) []Move {
 	bbAttackedBy := bitboard(data)
 	switch count := bbAttackedBy.ones(); {
 	case count > 1:
-		return pos.pseudoMoves(bbFull, true, false)
+		return pos.pseudoMoves(moves, bbFull, true, genAll)
 	case count == 1:
 		s1 := bbAttackedBy.scanForward()
 		s2 := pos.board.sqKings[pos.turn]
 		bbInterference := bbInBetweens[s1][s2] | bbAttackedBy
-		return pos.pseudoMoves(bbInterference, false, false)
+		return pos.pseudoMoves(moves, bbInterference, false, genAll)
 	default:
-		return pos.pseudoMoves(bbFull, false, false)
+		return pos.pseudoMoves(moves, bbFull, false, genAll)
 	}
 }
 
-// LoudMoves returns the list of pseudo loud moves.
-// Loud moves are moves that capture an opponent piece.
+// AppendLegalMoves appends the legal moves to moves and returns the extended slice.
 //
-// Some moves may be putting the moving player's king in check and therefore be illegal.
-func (pos *Position) LoudMoves() []Move {
-	return pos.pseudoMoves(bbFull, false, true)
+// Does not allocate when moves has enough capacity, use a MoveList to hold them.
+func (pos *Position) AppendLegalMoves(moves []Move, data CheckData) []Move {
+	start := len(moves)
+	moves = pos.AppendPseudoMoves(moves, data)
+	bbPinned := pos.pinnedBitboard()
+
+	legal := moves[:start]
+	for _, m := range moves[start:] {
+		if pos.isLegal(m, bbPinned) {
+			legal = append(legal, m)
+		}
+	}
+
+	return legal
+}
+
+// IsPseudoLegal checks whether the move is a pseudo move in the position.
+//
+// Expects the king not to be in check. Informative tags such as Check are
+// ignored. Intended to validate moves coming from other positions, such as
+// the hash move or killer moves, before generating the full move list.
+func (pos *Position) IsPseudoLegal(m Move) bool {
+	p1, p2 := m.P1(), m.P2()
+	s1, s2 := m.S1(), m.S2()
+	if m == NoMove || p1 == NoPiece || p1.Color() != pos.turn || pos.board.pieceAt(s1) != p1 {
+		return false
+	}
+
+	if m.HasTag(ASideCastle ^ HSideCastle) {
+		s := aSide
+		if m.HasTag(HSideCastle) {
+			s = hSide
+		}
+		bbOccupancy := pos.board.bbColors[White] ^ pos.board.bbColors[Black]
+		cc := pos.castleChecks[2*uint8(pos.turn)+uint8(s)]
+		return pos.castling.rights.canCastle(pos.turn, s) &&
+			cc.king1 == s1 && cc.king2 == s2 &&
+			bbOccupancy&cc.bbKingTravel == 0 &&
+			bbOccupancy&cc.bbRookTravel == 0
+	}
+
+	if m.HasTag(EnPassant) {
+		return p1.Type() == Pawn && s2 == pos.enPassant &&
+			singlePawnCaptureBitboard(s1, pos.turn)&s2.bitboard() > 0
+	}
+
+	if pos.board.pieceAt(s2) != p2 || (p2 != NoPiece && (p2.Color() == pos.turn || p2.Type() == King)) {
+		return false
+	}
+
+	bbOccupancy := pos.board.bbColors[White] ^ pos.board.bbColors[Black]
+	s2bb := s2.bitboard()
+
+	switch pt := p1.Type(); pt {
+	case Pawn:
+		if (s2bb&(bbRank1^bbRank8) > 0) != (m.Promo() != NoPiece) {
+			return false
+		}
+		if p2 != NoPiece {
+			return singlePawnCaptureBitboard(s1, pos.turn)&s2bb > 0
+		}
+		bbUpOne, bbUpTwo := pawnMoveBitboard(s1.bitboard(), bbOccupancy, pos.turn)
+		return (bbUpOne|bbUpTwo)&s2bb > 0
+	case King:
+		return m.Promo() == NoPiece && bbKingMoves[s1]&s2bb > 0
+	default:
+		return m.Promo() == NoPiece && pieceBitboard(s1, pt, bbOccupancy)&s2bb > 0
+	}
 }
 
+// moveGen represents the kinds of pseudo moves to generate.
+type moveGen uint8
+
+const (
+	// genCaptures generates captures, including en passant and capture promotions.
+	genCaptures moveGen = 1 << iota
+	// genPromotions generates queen promotions that do not capture.
+	genPromotions
+	// genQuiets generates quiet moves, castles and under promotions that do not capture.
+	genQuiets
+	// genAll generates all pseudo moves.
+	genAll = genCaptures | genPromotions | genQuiets
+)
+
 // pseudoMoves returns the pseudo moves depending on some options.
 //
 // bbInterference passes the bitboard in which all pieces (except the king) must move to.
@@ -77,14 +236,10 @@ func (pos *Position) LoudMoves() []Move {
 // onlyKing returns only the king moves, bypassing all others. Use it when the king is
 // in double check.
 //
-// loud returns only moves that capture enemy pieces. Use it in quiescence search.
-func (pos *Position) pseudoMoves(bbInterference bitboard, onlyKing, loud bool) []Move {
-	size := 50
-	if loud {
-		size = 20
-	}
-	moves := make([]Move, 0, size)
-
+// gen determines the kinds of moves returned. Use genCaptures in quiescence search.
+//
+// The moves are appended to the passed slice.
+func (pos *Position) pseudoMoves(moves []Move, bbInterference bitboard, onlyKing bool, gen moveGen) []Move {
 	// Setting up variables
 	player, opponent := pos.turn, pos.turn.Other()
 	pawn := WhitePawn
@@ -102,12 +257,18 @@ func (pos *Position) pseudoMoves(bbInterference bitboard, onlyKing, loud bool) [
 	}
 	bbPawn := pos.board.bbPieces[Pawn] & bbPlayer
 
+	// Target squares of the pieces depending on the kinds of moves
+	bbTarget := ^bbPlayer
+	switch {
+	case gen&genCaptures == 0:
+		bbTarget &= ^bbOpponent
+	case gen&genQuiets == 0:
+		bbTarget &= bbOpponent
+	}
+
 	// King moves
 	sqKing, sqEnemyKing := pos.board.sqKings[player], pos.board.sqKings[opponent]
-	bbKing := bbKingMoves[sqKing] & ^bbKingMoves[sqEnemyKing] & ^bbPlayer
-	if loud {
-		bbKing &= bbOpponent
-	}
+	bbKing := bbKingMoves[sqKing] & ^bbKingMoves[sqEnemyKing] & bbTarget
 	for bbs2 := bbKing; bbs2 > 0; bbs2 = bbs2.resetLSB() {
 		s2, p2 := bbs2.scanForward(), NoPiece
 		if s2.bitboard()&bbOpponent > 0 {
@@ -123,7 +284,7 @@ func (pos *Position) pseudoMoves(bbInterference bitboard, onlyKing, loud bool) [
 	bbChecks := pos.attackBitboards(sqEnemyKing, opponent)
 
 	// Castles
-	if !loud {
+	if gen&genQuiets > 0 {
 		if cc := pos.castleChecks[2*uint8(player)+uint8(hSide)]; pos.castling.rights.canCastle(player, hSide) &&
 			bbOccupancy&cc.bbKingTravel == 0 &&
 			bbOccupancy&cc.bbRookTravel == 0 {
@@ -139,8 +300,17 @@ func (pos *Position) pseudoMoves(bbInterference bitboard, onlyKing, loud bool) [
 	}
 
 	// Pawn moves
-	if !loud {
+	if gen&(genPromotions|genQuiets) > 0 {
 		bbUpOne, bbUpTwo := pawnMoveBitboard(bbPawn, bbOccupancy, player)
+		bbPromo := bbRank1 ^ bbRank8
+		switch {
+		case gen&genQuiets == 0:
+			bbUpOne &= bbPromo
+			bbUpTwo = bbEmpty
+		case gen&genPromotions == 0:
+			bbUpOne &= ^bbPromo
+		}
+
 		for _, dest := range [2]bbDir{
 			{bbUpOne & bbInterference, upOne},
 			{bbUpTwo & bbInterference, upTwo},
@@ -150,54 +320,61 @@ func (pos *Position) pseudoMoves(bbInterference bitboard, onlyKing, loud bool) [
 				s1 := s2 - Square(dest.dir)
 				s2bb := s2.bitboard()
 
-				if s2bb&(bbRank1^bbRank8) == 0 {
+				if s2bb&bbPromo == 0 {
 					check := s2bb&bbChecks[Pawn] > 0
 					moves = append(moves, newPawnMove(pawn, NoPiece, s1, s2, NoSquare, NoPiece, check))
 					continue
 				}
 
-				moves = append(moves,
-					newPawnMove(pawn, NoPiece, s1, s2, NoSquare, Queen.color(player), s2bb&bbChecks[Queen] > 0),
-					newPawnMove(pawn, NoPiece, s1, s2, NoSquare, Rook.color(player), s2bb&bbChecks[Rook] > 0),
-					newPawnMove(pawn, NoPiece, s1, s2, NoSquare, Bishop.color(player), s2bb&bbChecks[Bishop] > 0),
-					newPawnMove(pawn, NoPiece, s1, s2, NoSquare, Knight.color(player), s2bb&bbChecks[Knight] > 0),
-				)
+				if gen&genPromotions > 0 {
+					moves = append(moves, newPawnMove(pawn, NoPiece, s1, s2, NoSquare, Queen.color(player), s2bb&bbChecks[Queen] > 0))
+				}
+
+				if gen&genQuiets > 0 {
+					moves = append(moves,
+						newPawnMove(pawn, NoPiece, s1, s2, NoSquare, Rook.color(player), s2bb&bbChecks[Rook] > 0),
+						newPawnMove(pawn, NoPiece, s1, s2, NoSquare, Bishop.color(player), s2bb&bbChecks[Bishop] > 0),
+						newPawnMove(pawn, NoPiece, s1, s2, NoSquare, Knight.color(player), s2bb&bbChecks[Knight] > 0),
+					)
+				}
 			}
 		}
 	}
 
 	// Pawn captures
-	bbCaptureR, bbCaptureL := pawnCaptureBitboard(bbPawn, player)
-	bbEnPassant := pos.enPassant.bitboard()
+	if gen&genCaptures > 0 {
+		bbCaptureR, bbCaptureL := pawnCaptureBitboard(bbPawn, player)
+		bbEnPassant := pos.enPassant.bitboard()
+
+		bbPawnInterference := bbInterference
+		if pos.enPassant != NoSquare &&
+			(bbInterference&pos.board.bbPieces[Pawn]).scanForward()+Square(upOne) == pos.enPassant {
+			bbPawnInterference |= bbEnPassant
+		}
 
-	bbPawnInterference := bbInterference
-	if pos.enPassant != NoSquare &&
-		(bbInterference&pos.board.bbPieces[Pawn]).scanForward()+Square(upOne) == pos.enPassant {
-		bbPawnInterference |= bbEnPassant
-	}
+		for _, dest := range [2]bbDir{
+			{bbCaptureR & (bbOpponent | bbEnPassant) & bbPawnInterference, captureR},
+			{bbCaptureL & (bbOpponent | bbEnPassant) & bbPawnInterference, captureL},
+		} {
+			for ; dest.bb > 0; dest.bb = dest.bb.resetLSB() {
+				s2 := dest.bb.scanForward()
+				s1 := s2 - Square(dest.dir)
+				p2 := pos.board.pieceByColor(s2, opponent)
+				s2bb := s2.bitboard()
 
-	for _, dest := range [2]bbDir{
-		{bbCaptureR & (bbOpponent | bbEnPassant) & bbPawnInterference, captureR},
-		{bbCaptureL & (bbOpponent | bbEnPassant) & bbPawnInterference, captureL},
-	} {
-		for ; dest.bb > 0; dest.bb = dest.bb.resetLSB() {
-			s2 := dest.bb.scanForward()
-			s1 := s2 - Square(dest.dir)
-			p2 := pos.board.pieceByColor(s2, opponent)
-			s2bb := s2.bitboard()
-
-			if s2bb&(bbRank1^bbRank8) == 0 {
-				check := s2bb&bbChecks[Pawn] > 0
-				moves = append(moves, newPawnMove(pawn, p2, s1, s2, pos.enPassant, NoPiece, check))
-				continue
-			}
+				if s2bb&(bbRank1^bbRank8) == 0 {
+					check := s2bb&bbChecks[Pawn] > 0
+					moves = append(moves, newPawnMove(pawn, p2, s1, s2, pos.enPassant, NoPiece, check))
+					continue
+				}
 
-			moves = append(moves,
-				newPawnMove(pawn, p2, s1, s2, NoSquare, Queen.color(player), s2bb&bbChecks[Queen] > 0),
-				newPawnMove(pawn, p2, s1, s2, NoSquare, Rook.color(player), s2bb&bbChecks[Rook] > 0),
-				newPawnMove(pawn, p2, s1, s2, NoSquare, Bishop.color(player), s2bb&bbChecks[Bishop] > 0),
-				newPawnMove(pawn, p2, s1, s2, NoSquare, Knight.color(player), s2bb&bbChecks[Knight] > 0),
-			)
+				moves = append(moves,
+					newPawnMove(pawn, p2, s1, s2, NoSquare, Queen.color(player), s2bb&bbChecks[Queen] > 0),
+					newPawnMove(pawn, p2, s1, s2, NoSquare, Rook.color(player), s2bb&bbChecks[Rook] > 0),
+					newPawnMove(pawn, p2, s1, s2, NoSquare, Bishop.color(player), s2bb&bbChecks[Bishop] > 0),
+					newPawnMove(pawn, p2, s1, s2, NoSquare, Knight.color(player), s2bb&bbChecks[Knight] > 0),
+				)
+			}
 		}
 	}
 
@@ -206,10 +383,7 @@ func (pos *Position) pseudoMoves(bbInterference bitboard, onlyKing, loud bool) [
 		p1 := pt.color(player)
 		for bbs1 := pos.board.bbPieces[pt] & bbPlayer; bbs1 > 0; bbs1 = bbs1.resetLSB() {
 			s1 := bbs1.scanForward()
-			bbs2 := pieceBitboard(s1, pt, bbOccupancy) & ^bbPlayer & bbInterference
-			if loud {
-				bbs2 &= bbOpponent
-			}
+			bbs2 := pieceBitboard(s1, pt, bbOccupancy) & bbTarget & bbInterference
 			for ; bbs2 > 0; bbs2 = bbs2.resetLSB() {
 				s2, p2 := bbs2.scanForward(), NoPiece
 				if s2.bitboard()&bbOpponent > 0 {
@@ -276,6 +450,47 @@ func (pos *Position) attackBitboards(sq Square, c Color) [5]bitboard {
 	}
 }
 
+// isLegal checks whether the pseudo move is legal.
+//
+// Moves of pieces that are not pinned are legal without further checks,
+// since pseudo moves already handle checks with the interference bitboard.
+// King moves, en passant and moves of pinned pieces are made on the board.
+func (pos *Position) isLegal(m Move, bbPinned bitboard) bool {
+	switch {
+	case m.HasTag(ASideCastle^HSideCastle) && !pos.isCastleLegal(m):
+		return false
+	case m.P1().Type() != King && !m.HasTag(EnPassant) && m.S1().bitboard()&bbPinned == 0:
+		return true
+	}
+
+	pos.board.makeMove(m, pos.castling.files)
+	legal := !pos.isSquareAttacked(pos.board.sqKings[pos.turn])
+	pos.board.unmakeMove(m, pos.castling.files)
+	return legal
+}
+
+// pinnedBitboard returns the bitboard of the pieces of the player to move
+// that are pinned to their king.
+func (pos *Position) pinnedBitboard() bitboard {
+	sq := pos.board.sqKings[pos.turn]
+	bbPlayer, bbOpponent := pos.board.bbColors[pos.turn], pos.board.bbColors[pos.turn.Other()]
+
+	// x-ray through the player's pieces
+	bbRookXRay := bbMagicRookMoves[rookMagics[sq].index(bbOpponent)]
+	bbBishopXRay := bbMagicBishopMoves[bishopMagics[sq].index(bbOpponent)]
+	bbSnipers := bbOpponent & (bbRookXRay&(pos.board.bbPieces[Rook]|pos.board.bbPieces[Queen]) |
+		bbBishopXRay&(pos.board.bbPieces[Bishop]|pos.board.bbPieces[Queen]))
+
+	var bbPinned bitboard
+	for ; bbSnipers > 0; bbSnipers = bbSnipers.resetLSB() {
+		if bb := bbInBetweens[sq][bbSnipers.scanForward()] & bbPlayer; bb.ones() == 1 {
+			bbPinned |= bb
+		}
+	}
+
+	return bbPinned
+}
+
 // isCastleLegal checks whether the castle move is legal.
 //
 // Assumes that the castle rights have already been checked and
@@ -298,8 +513,9 @@ func (pos *Position) isCastleLegal(m Move) bool {
 		return false
 	}
 
+	// the king and the castling rook do not block attacks on the travel squares
 	var bbBishopAttacks, bbRookAttacks bitboard
-	bbOccupancy := pos.board.bbColors[White] ^ pos.board.bbColors[Black]
+	bbOccupancy := (pos.board.bbColors[White] ^ pos.board.bbColors[Black]) & ^cc.king1.bitboard() & ^cc.rook1.bitboard()
 	for bb := cc.bbNoCheck; bb > 0; bb = bb.resetLSB() {
 		sq := bb.scanForward()
 		bbBishopAttacks |= bbMagicBishopMoves[bishopMagics[sq].index(bbOccupancy)]
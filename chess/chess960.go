@@ -0,0 +1,222 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errNotChess960Position = errors.New("not a chess960 starting position")
+
+// chess960Positions is the number of Chess960 starting positions.
+const chess960Positions = 960
+
+// chess960Knights contains the placements of the knights on the five remaining
+// squares of the back rank, indexed by the knights part of the Scharnagl index.
+var chess960Knights = [10][2]int{
+	{0, 1}, {0, 2}, {0, 3}, {0, 4}, {1, 2},
+	{1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4},
+}
+
+// Chess960Position returns the Chess960 starting position with the given
+// Scharnagl index, from 0 to 959.
+//
+// The index 518 is the classical starting position.
+func Chess960Position(n int) (*Position, error) {
+	return DoubleChess960Position(n, n)
+}
+
+// DoubleChess960Position returns the Double Fischer Random starting position
+// where the white and black back ranks are set up independently from their
+// Scharnagl indexes, from 0 to 959.
+func DoubleChess960Position(white, black int) (*Position, error) {
+	whiteRank, err := chess960BackRank(white)
+	if err != nil {
+		return nil, err
+	}
+
+	blackRank, err := chess960BackRank(black)
+	if err != nil {
+		return nil, err
+	}
+
+	blackPieces, blackRights := chess960Fields(blackRank, Black)
+	whitePieces, whiteRights := chess960Fields(whiteRank, White)
+
+	return ShredderFEN{}.Decode(fmt.Sprintf(
+		"%s/pppppppp/8/8/8/8/PPPPPPPP/%s w %s%s - 0 1",
+		blackPieces, whitePieces, whiteRights, blackRights,
+	))
+}
+
+// chess960Fields returns the FEN representation of the back rank
+// and the Shredder-FEN castling rights of the color.
+func chess960Fields(rank [8]PieceType, c Color) (string, string) {
+	var pieces string
+	var rooks []File
+	for f, pt := range rank {
+		pieces += pt.color(c).String()
+		if pt == Rook {
+			rooks = append(rooks, File(f))
+		}
+	}
+
+	rights := rooks[1].String() + rooks[0].String()
+	if c == White {
+		rights = strings.ToUpper(rights)
+	}
+
+	return pieces, rights
+}
+
+// Chess960Index returns the Scharnagl index of a Chess960 starting position.
+//
+// Returns an error if the position is not a starting position or if the
+// white and black back ranks differ.
+func Chess960Index(pos *Position) (int, error) {
+	white, black, err := DoubleChess960Index(pos)
+	if err != nil {
+		return 0, err
+	}
+
+	if white != black {
+		return 0, errNotChess960Position
+	}
+
+	return white, nil
+}
+
+// DoubleChess960Index returns the Scharnagl indexes of the white and black
+// back ranks of a Double Fischer Random starting position.
+//
+// Returns an error if the position is not a starting position.
+func DoubleChess960Index(pos *Position) (white, black int, err error) {
+	white, err = chess960Index(pos.board, Rank1)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	black, err = chess960Index(pos.board, Rank8)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// the rest of the position must match: pawns, castling rights, turn
+	expected, err := DoubleChess960Position(white, black)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if expected.board != pos.board ||
+		expected.castling != pos.castling ||
+		expected.turn != pos.turn ||
+		pos.enPassant != NoSquare {
+		return 0, 0, errNotChess960Position
+	}
+
+	return white, black, nil
+}
+
+// chess960BackRank returns the back rank of the Chess960 starting position
+// with the given Scharnagl index.
+func chess960BackRank(n int) ([8]PieceType, error) {
+	var rank [8]PieceType
+	if n < 0 || chess960Positions <= n {
+		return rank, fmt.Errorf("invalid chess960 index (%d), must be between 0 and %d", n, chess960Positions-1)
+	}
+
+	for f := range rank {
+		rank[f] = NoPieceType
+	}
+
+	// light squared bishop on files b, d, f, h
+	rank[2*(n%4)+1] = Bishop
+	n /= 4
+
+	// dark squared bishop on files a, c, e, g
+	rank[2*(n%4)] = Bishop
+	n /= 4
+
+	// queen on one of the six remaining squares
+	rank[emptyFile(rank, n%6)] = Queen
+	n /= 6
+
+	// knights on two of the five remaining squares
+	knights := chess960Knights[n]
+	rank[emptyFile(rank, knights[1])] = Knight
+	rank[emptyFile(rank, knights[0])] = Knight
+
+	// rook, king and rook on the three remaining squares
+	rank[emptyFile(rank, 0)] = Rook
+	rank[emptyFile(rank, 0)] = King
+	rank[emptyFile(rank, 0)] = Rook
+
+	return rank, nil
+}
+
+// chess960Index returns the Scharnagl index of the back rank.
+func chess960Index(b board, r Rank) (int, error) {
+	var rank [8]PieceType
+	var bishops [2]int
+	for f := FileA; f <= FileH; f++ {
+		rank[f] = b.pieceAt(newSquare(f, r)).Type()
+		if rank[f] == Bishop {
+			bishops[f%2] = int(f / 2)
+		}
+	}
+
+	// the queen is indexed among the squares without bishops,
+	// the knights among the squares without bishops nor queen
+	var queen, knights []int
+	var empty int
+	for _, pt := range rank {
+		switch pt {
+		case Bishop:
+			continue
+		case Queen:
+			queen = append(queen, empty)
+		}
+		empty++
+	}
+
+	empty = 0
+	for _, pt := range rank {
+		switch pt {
+		case Bishop, Queen:
+			continue
+		case Knight:
+			knights = append(knights, empty)
+		}
+		empty++
+	}
+
+	if len(queen) != 1 || len(knights) != 2 {
+		return 0, errNotChess960Position
+	}
+
+	for i, k := range chess960Knights {
+		if k[0] == knights[0] && k[1] == knights[1] {
+			n := ((i*6+queen[0])*4+bishops[0])*4 + bishops[1]
+			if expected, _ := chess960BackRank(n); expected != rank {
+				return 0, errNotChess960Position
+			}
+			return n, nil
+		}
+	}
+
+	return 0, errNotChess960Position
+}
+
+// emptyFile returns the file of the n-th empty square of the rank.
+func emptyFile(rank [8]PieceType, n int) int {
+	for f, pt := range rank {
+		if pt != NoPieceType {
+			continue
+		}
+		if n == 0 {
+			return f
+		}
+		n--
+	}
+	return -1
+}
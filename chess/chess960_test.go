@@ -0,0 +1,133 @@
+package chess
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChess960Position(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		args int
+		want string
+	}{
+		{0, "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w HFhf - 0 1"},
+		{1, "bqnbnrkr/pppppppp/8/8/8/8/PPPPPPPP/BQNBNRKR w HFhf - 0 1"},
+		{2, "bqnnrbkr/pppppppp/8/8/8/8/PPPPPPPP/BQNNRBKR w HEhe - 0 1"},
+		{518, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1"},
+		{959, "rkrnnqbb/pppppppp/8/8/8/8/PPPPPPPP/RKRNNQBB w CAca - 0 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+			pos, err := Chess960Position(tt.args)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, ShredderFEN{}.Encode(pos))
+
+			n, err := Chess960Index(pos)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.args, n)
+		})
+	}
+}
+
+func TestChess960PositionInvalid(t *testing.T) {
+	t.Parallel()
+	for _, n := range []int{-1, 960} {
+		_, err := Chess960Position(n)
+		assert.NotNil(t, err)
+
+		_, err = DoubleChess960Position(518, n)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestChess960Index(t *testing.T) {
+	t.Parallel()
+	seen := make(map[string]bool)
+	for n := range chess960Positions {
+		pos, err := Chess960Position(n)
+		assert.Nil(t, err)
+
+		fen := ShredderFEN{}.Encode(pos)
+		assert.False(t, seen[fen], fen)
+		seen[fen] = true
+
+		index, err := Chess960Index(pos)
+		assert.Nil(t, err)
+		assert.Equal(t, n, index)
+	}
+}
+
+func TestChess960IndexInvalid(t *testing.T) {
+	t.Parallel()
+	tests := []string{
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b HAha e3 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR b HAha - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w Aha - 0 1",
+		"rbnqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RBNQKBNR w HAha - 0 1",
+		"bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAhf - 0 1",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			t.Parallel()
+			pos, err := ShredderFEN{}.Decode(tt)
+			assert.Nil(t, err)
+
+			_, err = Chess960Index(pos)
+			assert.Equal(t, errNotChess960Position, err)
+		})
+	}
+}
+
+func TestDoubleChess960Position(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		white, black int
+		want         string
+	}{
+		{518, 518, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1"},
+		{518, 0, "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAhf - 0 1"},
+		{959, 2, "bqnnrbkr/pppppppp/8/8/8/8/PPPPPPPP/RKRNNQBB w CAhe - 0 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+			pos, err := DoubleChess960Position(tt.white, tt.black)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, ShredderFEN{}.Encode(pos))
+
+			white, black, err := DoubleChess960Index(pos)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.white, white)
+			assert.Equal(t, tt.black, black)
+		})
+	}
+}
+
+func TestDoubleChess960Castling(t *testing.T) {
+	t.Parallel()
+	// white rooks on b1 and g1, black rooks on a8 and h8
+	pos, err := ShredderFEN{}.Decode("r3k2r/8/8/8/8/8/6P1/1R2K1R1 w GBha - 0 1")
+	assert.Nil(t, err)
+
+	moves := []struct {
+		move string
+		want string
+	}{
+		{"e1b1", "r3k2r/8/8/8/8/8/6P1/2KR2R1 b ha - 1 1"},
+		{"e8h8", "r4rk1/8/8/8/8/8/6P1/2KR2R1 w - - 2 2"},
+	}
+
+	for _, tt := range moves {
+		m, err := UCIChess960{}.Decode(pos, tt.move)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.move, UCIChess960{}.Encode(pos, m))
+		assert.True(t, pos.MakeMove(m))
+		assert.Equal(t, tt.want, ShredderFEN{}.Encode(pos))
+	}
+}
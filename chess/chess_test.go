@@ -153,6 +153,38 @@ func BenchmarkPseudoMoves(b *testing.B) {
 	}
 }
 
+func TestAppendPseudoMoves(t *testing.T) {
+	for _, tt := range perftResults {
+		t.Run(tt.fen, func(t *testing.T) {
+			pos := unsafeFEN(tt.fen)
+			checkData, _ := pos.InCheck()
+			want := pos.PseudoMoves(checkData)
+
+			var ml MoveList
+			var got []Move
+			allocs := testing.AllocsPerRun(10, func() {
+				got = pos.AppendPseudoMoves(ml[:0], checkData)
+			})
+
+			assert.Equal(t, want, got)
+			assert.Zero(t, allocs)
+		})
+	}
+}
+
+func BenchmarkAppendPseudoMoves(b *testing.B) {
+	for _, bb := range testPositions {
+		pos := unsafeFEN(bb.preFEN)
+		checkData, _ := pos.InCheck()
+		var ml MoveList
+		b.Run(bb.preFEN, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				pos.AppendPseudoMoves(ml[:0], checkData)
+			}
+		})
+	}
+}
+
 func TestLoudMoves(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -208,6 +240,102 @@ func BenchmarkLoudMoves(b *testing.B) {
 	}
 }
 
+func TestCaptureAndQuietMoves(t *testing.T) {
+	t.Parallel()
+	for _, tt := range perftResults {
+		t.Run(tt.fen, func(t *testing.T) {
+			t.Parallel()
+			pos := unsafeFEN(tt.fen)
+			checkData, inCheck := pos.InCheck()
+			if inCheck {
+				t.Skip("expects the king not to be in check")
+			}
+			want := pos.PseudoMoves(checkData)
+			captures := pos.CaptureMoves()
+			quiets := pos.QuietMoves()
+
+			for _, move := range captures {
+				assert.True(t, move.HasTag(Capture) || move.Promo().Type() == Queen, move.String())
+			}
+			for _, move := range quiets {
+				assert.False(t, move.HasTag(Capture), move.String())
+			}
+			assert.ElementsMatch(t, want, append(captures, quiets...))
+		})
+	}
+}
+
+func TestEvasionMoves(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		fen  string
+		want []string
+	}{
+		{"8/8/8/5K2/7k/8/8/7R b - - 0 1", []string{"h4g3"}},
+		{"8/8/8/5K1k/8/8/8/7R b - - 0 1", []string{}},
+		{"rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", []string{}},
+		{"4k3/8/8/8/1b6/8/2P5/4K3 w - - 0 1", []string{
+			"c2c3", "e1d1", "e1e2", "e1f1", "e1f2",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fen, func(t *testing.T) {
+			t.Parallel()
+			pos := unsafeFEN(tt.fen)
+			checkData, inCheck := pos.InCheck()
+			assert.True(t, inCheck)
+			got := []string{}
+			for _, move := range pos.EvasionMoves(checkData) {
+				if ok := pos.MakeMove(move); ok {
+					got = append(got, move.String())
+				}
+				pos = unsafeFEN(tt.fen)
+			}
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
+func TestIsPseudoLegal(t *testing.T) {
+	t.Parallel()
+	var fens []string
+	for _, tt := range perftResults {
+		if _, inCheck := unsafeFEN(tt.fen).InCheck(); !inCheck {
+			fens = append(fens, tt.fen)
+		}
+	}
+
+	for _, fen := range fens {
+		t.Run(fen, func(t *testing.T) {
+			t.Parallel()
+			pos := unsafeFEN(fen)
+			generated := make(map[Move]bool)
+			for _, move := range pos.PseudoMoves(0) {
+				generated[move&(1<<24-1)] = true
+			}
+
+			for _, other := range fens {
+				for _, move := range unsafeFEN(other).PseudoMoves(0) {
+					want := generated[move&(1<<24-1)]
+					assert.Equal(t, want, pos.IsPseudoLegal(move), "%s from %s", move.String(), other)
+				}
+			}
+			assert.False(t, pos.IsPseudoLegal(NoMove))
+		})
+	}
+}
+
+func BenchmarkIsPseudoLegal(b *testing.B) {
+	pos := unsafeFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	moves := pos.PseudoMoves(0)
+	for n := 0; n < b.N; n++ {
+		for _, move := range moves {
+			pos.IsPseudoLegal(move)
+		}
+	}
+}
+
 func TestAttackedByBitboard(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -51,13 +51,14 @@ func (FEN) Decode(s string) (*Position, error) {
 		return nil, err
 	}
 
-	files := [2]File{FileA, FileH}
+	files := newCastleFiles(FileA, FileH)
 	rights, err := fenCastlingRights(fields[2])
 	if err != nil {
 		return nil, err
 	}
 
 	pos.castling = castling{files, rights}
+	pos.chess960 = pos.castling.chess960Setup(pos.board.sqKings)
 
 	for c := Black; c <= White; c++ {
 		for s := aSide; s <= hSide; s++ {
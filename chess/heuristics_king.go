@@ -31,6 +31,82 @@ func (pos *Position) KingMap(cb func(p Piece, sq Square, shieldDefects, openFile
 	}
 }
 
+// KingAttack holds the attacks of the enemy on the zone around a king.
+//
+// The king zone is made of the king square, the squares next to it
+// and the squares in front of those, toward the enemy.
+type KingAttack struct {
+	Attackers   int    // Number of enemy knights, bishops, rooks and queens attacking the king zone.
+	Attacks     [6]int // Attacked squares of the king zone, summed over the enemy pieces. Indexed by piece type.
+	SafeChecks  [6]int // Checks of the enemy pieces to squares not defended by the king side. Indexed by piece type.
+	WeakSquares int    // Squares of the king zone attacked by the enemy and defended at most by the king or queen.
+}
+
+// KingAttacks returns the attacks of the enemy on the zone around the king of the color.
+//
+// Intended to be used in evaluation functions.
+func (pos *Position) KingAttacks(c Color) KingAttack {
+	op := c.Other()
+	sq := pos.board.sqKings[c]
+	bbZone := kingZone(sq, c)
+	bbOccupancy := pos.board.bbColors[White] ^ pos.board.bbColors[Black]
+
+	var bbDefended bitboard // not counting the king and queen
+	for pt := Pawn; pt <= Rook; pt++ {
+		bbDefended |= pos.attackedSquares(c, pt, bbOccupancy)
+	}
+	bbSafe := ^(bbDefended | pos.attackedSquares(c, Queen, bbOccupancy) | bbKingMoves[sq]) &
+		^pos.board.bbColors[op]
+	bbChecks := pos.attackBitboards(sq, c)
+
+	var ka KingAttack
+	bbPawnAttacks := pos.attackedSquares(op, Pawn, bbOccupancy)
+	bbAttacked := bbPawnAttacks | bbKingMoves[pos.board.sqKings[op]]
+	ka.Attacks[Pawn] = (bbPawnAttacks & bbZone).ones()
+
+	for pt := Knight; pt <= Queen; pt++ {
+		bbPiece := pos.board.bbColors[op] & pos.board.bbPieces[pt]
+		for ; bbPiece > 0; bbPiece = bbPiece.resetLSB() {
+			bbAttacks := pieceBitboard(bbPiece.scanForward(), pt, bbOccupancy)
+			bbAttacked |= bbAttacks
+
+			if bbZoneAttacks := bbAttacks & bbZone; bbZoneAttacks > 0 {
+				ka.Attackers++
+				ka.Attacks[pt] += bbZoneAttacks.ones()
+			}
+
+			ka.SafeChecks[pt] += (bbAttacks & bbChecks[pt] & bbSafe).ones()
+		}
+	}
+
+	ka.WeakSquares = (bbZone & bbAttacked & ^bbDefended).ones()
+
+	return ka
+}
+
+// kingZone returns the zone around the king of the color on the square.
+func kingZone(sq Square, c Color) bitboard {
+	bb := sq.bitboard() | bbKingMoves[sq]
+	if c == White {
+		return bb | bb.northOne()
+	}
+	return bb | bb.southOne()
+}
+
+// attackedSquares returns the squares attacked by the pieces of the color and type.
+func (pos *Position) attackedSquares(c Color, pt PieceType, bbOccupancy bitboard) bitboard {
+	bbPiece := pos.board.bbColors[c] & pos.board.bbPieces[pt]
+	if pt == Pawn {
+		return bbPiece.eastAttack(c) | bbPiece.westAttack(c)
+	}
+
+	var bb bitboard
+	for ; bbPiece > 0; bbPiece = bbPiece.resetLSB() {
+		bb |= pieceBitboard(bbPiece.scanForward(), pt, bbOccupancy)
+	}
+	return bb
+}
+
 // fileData computes open files and half open files, indexed by color.
 func (pos *Position) fileData() (bitboard, [2]bitboard) {
 	bbBlackPawn := pos.board.bbColors[Black] & pos.board.bbPieces[Pawn]
@@ -57,6 +57,54 @@ func TestKingMap(t *testing.T) {
 	}
 }
 
+func TestKingAttacks(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		fen  string
+		want [2]KingAttack // indexed by color
+	}{
+		{
+			"starting position",
+			startFEN,
+			[2]KingAttack{},
+		},
+		{
+			"knight and queen",
+			"6k1/5ppp/8/6N1/8/8/5PPP/3Q2K1 w - - 0 1",
+			[2]KingAttack{
+				{Attackers: 1, Attacks: [6]int{0, 2, 0, 0, 0, 0}, SafeChecks: [6]int{0, 0, 0, 0, 1, 0}, WeakSquares: 2},
+				{},
+			},
+		},
+		{
+			"defended checks",
+			"4r1k1/5ppp/8/6N1/8/8/5PPP/3Q2K1 w - - 0 1",
+			[2]KingAttack{
+				{Attackers: 1, Attacks: [6]int{0, 2, 0, 0, 0, 0}, WeakSquares: 2},
+				{},
+			},
+		},
+		{
+			"pawn storm",
+			"6k1/5p1p/5PpB/8/8/8/8/6K1 w - - 0 1",
+			[2]KingAttack{
+				{Attackers: 1, Attacks: [6]int{1, 0, 2, 0, 0, 0}, WeakSquares: 2},
+				{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pos := unsafeFEN(tt.fen)
+			assert.Equal(t, tt.want[Black], pos.KingAttacks(Black))
+			assert.Equal(t, tt.want[White], pos.KingAttacks(White))
+		})
+	}
+}
+
 func BenchmarkKingMap(b *testing.B) {
 	for _, bb := range kingPieceMapTests {
 		b.Run(bb.name, func(b *testing.B) {
@@ -30,6 +30,15 @@ const (
 	// A pawn is backward if its stop square is not in its own front attack spans
 	// but is controlled by an enemy sentry.
 	Backward
+	// Phalanx represents a pawn with a friendly pawn next to it on the same rank.
+	Phalanx
+	// Supported represents a pawn defended by a friendly pawn.
+	Supported
+	// Candidate represents a candidate passed pawn.
+	// A candidate is not passed but has no opponent pawns in front on the same file,
+	// and at least as many friendly pawns on adjacent files level or behind it
+	// as opponent sentries on adjacent files in front of it.
+	Candidate
 )
 
 // HasProperty checks the presence of the given property.
@@ -49,6 +58,9 @@ func (pos *Position) PawnMap(cb func(p Piece, sq Square, properties PawnProperty
 		bbIsolanis, bbHalfIsolanis := isolatedPawns(bbPlayerPawn)
 		bbPassed := passedPawns(c, bbPlayerPawn, bbOpponentPawn)
 		bbBackward := backwardPawns(c, bbPlayerPawn, bbOpponentPawn)
+		bbPhalanx := phalanxPawns(bbPlayerPawn)
+		bbSupported := supportedPawns(c, bbPlayerPawn)
+		bbCandidates := candidatePawns(c, bbPlayerPawn, bbOpponentPawn, bbPassed)
 
 		for pawn := Pawn.color(c); bbPlayerPawn > 0; bbPlayerPawn = bbPlayerPawn.resetLSB() {
 			sq := bbPlayerPawn.scanForward()
@@ -73,6 +85,18 @@ func (pos *Position) PawnMap(cb func(p Piece, sq Square, properties PawnProperty
 				properties ^= Backward
 			}
 
+			if bb&bbPhalanx > 0 {
+				properties ^= Phalanx
+			}
+
+			if bb&bbSupported > 0 {
+				properties ^= Supported
+			}
+
+			if bb&bbCandidates > 0 {
+				properties ^= Candidate
+			}
+
 			cb(pawn, sq, properties)
 		}
 	}
@@ -112,6 +136,32 @@ func backwardPawns(c Color, bbPlayerPawn, bbOpponentPawns bitboard) bitboard {
 	return bbIntersection.southOne()
 }
 
+func phalanxPawns(bbPlayerPawn bitboard) bitboard {
+	return bbPlayerPawn & (bbPlayerPawn.eastOne() | bbPlayerPawn.westOne())
+}
+
+func supportedPawns(c Color, bbPlayerPawn bitboard) bitboard {
+	return bbPlayerPawn & (bbPlayerPawn.eastAttack(c) | bbPlayerPawn.westAttack(c))
+}
+
+func candidatePawns(c Color, bbPlayerPawn, bbOpponentPawn, bbPassed bitboard) bitboard {
+	bbOpen := bbPlayerPawn & ^bbOpponentPawn.frontSpans(c.Other()) & ^bbPassed
+
+	var bbCandidates bitboard
+	for ; bbOpen > 0; bbOpen = bbOpen.resetLSB() {
+		bb := bbOpen.scanForward().bitboard()
+		front := bb.frontSpans(c)
+		bbSentries := bbOpponentPawn & (front.eastOne() | front.westOne())
+		rear := bb.rearSpans(c) | bb
+		bbHelpers := bbPlayerPawn & (rear.eastOne() | rear.westOne())
+		if bbHelpers.ones() >= bbSentries.ones() {
+			bbCandidates |= bb
+		}
+	}
+
+	return bbCandidates
+}
+
 func (b bitboard) noNeighborOnEastFile() bitboard {
 	return b & ^b.westAttackFileFill()
 }
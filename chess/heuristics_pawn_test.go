@@ -20,14 +20,14 @@ var pawnPieceMapTests = []struct {
 		name: "starting position",
 		fen:  startFEN,
 		pieces: map[Square]pawnCallbackArgs{
-			A7: {BlackPawn, HalfIsolani}, B7: {BlackPawn, NoPawnProperty},
-			C7: {BlackPawn, NoPawnProperty}, D7: {BlackPawn, NoPawnProperty},
-			E7: {BlackPawn, NoPawnProperty}, F7: {BlackPawn, NoPawnProperty},
-			G7: {BlackPawn, NoPawnProperty}, H7: {BlackPawn, HalfIsolani},
-			A2: {WhitePawn, HalfIsolani}, B2: {WhitePawn, NoPawnProperty},
-			C2: {WhitePawn, NoPawnProperty}, D2: {WhitePawn, NoPawnProperty},
-			E2: {WhitePawn, NoPawnProperty}, F2: {WhitePawn, NoPawnProperty},
-			G2: {WhitePawn, NoPawnProperty}, H2: {WhitePawn, HalfIsolani},
+			A7: {BlackPawn, HalfIsolani ^ Phalanx}, B7: {BlackPawn, Phalanx},
+			C7: {BlackPawn, Phalanx}, D7: {BlackPawn, Phalanx},
+			E7: {BlackPawn, Phalanx}, F7: {BlackPawn, Phalanx},
+			G7: {BlackPawn, Phalanx}, H7: {BlackPawn, HalfIsolani ^ Phalanx},
+			A2: {WhitePawn, HalfIsolani ^ Phalanx}, B2: {WhitePawn, Phalanx},
+			C2: {WhitePawn, Phalanx}, D2: {WhitePawn, Phalanx},
+			E2: {WhitePawn, Phalanx}, F2: {WhitePawn, Phalanx},
+			G2: {WhitePawn, Phalanx}, H2: {WhitePawn, HalfIsolani ^ Phalanx},
 		},
 	},
 	{
@@ -35,11 +35,11 @@ var pawnPieceMapTests = []struct {
 		fen:  "4k3/p1p3p1/3p3p/1P5P/1PP1P1P1/8/8/4K3 w - - 0 1",
 		pieces: map[Square]pawnCallbackArgs{
 			A7: {BlackPawn, Isolani ^ Backward}, C7: {BlackPawn, HalfIsolani},
-			D6: {BlackPawn, HalfIsolani}, G7: {BlackPawn, HalfIsolani},
-			H6: {BlackPawn, HalfIsolani},
-			B5: {WhitePawn, HalfIsolani ^ Doubled}, B4: {WhitePawn, HalfIsolani ^ Doubled},
-			C4: {WhitePawn, HalfIsolani}, E4: {WhitePawn, Isolani ^ Backward},
-			G4: {WhitePawn, HalfIsolani}, H5: {WhitePawn, HalfIsolani},
+			D6: {BlackPawn, HalfIsolani ^ Supported}, G7: {BlackPawn, HalfIsolani},
+			H6: {BlackPawn, HalfIsolani ^ Supported},
+			B5: {WhitePawn, HalfIsolani ^ Doubled ^ Supported}, B4: {WhitePawn, HalfIsolani ^ Doubled ^ Phalanx},
+			C4: {WhitePawn, HalfIsolani ^ Phalanx}, E4: {WhitePawn, Isolani ^ Backward},
+			G4: {WhitePawn, HalfIsolani}, H5: {WhitePawn, HalfIsolani ^ Supported},
 		},
 	},
 	{
@@ -48,9 +48,20 @@ var pawnPieceMapTests = []struct {
 		pieces: map[Square]pawnCallbackArgs{
 			D4: {BlackPawn, Isolani ^ Passed}, F5: {BlackPawn, Isolani},
 			H6: {BlackPawn, Isolani ^ Backward},
-			B5: {WhitePawn, HalfIsolani ^ Passed}, C4: {WhitePawn, HalfIsolani ^ Passed},
-			E5: {WhitePawn, HalfIsolani ^ Passed}, F4: {WhitePawn, NoPawnProperty},
-			G4: {WhitePawn, NoPawnProperty}, H5: {WhitePawn, HalfIsolani},
+			B5: {WhitePawn, HalfIsolani ^ Passed ^ Supported}, C4: {WhitePawn, HalfIsolani ^ Passed},
+			E5: {WhitePawn, HalfIsolani ^ Passed ^ Supported}, F4: {WhitePawn, Phalanx},
+			G4: {WhitePawn, Phalanx}, H5: {WhitePawn, HalfIsolani ^ Supported},
+		},
+	},
+	{
+		name: "phalanx, supported, candidate",
+		fen:  "4k3/1p3p2/p5p1/8/1PP3PP/P7/8/4K3 w - - 0 1",
+		pieces: map[Square]pawnCallbackArgs{
+			A6: {BlackPawn, HalfIsolani ^ Supported}, B7: {BlackPawn, HalfIsolani},
+			F7: {BlackPawn, HalfIsolani}, G6: {BlackPawn, HalfIsolani ^ Supported},
+			A3: {WhitePawn, HalfIsolani}, B4: {WhitePawn, Phalanx ^ Supported},
+			C4: {WhitePawn, HalfIsolani ^ Phalanx ^ Candidate}, G4: {WhitePawn, HalfIsolani ^ Phalanx},
+			H4: {WhitePawn, HalfIsolani ^ Phalanx ^ Candidate},
 		},
 	},
 }
@@ -17,6 +17,15 @@ func (pos *Position) CountOwnPieces() int {
 	return (pos.board.bbColors[pos.turn] & bbPieces).ones()
 }
 
+// CountColorPieces returns the count of knights, bishops, rooks, and queens of the color.
+func (pos *Position) CountColorPieces(c Color) int {
+	bbPieces := pos.board.bbPieces[Knight] ^
+		pos.board.bbPieces[Bishop] ^
+		pos.board.bbPieces[Rook] ^
+		pos.board.bbPieces[Queen]
+	return (pos.board.bbColors[c] & bbPieces).ones()
+}
+
 // FileData contains data on half open and open files for a particular pawn structure.
 type FileData [3]bitboard
 
@@ -60,6 +69,17 @@ const (
 	Trapped PieceProperty = 1 << iota
 	// Lost represents a lost piece. It should be impossible for the piece to escape.
 	Lost
+	// Outpost represents a knight or bishop in the enemy half of the board,
+	// defended by a friendly pawn and out of reach of the enemy pawns.
+	Outpost
+	// AttackedByPawn represents a piece attacked by an enemy pawn.
+	AttackedByPawn
+	// Threatened represents a piece attacked by an enemy piece of lower value, pawns excluded.
+	Threatened
+	// Hanging represents a piece attacked by the enemy and not defended.
+	Hanging
+	// Connected represents a rook defended by a friendly rook.
+	Connected
 )
 
 // HasProperty checks the presence of the given property.
@@ -76,6 +96,17 @@ func (pp PieceProperty) HasProperty(p PieceProperty) bool {
 func (pos *Position) PieceMap(cb func(p Piece, sq Square, mobility int, properties PieceProperty)) {
 	bbOccupancy := pos.board.bbColors[Black] | pos.board.bbColors[White]
 
+	var bbAttacks [2][6]bitboard // indexed by color and piece type
+	var bbAllAttacks [2]bitboard
+	for c := Black; c <= White; c++ {
+		for pt := Pawn; pt <= Queen; pt++ {
+			bbAttacks[c][pt] = pos.attackedSquares(c, pt, bbOccupancy)
+			bbAllAttacks[c] |= bbAttacks[c][pt]
+		}
+		bbAttacks[c][King] = bbKingMoves[pos.board.sqKings[c]]
+		bbAllAttacks[c] |= bbAttacks[c][King]
+	}
+
 	for p := BlackKnight; p <= WhiteQueen; p++ {
 		c, op := p.Color(), p.Color().Other()
 		pt := p.Type()
@@ -97,11 +128,57 @@ func (pos *Position) PieceMap(cb func(p Piece, sq Square, mobility int, properti
 				}
 			}
 
+			if bb&bbAttacks[op][Pawn] > 0 {
+				properties ^= AttackedByPawn
+			}
+
+			if bb&lowerValueAttacks(bbAttacks[op], pt) > 0 {
+				properties ^= Threatened
+			}
+
+			if bb&bbAllAttacks[op] > 0 && bb&bbAllAttacks[c] == 0 {
+				properties ^= Hanging
+			}
+
+			if (pt == Knight || pt == Bishop) && pos.isOutpost(bb, c) {
+				properties ^= Outpost
+			}
+
+			if pt == Rook && pieceBitboard(sq, Rook, bbOccupancy)&pos.board.bbColors[c]&pos.board.bbPieces[Rook] > 0 {
+				properties ^= Connected
+			}
+
 			cb(p, sq, mobility, properties)
 		}
 	}
 }
 
+// lowerValueAttacks returns the squares attacked by the pieces of lower value than the piece type,
+// pawns excluded.
+func lowerValueAttacks(bbAttacks [6]bitboard, pt PieceType) bitboard {
+	switch pt {
+	case Rook:
+		return bbAttacks[Knight] | bbAttacks[Bishop]
+	case Queen:
+		return bbAttacks[Knight] | bbAttacks[Bishop] | bbAttacks[Rook]
+	default:
+		return bbEmpty
+	}
+}
+
+// isOutpost checks whether the square is an outpost for the color.
+func (pos *Position) isOutpost(bb bitboard, c Color) bool {
+	bbPlayerPawn := pos.board.bbColors[c] & pos.board.bbPieces[Pawn]
+	bbOpponentPawn := pos.board.bbColors[c.Other()] & pos.board.bbPieces[Pawn]
+	bbSpans := bbOpponentPawn.frontSpans(c.Other())
+	bbOpponentAttackSpans := bbSpans.eastOne() | bbSpans.westOne()
+	bbDefended := bbPlayerPawn.eastAttack(c) | bbPlayerPawn.westAttack(c)
+	return bb&outpostRanks[c]&bbDefended&^bbOpponentAttackSpans > 0
+}
+
+// outpostRanks contains the ranks of the outposts, indexed by color.
+var outpostRanks = [2]bitboard{bbRank3 | bbRank4 | bbRank5, bbRank4 | bbRank5 | bbRank6}
+
 // pieceMobility computes the mobility of the piece.
 //
 // May include illegal moves.
@@ -21,6 +21,13 @@ func TestCountPieces(t *testing.T) {
 	assert.Equal(t, 2, queens)
 }
 
+func TestCountColorPieces(t *testing.T) {
+	t.Parallel()
+	pos := unsafeFEN("4k3/8/8/8/8/8/8/RN2KQ2 w - - 0 1")
+	assert.Equal(t, 0, pos.CountColorPieces(Black))
+	assert.Equal(t, 3, pos.CountColorPieces(White))
+}
+
 var pieceMapTests = []struct {
 	name string
 	fen  string
@@ -73,6 +80,55 @@ func TestPieceMap(t *testing.T) {
 	}
 }
 
+func TestPieceMap_Properties(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		fen        string
+		properties map[Square]PieceProperty
+	}{
+		{
+			name: "starting position",
+			fen:  startFEN,
+			properties: map[Square]PieceProperty{
+				A8: NoPieceProperty, B8: NoPieceProperty, C8: NoPieceProperty, D8: NoPieceProperty,
+				F8: NoPieceProperty, G8: NoPieceProperty, H8: NoPieceProperty,
+				A1: NoPieceProperty, B1: NoPieceProperty, C1: NoPieceProperty, D1: NoPieceProperty,
+				F1: NoPieceProperty, G1: NoPieceProperty, H1: NoPieceProperty,
+			},
+		},
+		{
+			name: "outposts and connected rooks",
+			fen:  "3r1rk1/pp3ppp/8/3N4/2Pb4/8/PP3PPP/3RR1K1 w - - 0 1",
+			properties: map[Square]PieceProperty{
+				D8: Connected, F8: Connected, D4: Hanging,
+				D5: Outpost, D1: Connected, E1: Connected,
+			},
+		},
+		{
+			name: "threats",
+			fen:  "4k3/8/2n2q2/1P4B1/8/5N2/8/4K2R b - - 0 1",
+			properties: map[Square]PieceProperty{
+				C6: AttackedByPawn, F6: Threatened ^ Hanging,
+				G5: NoPieceProperty, F3: Hanging, H1: NoPieceProperty,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pos := unsafeFEN(tt.fen)
+			var pieces int
+			pos.PieceMap(func(_ Piece, sq Square, _ int, properties PieceProperty) {
+				pieces++
+				assert.Equal(t, tt.properties[sq], properties, sq.String())
+			})
+			assert.Equal(t, len(tt.properties), pieces)
+		})
+	}
+}
+
 func BenchmarkPieceMap(b *testing.B) {
 	for _, bb := range pieceMapTests {
 		b.Run(bb.name, func(b *testing.B) {
@@ -48,6 +48,14 @@ type Move uint64
 // NoMove represents the absence of a move.
 const NoMove Move = 0
 
+// MaxMoves is the maximum number of pseudo moves in a position.
+const MaxMoves = 256
+
+// MoveList is a fixed size buffer that holds the pseudo moves of a position.
+//
+// Pass ml[:0] to the Append methods of Position to generate moves without allocating.
+type MoveList [MaxMoves]Move
+
 // newMove creates a new move.
 //
 // Expects the classic chess castling convention (king jumps two squares to castle).
@@ -85,14 +93,14 @@ func newMove(p1, p2 Piece, s1, s2, enPassant Square, promo Piece) Move {
 // Expects the Chess960 castling convention (king takes own rook).
 //
 // Example: e1h1 for H side white castle in the classic position.
-func newChess960Move(p1, p2 Piece, s1, s2, enPassant Square, promo Piece, files [2]File) Move {
+func newChess960Move(p1, p2 Piece, s1, s2, enPassant Square, promo Piece, files castleFiles) Move {
 	var tags MoveTag
 	switch pt, rook := p1.Type(), Rook.color(p1.Color()); {
-	case pt == King && p2 == rook && s2.File() == files[aSide]:
+	case pt == King && p2 == rook && s2.File() == files[p1.Color()][aSide]:
 		tags ^= ASideCastle
 		p2 = NoPiece
 		s2 = newSquare(kingFinalFile[aSide], s1.Rank())
-	case pt == King && p2 == rook && s2.File() == files[hSide]:
+	case pt == King && p2 == rook && s2.File() == files[p1.Color()][hSide]:
 		tags ^= HSideCastle
 		p2 = NoPiece
 		s2 = newSquare(kingFinalFile[hSide], s1.Rank())
@@ -229,8 +237,10 @@ func (m Move) Score() uint32 {
 }
 
 // WithScore returns a new move with the score set.
+//
+// Any previous score is replaced.
 func (m Move) WithScore(score uint32) Move {
-	return (Move(score) << 32) ^ m
+	return (Move(score) << 32) ^ (m & (1<<32 - 1))
 }
 
 // String implements the Stringer interface.
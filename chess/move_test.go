@@ -52,3 +52,12 @@ func TestNewMove_Invalid(t *testing.T) {
 		})
 	}
 }
+
+func TestMoveWithScore(t *testing.T) {
+	t.Parallel()
+	move := newPieceMove(WhiteKnight, NoPiece, G1, F3, false)
+	scored := move.WithScore(150)
+	assert.Equal(t, uint32(150), scored.Score())
+	assert.Equal(t, uint32(42), scored.WithScore(42).Score())
+	assert.Equal(t, move, scored.WithScore(0))
+}
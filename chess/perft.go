@@ -4,81 +4,362 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
 )
 
 // PerftResult contains the result of a perft test.
 type PerftResult struct {
 	moves []perftMove
+	stats PerftStats
 	nodes int
 }
 
+// Nodes returns the number of leaf nodes.
+func (pr PerftResult) Nodes() int {
+	return pr.nodes
+}
+
+// Stats returns the per-category counters of the leaf nodes.
+//
+// Only computed when the perft test runs with WithPerftStats.
+func (pr PerftResult) Stats() PerftStats {
+	return pr.stats
+}
+
+// PerftStats contains the per-category counters of the leaf nodes of a perft test,
+// as found in the standard perft result tables.
+type PerftStats struct {
+	Captures         int // Moves that capture a piece, including en passant.
+	EnPassants       int // En passant captures.
+	Castles          int // Castles.
+	Promotions       int // Promotions, including capture promotions.
+	Checks           int // Moves that put the enemy king in check.
+	DiscoveredChecks int // Single checks given by another piece than the one that moved.
+	DoubleChecks     int // Checks given by two pieces.
+	Checkmates       int // Moves that mate the enemy king.
+}
+
+// add adds the counters of other to the stats.
+func (ps *PerftStats) add(other PerftStats) {
+	ps.Captures += other.Captures
+	ps.EnPassants += other.EnPassants
+	ps.Castles += other.Castles
+	ps.Promotions += other.Promotions
+	ps.Checks += other.Checks
+	ps.DiscoveredChecks += other.DiscoveredChecks
+	ps.DoubleChecks += other.DoubleChecks
+	ps.Checkmates += other.Checkmates
+}
+
 // perfMove contains a single move and the number of its descendant nodes.
 type perftMove struct {
 	move  Move
+	stats PerftStats
 	nodes int
 }
 
+// PerftOption represents a perft option.
+type PerftOption func(*perftOptions)
+
+// perftOptions holds the perft options.
+type perftOptions struct {
+	hashSize int
+	workers  int
+	stats    bool
+}
+
+// WithPerftHash enables a hash table of the given size in MB.
+//
+// The hash table is ignored when the counters are computed.
+func WithPerftHash(size int) PerftOption {
+	return func(o *perftOptions) {
+		o.hashSize = size
+	}
+}
+
+// WithPerftWorkers sets the number of goroutines that divide the root moves.
+func WithPerftWorkers(workers int) PerftOption {
+	return func(o *perftOptions) {
+		o.workers = workers
+	}
+}
+
+// WithPerftStats enables the per-category counters.
+//
+// Bulk counting and the hash table are disabled since every leaf must be visited.
+func WithPerftStats() PerftOption {
+	return func(o *perftOptions) {
+		o.stats = true
+	}
+}
+
 // Perft performs a perft test.
-func (pos *Position) Perft(depth int) PerftResult {
-	meta := pos.Metadata()
-	hash := pos.Hash()
-	pawnHash := pos.PawnHash()
+//
+// By default, leaf nodes are bulk counted on a single goroutine.
+func (pos *Position) Perft(depth int, options ...PerftOption) PerftResult {
+	opts := perftOptions{workers: 1}
+	for _, fn := range options {
+		fn(&opts)
+	}
+
+	depth = max(depth, 1)
+
+	var table *perftTable
+	if opts.hashSize > 0 && !opts.stats {
+		table = newPerftTable(opts.hashSize)
+	}
 
-	var result PerftResult
+	var ml MoveList
 	checkData, _ := pos.InCheck()
-	for _, m := range pos.PseudoMoves(checkData) {
-		if ok := pos.MakeMove(m); ok {
-			nodes := perft(pos, depth-1)
-			result.moves = append(result.moves, perftMove{
-				move:  m,
-				nodes: nodes,
-			})
-			result.nodes += nodes
-			pos.UnmakeMove(m, meta, hash, pawnHash)
-		}
+	roots := pos.AppendLegalMoves(ml[:0], checkData)
+	moves := make([]perftMove, len(roots))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range max(opts.workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := newPerftWorker(*pos, depth, table)
+			for i := range jobs {
+				moves[i] = w.divide(roots[i], depth, opts.stats)
+			}
+		}()
+	}
+
+	for i := range roots {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	result := PerftResult{moves: moves}
+	for _, m := range moves {
+		result.nodes += m.nodes
+		result.stats.add(m.stats)
 	}
 
 	sort.Slice(result.moves, func(i, j int) bool {
-		return strings.Compare(result.moves[i].String(), result.moves[j].String()) < 0
+		return strings.Compare(result.moves[i].move.String(), result.moves[j].move.String()) < 0
 	})
 
 	return result
 }
 
-// perft returns the number of nodes until the given depth.
-func perft(pos *Position, depth int) int {
-	meta := pos.Metadata()
-	hash := pos.Hash()
-	pawnHash := pos.PawnHash()
+// perftWorker walks the tree of a position.
+type perftWorker struct {
+	pos   Position
+	table *perftTable
+	stack []MoveList // move lists of each ply so that the recursion does not allocate
+}
 
+// newPerftWorker returns a new perft worker that owns a copy of the position.
+func newPerftWorker(pos Position, depth int, table *perftTable) *perftWorker {
+	return &perftWorker{
+		pos:   pos,
+		table: table,
+		stack: make([]MoveList, depth+1),
+	}
+}
+
+// divide returns the number of nodes of which the root move is an ancestor.
+func (w *perftWorker) divide(m Move, depth int, stats bool) perftMove {
+	result := perftMove{move: m}
+
+	if stats && depth == 1 {
+		result.nodes = 1
+		w.record(m, 1, &result.stats)
+		return result
+	}
+
+	meta := w.pos.Metadata()
+	hash := w.pos.Hash()
+	pawnHash := w.pos.PawnHash()
+
+	_ = w.pos.MakeMove(m)
+	if stats {
+		result.nodes = w.leaves(depth-1, 1, &result.stats)
+	} else {
+		result.nodes = w.count(depth-1, 1)
+	}
+	w.pos.UnmakeMove(m, meta, hash, pawnHash)
+
+	return result
+}
+
+// count returns the number of nodes until the given depth.
+//
+// Leaf nodes are bulk counted with the legal moves of their parent.
+func (w *perftWorker) count(depth, ply int) int {
 	if depth <= 0 {
 		return 1
 	}
 
-	checkData, _ := pos.InCheck()
-	moves := pos.PseudoMoves(checkData)
+	hash := w.pos.Hash()
+	if nodes, ok := w.table.get(hash, depth); ok {
+		return nodes
+	}
+
+	checkData, _ := w.pos.InCheck()
+	moves := w.pos.AppendLegalMoves(w.stack[ply][:0], checkData)
+
+	if depth == 1 {
+		return len(moves)
+	}
+
+	meta := w.pos.Metadata()
+	pawnHash := w.pos.PawnHash()
+
+	var nodes int
+	for _, m := range moves {
+		_ = w.pos.MakeMove(m)
+		nodes += w.count(depth-1, ply+1)
+		w.pos.UnmakeMove(m, meta, hash, pawnHash)
+	}
+
+	w.table.set(hash, depth, nodes)
+	return nodes
+}
+
+// leaves returns the number of nodes until the given depth
+// and updates the counters with the moves leading to the leaf nodes.
+func (w *perftWorker) leaves(depth, ply int, stats *PerftStats) int {
+	checkData, _ := w.pos.InCheck()
+	moves := w.pos.AppendLegalMoves(w.stack[ply][:0], checkData)
 
 	if depth == 1 {
-		var nodes int
 		for _, m := range moves {
-			if ok := pos.MakeMove(m); ok {
-				nodes++
-				pos.UnmakeMove(m, meta, hash, pawnHash)
-			}
+			w.record(m, ply+1, stats)
 		}
-		return nodes
+		return len(moves)
 	}
 
+	meta := w.pos.Metadata()
+	hash := w.pos.Hash()
+	pawnHash := w.pos.PawnHash()
+
 	var nodes int
 	for _, m := range moves {
-		if ok := pos.MakeMove(m); ok {
-			nodes += perft(pos, depth-1)
-			pos.UnmakeMove(m, meta, hash, pawnHash)
-		}
+		_ = w.pos.MakeMove(m)
+		nodes += w.leaves(depth-1, ply+1, stats)
+		w.pos.UnmakeMove(m, meta, hash, pawnHash)
 	}
+
 	return nodes
 }
 
+// record updates the counters with a move leading to a leaf node.
+func (w *perftWorker) record(m Move, ply int, stats *PerftStats) {
+	if m.HasTag(Capture) {
+		stats.Captures++
+	}
+	if m.HasTag(EnPassant) {
+		stats.EnPassants++
+	}
+	if m.HasTag(Promotion) {
+		stats.Promotions++
+	}
+
+	// squares of the pieces that moved
+	bbMoved := m.S2().bitboard()
+	if m.HasTag(ASideCastle ^ HSideCastle) {
+		stats.Castles++
+		s := aSide
+		if m.HasTag(HSideCastle) {
+			s = hSide
+		}
+		bbMoved |= w.pos.castleChecks[2*uint8(w.pos.turn)+uint8(s)].rook2.bitboard()
+	}
+
+	meta := w.pos.Metadata()
+	hash := w.pos.Hash()
+	pawnHash := w.pos.PawnHash()
+
+	_ = w.pos.MakeMove(m)
+	defer w.pos.UnmakeMove(m, meta, hash, pawnHash)
+
+	checkData, inCheck := w.pos.InCheck()
+	if !inCheck {
+		return
+	}
+
+	stats.Checks++
+	switch bbCheckers := bitboard(checkData); {
+	case bbCheckers.ones() > 1:
+		stats.DoubleChecks++
+	case bbCheckers&^bbMoved > 0:
+		stats.DiscoveredChecks++
+	}
+
+	moves := w.pos.AppendLegalMoves(w.stack[ply][:0], checkData)
+	if len(moves) == 0 {
+		stats.Checkmates++
+	}
+}
+
+// perftTable is a hash table that caches the number of nodes of positions.
+//
+// Safe for concurrent use: entries are written without locks and the key
+// is XORed with the data so that torn entries are detected on read.
+type perftTable struct {
+	entries []perftEntry
+	length  uint64
+}
+
+// perftEntry represents a perft hash table entry.
+//
+//	data: 56 bits nodes, 8 bits depth
+//	key:  hash ^ data
+type perftEntry struct {
+	key  atomic.Uint64
+	data atomic.Uint64
+}
+
+// newPerftTable creates a new perft hash table of the given size in MB.
+func newPerftTable(size int) *perftTable {
+	entrySize := uint64(unsafe.Sizeof(perftEntry{}))
+	length := max(1024*1024*uint64(size)/entrySize, 1)
+
+	return &perftTable{
+		entries: make([]perftEntry, length),
+		length:  length,
+	}
+}
+
+// get returns the number of nodes of the position at the given depth.
+//
+// A nil table never finds an entry.
+func (pt *perftTable) get(hash Hash, depth int) (int, bool) {
+	if pt == nil {
+		return 0, false
+	}
+
+	entry := &pt.entries[uint64(hash)%pt.length]
+	data := entry.data.Load()
+	key := entry.key.Load()
+	if key^data != uint64(hash) || int(data&0xFF) != depth {
+		return 0, false
+	}
+
+	return int(data >> 8), true
+}
+
+// set stores the number of nodes of the position at the given depth.
+//
+// A nil table ignores the entry.
+func (pt *perftTable) set(hash Hash, depth, nodes int) {
+	if pt == nil {
+		return
+	}
+
+	entry := &pt.entries[uint64(hash)%pt.length]
+	data := uint64(nodes)<<8 | uint64(depth&0xFF)
+	entry.key.Store(uint64(hash) ^ data)
+	entry.data.Store(data)
+}
+
 // String implements the Stringer interface.
 //
 // Returns the perft result as an output accepted by the perftree cli.
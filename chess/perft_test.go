@@ -15,7 +15,7 @@ func TestPerft(t *testing.T) {
 				t.Parallel()
 				pos := unsafeFEN(tt.fen)
 				got := pos.Perft(depth + 1)
-				assert.Equal(t, tt.nodes[depth], got.nodes)
+				assert.Equal(t, tt.nodes[depth], got.Nodes())
 			})
 		}
 	}
@@ -23,10 +23,8 @@ func TestPerft(t *testing.T) {
 
 func TestPerftChess960(t *testing.T) {
 	t.Parallel()
-	// TODO: Chess960, broken at depth 3
-
 	for i, tt := range chess960perftResults {
-		for depth := 0; depth < len(tt.nodes) && depth < 2; depth++ {
+		for depth := 0; depth < len(tt.nodes) && depth < 3; depth++ {
 			t.Run(fmt.Sprintf("%d depth %d", i+1, depth+1), func(t *testing.T) {
 				t.Parallel()
 				pos := unsafeShredderFEN(tt.fen)
@@ -37,6 +35,130 @@ func TestPerftChess960(t *testing.T) {
 	}
 }
 
+func TestPerftOptions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		options []PerftOption
+	}{
+		{"hash", []PerftOption{WithPerftHash(1)}},
+		{"workers", []PerftOption{WithPerftWorkers(4)}},
+		{"hash and workers", []PerftOption{WithPerftHash(1), WithPerftWorkers(4)}},
+	}
+
+	for _, tt := range tests {
+		for _, r := range perftResults {
+			depth := min(len(r.nodes), 4)
+			t.Run(fmt.Sprintf("%s %s depth %d", tt.name, r.fen, depth), func(t *testing.T) {
+				t.Parallel()
+				pos := unsafeFEN(r.fen)
+				want := pos.Perft(depth)
+				got := pos.Perft(depth, tt.options...)
+				assert.Equal(t, r.nodes[depth-1], got.Nodes())
+				assert.Equal(t, want.String(), got.String())
+			})
+		}
+	}
+}
+
+func TestPerftStats(t *testing.T) {
+	t.Parallel()
+	// From https://www.chessprogramming.org/Perft_Results
+	tests := []struct {
+		fen   string
+		depth int
+		nodes int
+		want  PerftStats
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 1, 20, PerftStats{}},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 3, 8902, PerftStats{
+			Captures: 34, Checks: 12,
+		}},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 4, 197281, PerftStats{
+			Captures: 1576, Checks: 469, Checkmates: 8,
+		}},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 1, 48, PerftStats{
+			Captures: 8, Castles: 2,
+		}},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 2, 2039, PerftStats{
+			Captures: 351, EnPassants: 1, Castles: 91, Checks: 3,
+		}},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 3, 97862, PerftStats{
+			Captures: 17102, EnPassants: 45, Castles: 3162, Checks: 993, Checkmates: 1,
+		}},
+		{"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 4, 43238, PerftStats{
+			Captures: 3348, EnPassants: 123, Checks: 1680, DiscoveredChecks: 106, Checkmates: 17,
+		}},
+		{"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 5, 674624, PerftStats{
+			Captures: 52051, EnPassants: 1165, Checks: 52950, DiscoveredChecks: 1292, DoubleChecks: 3,
+		}},
+		{"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", 3, 9467, PerftStats{
+			Captures: 1021, EnPassants: 4, Promotions: 120, Checks: 38, DiscoveredChecks: 2, Checkmates: 22,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s depth %d", tt.fen, tt.depth), func(t *testing.T) {
+			t.Parallel()
+			pos := unsafeFEN(tt.fen)
+			got := pos.Perft(tt.depth, WithPerftStats(), WithPerftWorkers(2))
+			assert.Equal(t, tt.nodes, got.Nodes())
+			assert.Equal(t, tt.want, got.Stats())
+		})
+	}
+}
+
+func TestPerftAllocs(t *testing.T) {
+	w := newPerftWorker(*unsafeFEN(perftResults[1].fen), 3, nil)
+	allocs := testing.AllocsPerRun(1, func() {
+		_ = w.count(3, 0)
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestPerftTable(t *testing.T) {
+	t.Parallel()
+	table := newPerftTable(1)
+	hash := Hash(0xDEADBEEF)
+
+	_, ok := table.get(hash, 3)
+	assert.False(t, ok)
+
+	table.set(hash, 3, 97862)
+	nodes, ok := table.get(hash, 3)
+	assert.True(t, ok)
+	assert.Equal(t, 97862, nodes)
+
+	_, ok = table.get(hash, 2)
+	assert.False(t, ok)
+
+	var none *perftTable
+	none.set(hash, 3, 97862)
+	_, ok = none.get(hash, 3)
+	assert.False(t, ok)
+}
+
+func BenchmarkPerft(b *testing.B) {
+	benchs := []struct {
+		name    string
+		options []PerftOption
+	}{
+		{"bulk", nil},
+		{"hash", []PerftOption{WithPerftHash(16)}},
+		{"workers", []PerftOption{WithPerftWorkers(4)}},
+		{"stats", []PerftOption{WithPerftStats()}},
+	}
+
+	for _, bb := range benchs {
+		b.Run(bb.name, func(b *testing.B) {
+			pos := unsafeFEN(perftResults[1].fen)
+			for n := 0; n < b.N; n++ {
+				pos.Perft(4, bb.options...)
+			}
+		})
+	}
+}
+
 // unsafeShredderFEN returns a position without error checking, only meant for tests.
 func unsafeShredderFEN(fen string) *Position {
 	p, err := ShredderFEN{}.Decode(fen)
@@ -0,0 +1,166 @@
+package chess
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"unicode"
+)
+
+var errPGNTag = errors.New("invalid pgn tag")
+
+// PGNGame holds a game read from PGN data.
+type PGNGame struct {
+	Tags   map[string]string // Tag pairs, such as Event, WhiteElo or FEN.
+	Moves  []string          // Moves of the main line in SAN notation.
+	Result string            // Game termination marker: 1-0, 0-1, 1/2-1/2 or *.
+}
+
+// PGNScanner reads the games of PGN data.
+//
+// Comments, variations and numeric annotation glyphs are skipped.
+type PGNScanner struct {
+	r    *bufio.Reader
+	game PGNGame
+	err  error
+}
+
+// NewPGNScanner returns a new PGN scanner reading from r.
+func NewPGNScanner(r io.Reader) *PGNScanner {
+	return &PGNScanner{r: bufio.NewReader(r)}
+}
+
+// Game returns the most recent game read by Scan.
+func (s *PGNScanner) Game() PGNGame {
+	return s.game
+}
+
+// Err returns the first non-EOF error encountered by the scanner.
+func (s *PGNScanner) Err() error {
+	if errors.Is(s.err, io.EOF) {
+		return nil
+	}
+	return s.err
+}
+
+// Scan advances the scanner to the next game.
+//
+// Returns false when the end of the data is reached or an error occurs.
+func (s *PGNScanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+
+	s.game = PGNGame{Tags: make(map[string]string)}
+	var variations int
+	for {
+		c, err := s.skipSpace()
+		if err != nil {
+			return s.end(err)
+		}
+
+		switch {
+		case c == '[' && variations == 0:
+			if len(s.game.Moves) > 0 {
+				// the previous game has no termination marker
+				_ = s.r.UnreadByte()
+				return true
+			}
+			if err := s.readTag(); err != nil {
+				return s.end(err)
+			}
+		case c == '{':
+			if _, err := s.r.ReadString('}'); err != nil {
+				return s.end(err)
+			}
+		case c == ';' || c == '%':
+			if _, err := s.r.ReadString('\n'); err != nil {
+				return s.end(err)
+			}
+		case c == '(':
+			variations++
+		case c == ')':
+			variations = max(variations-1, 0)
+		default:
+			_ = s.r.UnreadByte()
+			token, err := s.readToken()
+			if err != nil && !errors.Is(err, io.EOF) {
+				return s.end(err)
+			}
+
+			if variations > 0 {
+				continue
+			}
+
+			switch token {
+			case "1-0", "0-1", "1/2-1/2", "*":
+				s.game.Result = token
+				return true
+			}
+
+			// move numbers may be attached to the moves, as in 1.e4
+			if token = strings.TrimLeft(token, "0123456789."); token != "" && !strings.HasPrefix(token, "$") {
+				s.game.Moves = append(s.game.Moves, token)
+			}
+		}
+	}
+}
+
+// end ends the scan on the error.
+//
+// Returns true if a game without termination marker was read until EOF.
+func (s *PGNScanner) end(err error) bool {
+	if !errors.Is(err, io.EOF) {
+		s.err = err
+		return false
+	}
+
+	s.err = io.EOF
+	return len(s.game.Tags) > 0 || len(s.game.Moves) > 0
+}
+
+// skipSpace skips white space and returns the next byte.
+func (s *PGNScanner) skipSpace() (byte, error) {
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil || !unicode.IsSpace(rune(c)) {
+			return c, err
+		}
+	}
+}
+
+// readToken reads a movetext token.
+func (s *PGNScanner) readToken() (string, error) {
+	var b strings.Builder
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return b.String(), err
+		}
+
+		if unicode.IsSpace(rune(c)) || strings.IndexByte("[]{}();", c) >= 0 {
+			_ = s.r.UnreadByte()
+			return b.String(), nil
+		}
+
+		b.WriteByte(c)
+	}
+}
+
+// readTag reads a tag pair after its opening bracket.
+func (s *PGNScanner) readTag() error {
+	line, err := s.r.ReadString(']')
+	if err != nil {
+		return errPGNTag
+	}
+
+	name, value, ok := strings.Cut(strings.TrimSuffix(line, "]"), " ")
+	value = strings.TrimSpace(value)
+	if !ok || len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return errPGNTag
+	}
+
+	s.game.Tags[name] = strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+	return nil
+}
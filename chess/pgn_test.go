@@ -0,0 +1,81 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPGN = `[Event "Casual \"blitz\""]
+[White "Lasker"]
+[WhiteElo "2500"]
+[Result "1-0"]
+
+1. d4 d5 2. c4 {the Queen's Gambit} e5 3. dxe5 d4 4. e3 $2 Bb4+ (4... dxe3 5. Qxd8+)
+5. Bd2 dxe3 6. Bxb4 exf2+ 7. Ke2 fxg1=N+ 8. Ke1 ; the trap
+Qh4+ 1-0
+
+[Event "No result"]
+
+1.e4 e5 2.Nf3 *
+
+1. e4 c5`
+
+func TestPGNScanner(t *testing.T) {
+	t.Parallel()
+	s := NewPGNScanner(strings.NewReader(testPGN))
+	var games []PGNGame
+	for s.Scan() {
+		games = append(games, s.Game())
+	}
+
+	assert.NoError(t, s.Err())
+	assert.Equal(t, []PGNGame{
+		{
+			Tags: map[string]string{
+				"Event":    `Casual "blitz"`,
+				"White":    "Lasker",
+				"WhiteElo": "2500",
+				"Result":   "1-0",
+			},
+			Moves: []string{
+				"d4", "d5", "c4", "e5", "dxe5", "d4", "e3", "Bb4+", "Bd2", "dxe3",
+				"Bxb4", "exf2+", "Ke2", "fxg1=N+", "Ke1", "Qh4+",
+			},
+			Result: "1-0",
+		},
+		{
+			Tags:   map[string]string{"Event": "No result"},
+			Moves:  []string{"e4", "e5", "Nf3"},
+			Result: "*",
+		},
+		{
+			Tags:  map[string]string{},
+			Moves: []string{"e4", "c5"},
+		},
+	}, games)
+}
+
+func TestPGNScannerMoves(t *testing.T) {
+	t.Parallel()
+	s := NewPGNScanner(strings.NewReader(testPGN))
+	assert.True(t, s.Scan())
+
+	pos := StartingPosition()
+	for _, san := range s.Game().Moves {
+		m, err := SAN{}.Decode(pos, san)
+		if !assert.NoError(t, err, san) {
+			return
+		}
+		assert.Equal(t, san, SAN{}.Encode(pos, m))
+		assert.True(t, pos.MakeMove(m))
+	}
+}
+
+func TestPGNScannerInvalidTag(t *testing.T) {
+	t.Parallel()
+	s := NewPGNScanner(strings.NewReader(`[Event Casual]`))
+	assert.False(t, s.Scan())
+	assert.Equal(t, errPGNTag, s.Err())
+}
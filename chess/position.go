@@ -7,10 +7,49 @@ type Position struct {
 	pawnHash      Hash
 	castleChecks  [4]castleCheck
 	castling      castling
+	chess960      [2]bool // Whether the castling rights showed a Chess960 setup. Indexed by color.
 	turn          Color
 	enPassant     Square
 	halfMoveClock uint8
 	fullMoves     uint8
+	hook          MoveHook
+}
+
+// MoveHook is notified of the moves made and unmade on a position,
+// for example to update an evaluation incrementally.
+//
+// Null moves are not notified as they do not move any piece.
+type MoveHook interface {
+	// MakeMove is called after a legal move has been made,
+	// with the pieces it moved.
+	MakeMove(dirty DirtyPieces)
+	// UnmakeMove is called after a move has been unmade.
+	UnmakeMove()
+}
+
+// DirtyPiece represents a piece moved by a move.
+//
+// From is NoSquare for a piece added to the board by a promotion,
+// To is NoSquare for a piece removed from the board.
+type DirtyPiece struct {
+	Piece Piece
+	From  Square
+	To    Square
+}
+
+// DirtyPieces holds the pieces moved by a move.
+//
+// A move moves up to three pieces: a capturing promotion removes a pawn
+// and the captured piece and adds the promoted piece.
+type DirtyPieces struct {
+	Pieces [3]DirtyPiece
+	Len    int
+}
+
+// add adds a piece to the dirty pieces.
+func (dp *DirtyPieces) add(p Piece, from, to Square) {
+	dp.Pieces[dp.Len] = DirtyPiece{Piece: p, From: from, To: to}
+	dp.Len++
 }
 
 const startFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
@@ -50,6 +89,55 @@ func (pos Position) FullMoves() uint8 {
 	return pos.fullMoves
 }
 
+// HalfMoveClock returns the number of half moves since the last capture or pawn move.
+func (pos Position) HalfMoveClock() uint8 {
+	return pos.halfMoveClock
+}
+
+// Chess960Setup returns whether the castling rights of the color showed
+// a Chess960 setup when the position was parsed, the king or a castling rook
+// starting off its file in classic chess.
+//
+// A color without castling rights is assumed to follow the classic setup.
+func (pos *Position) Chess960Setup(c Color) bool {
+	return pos.chess960[c]
+}
+
+// CanCastle returns whether any castling right remains.
+func (pos *Position) CanCastle() bool {
+	return pos.castling.rights != noCastle
+}
+
+// SetMoveHook sets the hook notified of the moves made and unmade on the position.
+//
+// A nil hook removes the current one.
+func (pos *Position) SetMoveHook(hook MoveHook) {
+	pos.hook = hook
+}
+
+// PieceAt returns the piece on the square, NoPiece if the square is empty.
+func (pos *Position) PieceAt(sq Square) Piece {
+	return pos.board.pieceAt(sq)
+}
+
+// KingSquare returns the square of the king of the color.
+func (pos *Position) KingSquare(c Color) Square {
+	return pos.board.sqKings[c]
+}
+
+// PieceCount returns the number of pieces on the board, kings and pawns included.
+func (pos *Position) PieceCount() int {
+	return (pos.board.bbColors[White] ^ pos.board.bbColors[Black]).ones()
+}
+
+// BoardMap calls the callback for each piece on the board in ascending square order.
+func (pos *Position) BoardMap(cb func(p Piece, sq Square)) {
+	for bb := pos.board.bbColors[White] ^ pos.board.bbColors[Black]; bb > 0; bb = bb.resetLSB() {
+		sq := bb.scanForward()
+		cb(pos.board.pieceAt(sq), sq)
+	}
+}
+
 // MakeMove makes a move.
 //
 // Checks the legality of the resulting position.
@@ -97,6 +185,10 @@ func (pos *Position) MakeMove(m Move) bool {
 		pos.fullMoves++
 	}
 
+	if pos.hook != nil {
+		pos.hook.MakeMove(dirtyPieces(m, pos.castling.files))
+	}
+
 	return true
 }
 
@@ -110,6 +202,10 @@ func (pos *Position) UnmakeMove(m Move, meta Metadata, hash, pawnHash Hash) {
 	pos.fullMoves = meta.fullMoves()
 	pos.hash = hash
 	pos.pawnHash = pawnHash
+
+	if pos.hook != nil {
+		pos.hook.UnmakeMove()
+	}
 }
 
 // MakeNullMove makes a null (passing) move.
@@ -141,17 +237,53 @@ func (pos *Position) String() string {
 	return FEN{}.Encode(pos)
 }
 
+// dirtyPieces returns the pieces moved by a move.
+func dirtyPieces(m Move, cf castleFiles) DirtyPieces {
+	var dp DirtyPieces
+	p1, p2 := m.P1(), m.P2()
+	s1, s2 := m.S1(), m.S2()
+	c := p1.Color()
+
+	if promo := m.Promo(); promo == NoPiece {
+		dp.add(p1, s1, s2)
+	} else {
+		dp.add(p1, s1, NoSquare)
+		dp.add(promo, NoSquare, s2)
+	}
+
+	rank := Rank1
+	if c == Black {
+		rank = Rank8
+	}
+
+	switch enPassant := m.HasTag(EnPassant); {
+	case m.HasTag(Quiet):
+	case p2 != NoPiece && !enPassant:
+		dp.add(p2, s2, NoSquare)
+	case c == White && enPassant:
+		dp.add(BlackPawn, s2-8, NoSquare)
+	case c == Black && enPassant:
+		dp.add(WhitePawn, s2+8, NoSquare)
+	case m.HasTag(ASideCastle):
+		dp.add(Rook.color(c), newSquare(cf[c][aSide], rank), newSquare(FileD, rank))
+	case m.HasTag(HSideCastle):
+		dp.add(Rook.color(c), newSquare(cf[c][hSide], rank), newSquare(FileF, rank))
+	}
+
+	return dp
+}
+
 // moveCastlingRights computes the new castling rights after a move.
-func moveCastlingRights(cr castlingRights, cf [2]File, m Move) castlingRights {
+func moveCastlingRights(cr castlingRights, cf castleFiles, m Move) castlingRights {
 	p1 := m.P1()
 	if pt := p1.Type(); pt != King && pt != Rook && !m.HasTag(Capture) {
 		return cr
 	}
 
-	blackRookA := newSquare(cf[aSide], Rank8)
-	blackRookH := newSquare(cf[hSide], Rank8)
-	whiteRookA := newSquare(cf[aSide], Rank1)
-	whiteRookH := newSquare(cf[hSide], Rank1)
+	blackRookA := newSquare(cf[Black][aSide], Rank8)
+	blackRookH := newSquare(cf[Black][hSide], Rank8)
+	whiteRookA := newSquare(cf[White][aSide], Rank1)
+	whiteRookH := newSquare(cf[White][hSide], Rank1)
 
 	switch s1, s2 := m.S1(), m.S2(); {
 	case p1 == BlackKing:
@@ -38,6 +38,52 @@ func TestStartingPosition(t *testing.T) {
 	assert.Equal(t, startFEN, StartingPosition().String())
 }
 
+func TestPosition_Accessors(t *testing.T) {
+	t.Parallel()
+	pos := unsafeFEN("4k3/8/8/8/8/8/4P3/R3K3 w Q - 12 40")
+	assert.Equal(t, uint8(12), pos.HalfMoveClock())
+	assert.True(t, pos.CanCastle())
+	assert.False(t, unsafeFEN("4k3/8/8/8/8/8/4P3/R3K3 w - - 0 1").CanCastle())
+	assert.Equal(t, WhiteRook, pos.PieceAt(A1))
+	assert.Equal(t, NoPiece, pos.PieceAt(A2))
+	assert.Equal(t, 4, pos.PieceCount())
+	assert.Equal(t, E1, pos.KingSquare(White))
+	assert.Equal(t, E8, pos.KingSquare(Black))
+
+	var pieces []string
+	pos.BoardMap(func(p Piece, sq Square) {
+		pieces = append(pieces, p.String()+sq.String())
+	})
+	assert.Equal(t, []string{"Ra1", "Ke1", "Pe2", "ke8"}, pieces)
+}
+
+func TestPosition_Chess960Setup(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		fen   string
+		n     Notation
+		white bool
+		black bool
+	}{
+		{"classic", startFEN, FEN{}, false, false},
+		{"no castling rights", "qbb1rkrn/1ppppppp/p7/7n/8/P2P4/1PP1PPPP/QBBRNKRN w - - 0 9", ShredderFEN{}, false, false},
+		{"chess960", "qbb1rkrn/1ppppppp/p7/7n/8/P2P4/1PP1PPPP/QBBRNKRN w Gg - 0 9", ShredderFEN{}, true, true},
+		{"rooks on classic files", "rbbqknnr/pppppppp/8/8/8/8/PPPPPPPP/RBBQKNNR w HAha - 0 1", ShredderFEN{}, false, false},
+		{"king off its classic file", "rbbkqnnr/pppppppp/8/8/8/8/PPPPPPPP/RBBKQNNR w HA - 0 1", ShredderFEN{}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pos, err := tt.n.Decode(tt.fen)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.white, pos.Chess960Setup(White))
+			assert.Equal(t, tt.black, pos.Chess960Setup(Black))
+		})
+	}
+}
+
 func TestPosition_MakeMove(t *testing.T) {
 	t.Parallel()
 	for _, tt := range testPositions {
@@ -52,6 +98,76 @@ func TestPosition_MakeMove(t *testing.T) {
 	}
 }
 
+// recordingHook records the moves notified to a move hook.
+type recordingHook struct {
+	made   []DirtyPieces
+	unmade int
+}
+
+func (h *recordingHook) MakeMove(dirty DirtyPieces) { h.made = append(h.made, dirty) }
+
+func (h *recordingHook) UnmakeMove() { h.unmade++ }
+
+func TestPosition_MoveHook(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		fen  string
+		move string
+		want []DirtyPiece
+	}{
+		{
+			"quiet",
+			startFEN,
+			"g1f3",
+			[]DirtyPiece{{WhiteKnight, G1, F3}},
+		},
+		{
+			"capture",
+			"4k3/8/8/3p4/4P3/8/8/4K3 w - - 0 1",
+			"e4d5",
+			[]DirtyPiece{{WhitePawn, E4, D5}, {BlackPawn, D5, NoSquare}},
+		},
+		{
+			"en passant",
+			"4k3/8/8/8/3pP3/8/8/4K3 b - e3 0 1",
+			"d4e3",
+			[]DirtyPiece{{BlackPawn, D4, E3}, {WhitePawn, E4, NoSquare}},
+		},
+		{
+			"capturing promotion",
+			"1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1",
+			"a7b8q",
+			[]DirtyPiece{{WhitePawn, A7, NoSquare}, {WhiteQueen, NoSquare, B8}, {BlackRook, B8, NoSquare}},
+		},
+		{
+			"castle",
+			"r3k3/8/8/8/8/8/8/4K3 b q - 0 1",
+			"e8c8",
+			[]DirtyPiece{{BlackKing, E8, C8}, {BlackRook, A8, D8}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pos := unsafeFEN(tt.fen)
+			hook := &recordingHook{}
+			pos.SetMoveHook(hook)
+
+			move, err := NewMove(pos, tt.move)
+			assert.NoError(t, err)
+			meta, hash, pawnHash := pos.Metadata(), pos.Hash(), pos.PawnHash()
+			assert.True(t, pos.MakeMove(move))
+			pos.UnmakeMove(move, meta, hash, pawnHash)
+
+			assert.Len(t, hook.made, 1)
+			assert.Equal(t, tt.want, hook.made[0].Pieces[:hook.made[0].Len])
+			assert.Equal(t, 1, hook.unmade)
+		})
+	}
+}
+
 func BenchmarkPosition_MakeMove(b *testing.B) {
 	for _, bb := range testPositions {
 		pos := unsafeFEN(bb.preFEN)
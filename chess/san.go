@@ -0,0 +1,239 @@
+package chess
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	errInvalidSAN   = errors.New("invalid move in SAN notation")
+	errIllegalSAN   = errors.New("illegal move in SAN notation")
+	errAmbiguousSAN = errors.New("ambiguous move in SAN notation")
+)
+
+// SAN is the Standard Algebraic Notation used in PGN files.
+type SAN struct{}
+
+// Encode encodes a move into a SAN string.
+//
+// Expects the move to be legal in the position.
+//
+// Implements the MoveNotation interface.
+func (SAN) Encode(pos *Position, m Move) string {
+	if m == NoMove {
+		return "null"
+	}
+
+	var b strings.Builder
+	switch pt := m.P1().Type(); {
+	case m.HasTag(ASideCastle):
+		b.WriteString("O-O-O")
+	case m.HasTag(HSideCastle):
+		b.WriteString("O-O")
+	case pt == Pawn:
+		if m.HasTag(Capture) {
+			b.WriteString(m.S1().File().String())
+			b.WriteByte('x')
+		}
+		b.WriteString(m.S2().String())
+		if m.HasTag(Promotion) {
+			b.WriteByte('=')
+			b.WriteString(strings.ToUpper(m.Promo().Type().String()))
+		}
+	default:
+		b.WriteString(strings.ToUpper(pt.String()))
+		b.WriteString(sanDisambiguation(pos, m))
+		if m.HasTag(Capture) {
+			b.WriteByte('x')
+		}
+		b.WriteString(m.S2().String())
+	}
+
+	meta := pos.Metadata()
+	hash := pos.Hash()
+	pawnHash := pos.PawnHash()
+
+	if ok := pos.MakeMove(m); !ok {
+		return b.String()
+	}
+	defer pos.UnmakeMove(m, meta, hash, pawnHash)
+
+	if checkData, inCheck := pos.InCheck(); inCheck {
+		var ml MoveList
+		if len(pos.AppendLegalMoves(ml[:0], checkData)) == 0 {
+			b.WriteByte('#')
+		} else {
+			b.WriteByte('+')
+		}
+	}
+
+	return b.String()
+}
+
+// sanDisambiguation returns the origin file, rank or square needed
+// to distinguish the move from the other legal moves of the same piece type
+// to the same destination square.
+func sanDisambiguation(pos *Position, m Move) string {
+	var ml MoveList
+	checkData, _ := pos.InCheck()
+
+	var ambiguous, sameFile, sameRank bool
+	for _, other := range pos.AppendLegalMoves(ml[:0], checkData) {
+		if other.S2() != m.S2() || other.S1() == m.S1() || other.P1() != m.P1() {
+			continue
+		}
+		ambiguous = true
+		sameFile = sameFile || other.S1().File() == m.S1().File()
+		sameRank = sameRank || other.S1().Rank() == m.S1().Rank()
+	}
+
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return m.S1().File().String()
+	case !sameRank:
+		return m.S1().Rank().String()
+	default:
+		return m.S1().String()
+	}
+}
+
+// Decode decodes a move from a SAN string.
+//
+// Check, mate and annotation suffixes are ignored.
+// Returns an error if the move is not legal in the position.
+//
+// Implements the MoveNotation interface.
+func (SAN) Decode(pos *Position, s string) (Move, error) {
+	if pos == nil {
+		return NoMove, errMissingPosition
+	}
+
+	san := strings.TrimRight(s, "+#!?")
+	var tag MoveTag
+	switch san {
+	case "O-O", "0-0":
+		tag = HSideCastle
+	case "O-O-O", "0-0-0":
+		tag = ASideCastle
+	}
+
+	var ml MoveList
+	checkData, _ := pos.InCheck()
+	moves := pos.AppendLegalMoves(ml[:0], checkData)
+
+	if tag != 0 {
+		for _, m := range moves {
+			if m.HasTag(tag) {
+				return m, nil
+			}
+		}
+		return NoMove, errIllegalSAN
+	}
+
+	pt, from, to, promo, err := parseSAN(san)
+	if err != nil {
+		return NoMove, err
+	}
+
+	found := NoMove
+	for _, m := range moves {
+		switch {
+		case m.P1().Type() != pt || m.S2() != to,
+			m.HasTag(ASideCastle ^ HSideCastle),
+			from.file >= 0 && m.S1().File() != File(from.file),
+			from.rank >= 0 && m.S1().Rank() != Rank(from.rank),
+			m.HasTag(Promotion) != (promo != NoPieceType),
+			promo != NoPieceType && m.Promo().Type() != promo:
+			continue
+		case found != NoMove:
+			return NoMove, errAmbiguousSAN
+		default:
+			found = m
+		}
+	}
+
+	if found == NoMove {
+		return NoMove, errIllegalSAN
+	}
+
+	return found, nil
+}
+
+// sanOrigin holds the optional origin file and rank of a SAN move, -1 when absent.
+type sanOrigin struct {
+	file, rank int
+}
+
+// parseSAN parses a SAN move without its suffixes.
+func parseSAN(san string) (PieceType, sanOrigin, Square, PieceType, error) {
+	pt, promo := Pawn, NoPieceType
+	from := sanOrigin{-1, -1}
+
+	if i := strings.IndexByte(san, '='); i >= 0 {
+		if i != len(san)-2 {
+			return pt, from, NoSquare, promo, errInvalidSAN
+		}
+		promo = sanPieceType(san[i+1])
+		if promo == NoPieceType {
+			return pt, from, NoSquare, promo, errInvalidSAN
+		}
+		san = san[:i]
+	} else if n := len(san); n > 2 && sanPieceType(san[n-1]) != NoPieceType && san[n-2] >= '1' && san[n-2] <= '8' {
+		// promotion without the equal sign, as in e8Q
+		promo = sanPieceType(san[n-1])
+		san = san[:n-1]
+	}
+
+	if promo == King {
+		return pt, from, NoSquare, promo, errInvalidSAN
+	}
+
+	if len(san) > 0 && sanPieceType(san[0]) != NoPieceType {
+		pt = sanPieceType(san[0])
+		san = san[1:]
+	}
+
+	if len(san) < 2 {
+		return pt, from, NoSquare, promo, errInvalidSAN
+	}
+
+	to, err := uciSquare(san[len(san)-2:])
+	if err != nil {
+		return pt, from, NoSquare, promo, errInvalidSAN
+	}
+
+	for _, c := range []byte(strings.TrimSuffix(san[:len(san)-2], "x")) {
+		switch {
+		case c >= 'a' && c <= 'h' && from.file < 0:
+			from.file = int(c - 'a')
+		case c >= '1' && c <= '8' && from.rank < 0:
+			from.rank = int(c - '1')
+		default:
+			return pt, from, NoSquare, promo, errInvalidSAN
+		}
+	}
+
+	return pt, from, to, promo, nil
+}
+
+// sanPieceType returns the piece type of an uppercase SAN piece letter.
+//
+// Returns NoPieceType if the letter is not a piece letter.
+func sanPieceType(c byte) PieceType {
+	switch c {
+	case 'N':
+		return Knight
+	case 'B':
+		return Bishop
+	case 'R':
+		return Rook
+	case 'Q':
+		return Queen
+	case 'K':
+		return King
+	default:
+		return NoPieceType
+	}
+}
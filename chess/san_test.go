@@ -0,0 +1,75 @@
+package chess
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// compile time check that SAN implements MoveNotation.
+var _ MoveNotation = SAN{}
+
+func TestSAN(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		fen string
+		uci string
+		san string
+	}{
+		{startFEN, "e2e4", "e4"},
+		{startFEN, "g1f3", "Nf3"},
+		{"r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3", "f3e5", "Nxe5"},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", "e4d5", "exd5"},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "e5f6", "exf6"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", "O-O"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "e8c8", "O-O-O"},
+		{"7k/4P3/8/8/8/8/8/K7 w - - 0 1", "e7e8q", "e8=Q+"},
+		{"3r3k/4P3/8/8/8/8/8/K7 w - - 0 1", "e7d8n", "exd8=N"},
+		{"k7/8/8/8/8/8/8/KR1R4 w - - 0 1", "b1c1", "Rbc1"},
+		{"k7/8/8/8/8/8/8/K7 b - - 0 1", "a8b8", "Kb8"},
+		{"7k/8/8/8/R7/8/R7/K7 w - - 0 1", "a2a3", "R2a3"},
+		{"7k/8/8/8/Q1Q5/8/Q7/K7 w - - 0 1", "a4b3", "Qa4b3"},
+		{"6k1/5ppp/8/8/8/8/8/K3R3 w - - 0 1", "e1e8", "Re8#"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.san, func(t *testing.T) {
+			t.Parallel()
+			pos := unsafeFEN(tt.fen)
+			m, err := SAN{}.Decode(pos, tt.san)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.uci, m.String())
+			assert.Equal(t, tt.san, SAN{}.Encode(pos, m))
+		})
+	}
+}
+
+func TestSANDecode(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		fen  string
+		san  string
+		want string
+		err  error
+	}{
+		{startFEN, "Nf3!?", "g1f3", nil},
+		{startFEN, "0-0", "null", errIllegalSAN},
+		{startFEN, "Ng1f3", "g1f3", nil},
+		{"7k/4P3/8/8/8/8/8/K7 w - - 0 1", "e8Q", "e7e8q", nil},
+		{"7k/4P3/8/8/8/8/8/K7 w - - 0 1", "e8", "null", errIllegalSAN},
+		{"7k/4P3/8/8/8/8/8/K7 w - - 0 1", "e8=K", "null", errInvalidSAN},
+		{"k7/8/8/8/8/8/8/KR1R4 w - - 0 1", "Rc1", "null", errAmbiguousSAN},
+		{startFEN, "e9", "null", errInvalidSAN},
+		{startFEN, "Nzf3", "null", errInvalidSAN},
+		{startFEN, "e5", "null", errIllegalSAN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.san, func(t *testing.T) {
+			t.Parallel()
+			m, err := SAN{}.Decode(unsafeFEN(tt.fen), tt.san)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.want, m.String())
+		})
+	}
+}
@@ -58,6 +58,7 @@ func (ShredderFEN) Decode(s string) (*Position, error) {
 	if err != nil {
 		return nil, err
 	}
+	pos.chess960 = pos.castling.chess960Setup(pos.board.sqKings)
 
 	for c := Black; c <= White; c++ {
 		for s := aSide; s <= hSide; s++ {
@@ -95,16 +96,16 @@ func shredderFenCastling(c castling) string {
 
 	var rights string
 	if c.rights.canCastle(White, hSide) {
-		rights += strings.ToUpper(c.files[hSide].String())
+		rights += strings.ToUpper(c.files[White][hSide].String())
 	}
 	if c.rights.canCastle(White, aSide) {
-		rights += strings.ToUpper(c.files[aSide].String())
+		rights += strings.ToUpper(c.files[White][aSide].String())
 	}
 	if c.rights.canCastle(Black, hSide) {
-		rights += c.files[hSide].String()
+		rights += c.files[Black][hSide].String()
 	}
 	if c.rights.canCastle(Black, aSide) {
-		rights += c.files[aSide].String()
+		rights += c.files[Black][aSide].String()
 	}
 	return rights
 }
@@ -116,7 +117,7 @@ func shredderFenCastlingField(field string, kings [2]Square) (castling, error) {
 		return castling{}, nil
 	}
 
-	files := [2]File{FileA, FileH}
+	files := newCastleFiles(FileA, FileH)
 	rights := noCastle
 
 	runes := []rune(field)
@@ -138,7 +139,7 @@ func shredderFenCastlingField(field string, kings [2]Square) (castling, error) {
 		}
 
 		rights |= castlingRightsMap[2*uint8(c)+uint8(s)]
-		files[s] = file
+		files[c][s] = file
 	}
 
 	return castling{files: files, rights: rights}, nil
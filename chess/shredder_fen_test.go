@@ -42,12 +42,12 @@ func TestShredderFENCastling(t *testing.T) {
 		args castling
 		want string
 	}{
-		{castling{[2]File{FileA, FileH}, noCastle}, "-"},
-		{castling{[2]File{FileA, FileH}, castleBlackA | castleBlackH | castleWhiteA | castleWhiteH}, "HAha"},
-		{castling{[2]File{FileA, FileH}, castleWhiteA | castleWhiteH}, "HA"},
-		{castling{[2]File{FileF, FileH}, castleBlackA | castleBlackH | castleWhiteA | castleWhiteH}, "HFhf"},
-		{castling{[2]File{FileE, FileG}, castleWhiteA | castleWhiteH}, "GE"},
-		{castling{[2]File{FileD, FileH}, castleBlackA | castleBlackH | castleWhiteA | castleWhiteH}, "HDhd"},
+		{castling{newCastleFiles(FileA, FileH), noCastle}, "-"},
+		{castling{newCastleFiles(FileA, FileH), castleBlackA | castleBlackH | castleWhiteA | castleWhiteH}, "HAha"},
+		{castling{newCastleFiles(FileA, FileH), castleWhiteA | castleWhiteH}, "HA"},
+		{castling{newCastleFiles(FileF, FileH), castleBlackA | castleBlackH | castleWhiteA | castleWhiteH}, "HFhf"},
+		{castling{newCastleFiles(FileE, FileG), castleWhiteA | castleWhiteH}, "GE"},
+		{castling{newCastleFiles(FileD, FileH), castleBlackA | castleBlackH | castleWhiteA | castleWhiteH}, "HDhd"},
 	}
 
 	for _, tt := range tests {
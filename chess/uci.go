@@ -49,9 +49,9 @@ func (UCIChess960) Encode(pos *Position, m Move) string {
 	case m == NoMove:
 		return "null"
 	case m.HasTag(ASideCastle):
-		return m.S1().String() + newSquare(pos.castling.files[aSide], m.S1().Rank()).String()
+		return m.S1().String() + newSquare(pos.castling.files[m.P1().Color()][aSide], m.S1().Rank()).String()
 	case m.HasTag(HSideCastle):
-		return m.S1().String() + newSquare(pos.castling.files[hSide], m.S1().Rank()).String()
+		return m.S1().String() + newSquare(pos.castling.files[m.P1().Color()][hSide], m.S1().Rank()).String()
 	case m.HasTag(Promotion):
 		return m.S1().String() + m.S2().String() + m.Promo().Type().String()
 	default:
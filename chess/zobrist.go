@@ -53,7 +53,7 @@ func newPawnZobristHash(pos *Position) (hash Hash) {
 // xorHashPartialMove updates a position hash incrementally.
 //
 // This function does not account for changes in en passant squares.
-func xorHashPartialMove(m Move, cr1, cr2 castlingRights, cf [2]File) (h, ph Hash) {
+func xorHashPartialMove(m Move, cr1, cr2 castlingRights, cf castleFiles) (h, ph Hash) {
 	// turn
 	h = polyTurn
 
@@ -106,16 +106,16 @@ func xorHashPartialMove(m Move, cr1, cr2 castlingRights, cf [2]File) (h, ph Hash
 		h ^= hep
 		ph ^= hep
 	case c == Black && m.HasTag(ASideCastle):
-		h ^= polyRandom[64*int(BlackRook)+int(newSquare(cf[aSide], Rank8))]
+		h ^= polyRandom[64*int(BlackRook)+int(newSquare(cf[Black][aSide], Rank8))]
 		h ^= polyRandom[64*int(BlackRook)+int(D8)]
 	case c == Black && m.HasTag(HSideCastle):
-		h ^= polyRandom[64*int(BlackRook)+int(newSquare(cf[hSide], Rank8))]
+		h ^= polyRandom[64*int(BlackRook)+int(newSquare(cf[Black][hSide], Rank8))]
 		h ^= polyRandom[64*int(BlackRook)+int(F8)]
 	case c == White && m.HasTag(ASideCastle):
-		h ^= polyRandom[64*int(WhiteRook)+int(newSquare(cf[aSide], Rank1))]
+		h ^= polyRandom[64*int(WhiteRook)+int(newSquare(cf[White][aSide], Rank1))]
 		h ^= polyRandom[64*int(WhiteRook)+int(D1)]
 	case c == White && m.HasTag(HSideCastle):
-		h ^= polyRandom[64*int(WhiteRook)+int(newSquare(cf[hSide], Rank1))]
+		h ^= polyRandom[64*int(WhiteRook)+int(newSquare(cf[White][hSide], Rank1))]
 		h ^= polyRandom[64*int(WhiteRook)+int(F1)]
 	}
 
@@ -0,0 +1,454 @@
+// Package main builds, merges, dumps and learns Polyglot opening books.
+//
+// Usage:
+//
+//	book build [flags] <games.pgn>...
+//	book merge [flags] <book.bin>...
+//	book dump [flags] <book.bin>
+//	book learn [flags] <book.bin> <games.pgn>...
+//
+// The build subcommand reads PGN games and weights each move played by
+// 2 for a win, 1 for a draw and 0 for a loss of the side to move,
+// as the Polyglot book generator does. Moves with a zero weight are removed.
+//
+// The merge subcommand adds up the weights of several books.
+//
+// The dump subcommand prints the raw entries of a book,
+// or the book moves of a single position.
+//
+// The learn subcommand updates a book in place with the results of games:
+// the learning data and the weights of the book moves played are updated
+// until the first move out of the book, as the engine's book learning does.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "build":
+		err = build(os.Stdout, args)
+	case "merge":
+		err = merge(os.Stdout, args)
+	case "dump":
+		err = dump(os.Stdout, args)
+	case "learn":
+		err = learn(os.Stdout, args)
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// usage prints the usage of the tool.
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <build|merge|dump|learn> [flags] <files>...\n", os.Args[0])
+}
+
+// newFlagSet returns a new flag set for the subcommand.
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s %s [flags] %s\n", os.Args[0], name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// build builds a book from PGN files.
+func build(w io.Writer, args []string) error {
+	fs := newFlagSet("build", "<games.pgn>...")
+	output := fs.String("o", "book.bin", "output book file")
+	minElo := fs.Int("min-elo", 0, "minimum Elo of both players, games without ratings are skipped when set")
+	maxPly := fs.Int("max-ply", 40, "maximum number of plies of each game added to the book")
+	results := fs.String("results", "1-0,0-1,1/2-1/2", "comma separated results of the games added to the book")
+	_ = fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	b := builder{
+		book:    chess.NewBookBuilder(),
+		minElo:  *minElo,
+		maxPly:  *maxPly,
+		results: strings.Split(*results, ","),
+	}
+
+	for _, path := range fs.Args() {
+		if err := b.readFile(path); err != nil {
+			return err
+		}
+	}
+
+	if err := writeBook(*output, b.book); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%d games read, %d games added, %d entries written to %s\n", b.read, b.added, b.book.Len(), *output)
+	return nil
+}
+
+// builder adds the games of PGN files to a book.
+type builder struct {
+	book    *chess.BookBuilder
+	results []string
+	minElo  int
+	maxPly  int
+	read    int
+	added   int
+}
+
+// readFile adds the games of a PGN file to the book.
+func (b *builder) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := chess.NewPGNScanner(f)
+	for scanner.Scan() {
+		b.read++
+		game := scanner.Game()
+		if !b.accept(game) {
+			continue
+		}
+
+		if err := b.addGame(game); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: game %d: %v\n", path, b.read, err)
+			continue
+		}
+		b.added++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
+
+// accept returns whether the game passes the filters.
+func (b *builder) accept(game chess.PGNGame) bool {
+	result := gameResult(game)
+	accepted := false
+	for _, r := range b.results {
+		accepted = accepted || r == result
+	}
+
+	if !accepted || b.minElo <= 0 {
+		return accepted
+	}
+
+	white, errWhite := strconv.Atoi(game.Tags["WhiteElo"])
+	black, errBlack := strconv.Atoi(game.Tags["BlackElo"])
+	return errWhite == nil && errBlack == nil && white >= b.minElo && black >= b.minElo
+}
+
+var errIllegalMove = errors.New("illegal move")
+
+// addGame adds the moves of the game to the book, until the maximum ply.
+//
+// Games with an illegal move before the maximum ply are not added.
+func (b *builder) addGame(game chess.PGNGame) error {
+	pos, err := startPosition(game)
+	if err != nil {
+		return err
+	}
+
+	var moves []chess.Move
+	for ply, san := range game.Moves {
+		if ply >= b.maxPly {
+			break
+		}
+
+		m, err := chess.SAN{}.Decode(pos, san)
+		if err != nil {
+			return fmt.Errorf("%s: %w", san, err)
+		}
+
+		if ok := pos.MakeMove(m); !ok {
+			return fmt.Errorf("%s: %w", san, errIllegalMove)
+		}
+		moves = append(moves, m)
+	}
+
+	pos, _ = startPosition(game)
+	result := gameResult(game)
+	for _, m := range moves {
+		b.book.Add(pos, m, moveWeight(result, pos.Turn()))
+		_ = pos.MakeMove(m)
+	}
+
+	return nil
+}
+
+// startPosition returns the starting position of the game, from the FEN tag if any.
+func startPosition(game chess.PGNGame) (*chess.Position, error) {
+	if fen, ok := game.Tags["FEN"]; ok {
+		return chess.NewPosition(fen)
+	}
+	return chess.StartingPosition(), nil
+}
+
+// gameResult returns the result of the game,
+// from the Result tag if the movetext has no termination marker.
+func gameResult(game chess.PGNGame) string {
+	if game.Result == "" || game.Result == "*" {
+		return game.Tags["Result"]
+	}
+	return game.Result
+}
+
+// moveWeight returns the weight of a move played by the color in a game with the result.
+func moveWeight(result string, c chess.Color) int {
+	switch {
+	case result == "1/2-1/2":
+		return 1
+	case result == "1-0" && c == chess.White, result == "0-1" && c == chess.Black:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// merge merges several books.
+func merge(w io.Writer, args []string) error {
+	fs := newFlagSet("merge", "<book.bin>...")
+	output := fs.String("o", "book.bin", "output book file")
+	_ = fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	bb := chess.NewBookBuilder()
+	for _, path := range fs.Args() {
+		entries, err := readEntries(path)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			bb.AddEntry(e)
+		}
+	}
+
+	if err := writeBook(*output, bb); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%d books merged, %d entries written to %s\n", fs.NArg(), bb.Len(), *output)
+	return nil
+}
+
+// dump prints the entries of a book.
+func dump(w io.Writer, args []string) error {
+	fs := newFlagSet("dump", "<book.bin>")
+	fen := fs.String("fen", "", "only print the book moves of the position")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if *fen == "" {
+		entries, err := readEntries(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			fmt.Fprintln(w, e)
+		}
+		return nil
+	}
+
+	pos, err := chess.NewPosition(*fen)
+	if err != nil {
+		return err
+	}
+
+	book, err := chess.OpenBook(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer book.Close()
+
+	for _, wm := range book.Lookup(pos) {
+		fmt.Fprintf(w, "%s %d\n", chess.SAN{}.Encode(pos, wm.Move), wm.Weight)
+	}
+
+	return nil
+}
+
+// learn updates a book with the results of PGN games.
+func learn(w io.Writer, args []string) error {
+	fs := newFlagSet("learn", "<book.bin> <games.pgn>...")
+	color := fs.String("color", "", "only learn the moves of the color, white or black")
+	result := fs.String("result", "", "result of the games, overrides the PGN results")
+	_ = fs.Parse(args)
+
+	if fs.NArg() < 2 || (*color != "" && *color != "white" && *color != "black") {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	book, err := chess.OpenWritableBook(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	l := learner{book: book, color: *color, result: *result}
+	for _, path := range fs.Args()[1:] {
+		if err := l.readFile(path); err != nil {
+			_ = book.Close()
+			return err
+		}
+	}
+
+	if err := book.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%d games read, %d book moves learned in %s\n", l.read, l.learned, fs.Arg(0))
+	return nil
+}
+
+// learner updates a book with the results of games.
+type learner struct {
+	book    *chess.Book
+	color   string
+	result  string
+	read    int
+	learned int
+}
+
+// readFile updates the book with the games of a PGN file.
+func (l *learner) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := chess.NewPGNScanner(f)
+	for scanner.Scan() {
+		l.read++
+		if err := l.learnGame(scanner.Game()); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: game %d: %v\n", path, l.read, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
+
+// learnGame updates the book with the moves of the game until the first move out of the book.
+//
+// Games without a result are skipped.
+func (l *learner) learnGame(game chess.PGNGame) error {
+	result := l.result
+	if result == "" {
+		result = gameResult(game)
+	}
+	if result != "1-0" && result != "0-1" && result != "1/2-1/2" {
+		return nil
+	}
+
+	pos, err := startPosition(game)
+	if err != nil {
+		return err
+	}
+
+	for _, san := range game.Moves {
+		m, err := chess.SAN{}.Decode(pos, san)
+		if err != nil {
+			return fmt.Errorf("%s: %w", san, err)
+		}
+
+		if l.color == "" || l.color[:1] == pos.Turn().String() {
+			if err := l.book.Learn(pos, m, learnResult(result, pos.Turn())); err != nil {
+				// out of the book
+				return nil
+			}
+			l.learned++
+		}
+
+		if ok := pos.MakeMove(m); !ok {
+			return fmt.Errorf("%s: %w", san, errIllegalMove)
+		}
+	}
+
+	return nil
+}
+
+// learnResult returns the result of a game for the color.
+func learnResult(result string, c chess.Color) chess.LearnResult {
+	switch moveWeight(result, c) {
+	case 2:
+		return chess.LearnWin
+	case 1:
+		return chess.LearnDraw
+	default:
+		return chess.LearnLoss
+	}
+}
+
+// readEntries reads the raw entries of a book file.
+func readEntries(path string) ([]chess.BookEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries, err := chess.ReadBookEntries(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return entries, nil
+}
+
+// writeBook writes the book to the file.
+func writeBook(path string, w io.WriterTo) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.WriteTo(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
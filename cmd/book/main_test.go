@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+const gamesFile = "../../test/data/games.pgn"
+
+const afterE4 = "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+	startPos := chess.StartingPosition().String()
+
+	tests := []struct {
+		name   string
+		flags  []string
+		output string
+		want   map[string][]string
+	}{
+		{
+			name:   "all games",
+			output: "5 games read, 3 games added, 6 entries written",
+			want: map[string][]string{
+				startPos: {"e4 3"},
+				afterE4:  {"c5 1"},
+			},
+		},
+		{
+			name:   "min elo",
+			flags:  []string{"-min-elo", "2400"},
+			output: "5 games read, 1 games added, 3 entries written",
+			want: map[string][]string{
+				startPos: {"e4 2"},
+				afterE4:  nil,
+			},
+		},
+		{
+			name:   "max ply",
+			flags:  []string{"-max-ply", "1"},
+			output: "5 games read, 4 games added, 1 entries written",
+			want: map[string][]string{
+				startPos: {"e4 5"},
+				afterE4:  nil,
+			},
+		},
+		{
+			name:   "results",
+			flags:  []string{"-results", "0-1,1/2-1/2"},
+			output: "5 games read, 2 games added, 4 entries written",
+			want: map[string][]string{
+				startPos: {"e4 1"},
+				afterE4:  {"c5 1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "book.bin")
+
+			var w bytes.Buffer
+			args := append([]string{"-o", path}, tt.flags...)
+			require.NoError(t, build(&w, append(args, gamesFile)))
+			assert.Equal(t, fmt.Sprintf("%s to %s\n", tt.output, path), w.String())
+
+			for fen, want := range tt.want {
+				assert.Equal(t, want, lookup(t, path, fen), fen)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.bin")
+	second := filepath.Join(dir, "second.bin")
+	merged := filepath.Join(dir, "merged.bin")
+	require.NoError(t, build(&bytes.Buffer{}, []string{"-o", first, gamesFile}))
+	require.NoError(t, build(&bytes.Buffer{}, []string{"-o", second, "-results", "1-0", gamesFile}))
+
+	var w bytes.Buffer
+	require.NoError(t, merge(&w, []string{"-o", merged, first, second}))
+	assert.Equal(t, fmt.Sprintf("2 books merged, 6 entries written to %s\n", merged), w.String())
+	assert.Equal(t, []string{"e4 5"}, lookup(t, merged, chess.StartingPosition().String()))
+	assert.Equal(t, []string{"c5 1"}, lookup(t, merged, afterE4))
+}
+
+func TestDump(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "book.bin")
+	require.NoError(t, build(&bytes.Buffer{}, []string{"-o", path, "-min-elo", "2400", gamesFile}))
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"entries", []string{path}, []string{
+			"0844931a6ef4b9a0 g1f3 2 0",
+			"463b96181691fc9c e2e4 2 0",
+			"78cda70e17837d9e f1b5 2 0",
+		}},
+		{"position", []string{"-fen", chess.StartingPosition().String(), path}, []string{"e4 2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var w bytes.Buffer
+			require.NoError(t, dump(&w, tt.args))
+			assert.Equal(t, strings.Join(tt.want, "\n")+"\n", w.String())
+		})
+	}
+}
+
+// lookup returns the book moves of the position in SAN with their weights.
+func lookup(t *testing.T, path, fen string) []string {
+	t.Helper()
+	book, err := chess.OpenBook(path)
+	require.NoError(t, err)
+	defer book.Close()
+
+	pos, err := chess.NewPosition(fen)
+	require.NoError(t, err)
+
+	var moves []string
+	for _, wm := range book.Lookup(pos) {
+		moves = append(moves, fmt.Sprintf("%s %d", chess.SAN{}.Encode(pos, wm.Move), wm.Weight))
+	}
+	return moves
+}
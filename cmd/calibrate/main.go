@@ -0,0 +1,338 @@
+// Package main calibrates the Elo ratings of the skill levels with self-play.
+//
+// Each skill level plays a match against the next one. The games start from
+// openings of random moves, each opening being played once with each color.
+// Games are adjudicated as draws after a maximum number of plies.
+//
+// The rating difference between two levels follows from the score of their
+// match. The ratings are chained down from the full strength, anchored at
+// search.MaxElo. The levels measured out of order are given their mean rating,
+// and the ratings are printed as the skillElo table of the search package.
+//
+// Usage:
+//
+//	calibrate [flags]
+//
+// The full strength searches a fixed number of nodes per move, which stands
+// for the time control. The searches are also stopped after a maximum time,
+// as the node limit is only checked between iterations. The ratings are
+// relative to the full strength: they are not measured against rated opponents.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/leonhfr/orca/chess"
+	"github.com/leonhfr/orca/search"
+)
+
+// maxSkillLevel is the skill level of the full strength.
+const maxSkillLevel = 20
+
+func main() {
+	games := flag.Int("games", 40, "number of games of each match, rounded up to an even number")
+	nodes := flag.Int("nodes", 131072, "nodes searched per move at the full strength")
+	plies := flag.Int("plies", 6, "number of random plies of the openings")
+	maxPlies := flag.Int("max-plies", 200, "number of plies after which a game is adjudicated as a draw")
+	moveTime := flag.Duration("movetime", time.Second, "maximum time per move, as the node limit is only checked between iterations")
+	seed := flag.Int64("seed", 1, "seed of the random openings")
+	hash := flag.Int("hash", 16, "hash table size in MB of each engine")
+	verbose := flag.Bool("v", false, "print the result of each game")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 0 || *games <= 0 || *nodes <= 0 || *plies < 0 || *maxPlies <= 0 || *moveTime <= 0 || *hash <= 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	c := calibration{
+		w:        os.Stdout,
+		games:    (*games + 1) / 2 * 2,
+		nodes:    *nodes,
+		plies:    *plies,
+		maxPlies: *maxPlies,
+		moveTime: *moveTime,
+		hash:     *hash,
+		verbose:  *verbose,
+		rand:     rand.New(rand.NewSource(*seed)),
+	}
+	c.run()
+}
+
+// result represents the result of a game from White's point of view.
+type result float64
+
+const (
+	blackWin result = 0
+	draw     result = 0.5
+	whiteWin result = 1
+)
+
+// calibration holds the settings of the matches.
+type calibration struct {
+	w        io.Writer
+	games    int
+	nodes    int
+	plies    int
+	maxPlies int
+	moveTime time.Duration
+	hash     int
+	verbose  bool
+	rand     *rand.Rand
+}
+
+// run plays the matches between consecutive levels and prints the ratings.
+func (c calibration) run() {
+	fmt.Fprintf(c.w, "%d games per match, %d nodes and at most %v per move at the full strength, %d random plies\n\n",
+		c.games, c.nodes, c.moveTime, c.plies)
+	fmt.Fprintln(c.w, "level  opponent  score  difference")
+
+	var diffs [maxSkillLevel]float64
+	for level := maxSkillLevel - 1; level >= 0; level-- {
+		start := time.Now()
+		score := c.match(level, level+1)
+		diff, margin := eloDiff(score, c.games)
+		diffs[level] = diff
+		fmt.Fprintf(c.w, "%5d  %8d  %4.1f%%  %+6.0f ±%.0f  (%s)\n",
+			level, level+1, 100*score, diff, margin, time.Since(start).Round(time.Second))
+	}
+
+	measured := ratings(diffs)
+	elo := ordered(measured)
+	fmt.Fprintln(c.w, "\nlevel  measured  table")
+	for level, rating := range elo {
+		fmt.Fprintf(c.w, "%5d  %8d  %5d\n", level, measured[level], rating)
+	}
+
+	fmt.Fprintln(c.w, "\nvar skillElo = [maxSkillLevel + 1]int{")
+	for i := 0; i < len(elo); i += 10 {
+		values := make([]string, 0, 10)
+		for _, rating := range elo[i:min(i+10, len(elo))] {
+			values = append(values, fmt.Sprint(rating))
+		}
+		fmt.Fprintf(c.w, "\t%s,\n", strings.Join(values, ", "))
+	}
+	fmt.Fprintln(c.w, "}")
+}
+
+// match plays the games of a level against another one and returns
+// the score of the level between 0 and 1.
+func (c calibration) match(level, opponent int) float64 {
+	var points float64
+	for range c.games / 2 {
+		opening := c.opening()
+		for _, white := range [2]bool{true, false} {
+			players := [2]*search.Engine{c.engine(opponent), c.engine(level)}
+			if white {
+				players[0], players[1] = players[1], players[0]
+			}
+
+			start := time.Now()
+			r, plies := c.play(players, opening)
+			if c.verbose {
+				fmt.Fprintf(c.w, "  level %d as %s: %s in %d plies (%s)\n",
+					level, colorName(white), r, plies, time.Since(start).Round(time.Second))
+			}
+			if white {
+				points += float64(r)
+			} else {
+				points += 1 - float64(r)
+			}
+
+			for _, e := range players {
+				e.Close()
+			}
+		}
+	}
+	return points / float64(c.games)
+}
+
+// engine returns a new engine playing at the given level.
+func (c calibration) engine(level int) *search.Engine {
+	return search.NewEngine(search.WithSkillLevel(level), search.WithTableSize(c.hash))
+}
+
+// opening returns the moves of a random opening that does not end the game.
+func (c calibration) opening() []chess.Move {
+	for {
+		pos := chess.StartingPosition()
+		moves := make([]chess.Move, 0, c.plies)
+		for range c.plies {
+			legal := legalMoves(pos)
+			if len(legal) == 0 {
+				break
+			}
+			m := legal[c.rand.Intn(len(legal))]
+			pos.MakeMove(m)
+			moves = append(moves, m)
+		}
+
+		if len(moves) == c.plies && len(legalMoves(pos)) > 0 {
+			return moves
+		}
+	}
+}
+
+// play plays a game between the engines, White first, from the opening
+// and returns its result and its number of plies.
+func (c calibration) play(players [2]*search.Engine, opening []chess.Move) (result, int) {
+	pos := chess.StartingPosition()
+	var hashes []chess.Hash
+	for _, m := range opening {
+		hashes = append(hashes, pos.Hash())
+		pos.MakeMove(m)
+	}
+
+	for ply := len(opening); ; ply++ {
+		if r, over := outcome(pos, hashes); over {
+			return r, ply
+		}
+		if ply >= c.maxPlies {
+			return draw, ply
+		}
+
+		e := players[ply%2]
+		e.SetHistory(hashes)
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.moveTime)
+		var move chess.Move
+		for o := range e.Search(ctx, pos, 0, c.nodes) {
+			if len(o.PV) > 0 {
+				move = o.PV[0]
+			}
+		}
+		cancel()
+
+		hashes = append(hashes, pos.Hash())
+		if !pos.MakeMove(move) {
+			panic(fmt.Sprintf("illegal move %s in %s", move, pos))
+		}
+	}
+}
+
+// outcome returns the result of the game in the position reached after
+// the positions of the given hashes, and whether the game is over.
+func outcome(pos *chess.Position, hashes []chess.Hash) (result, bool) {
+	if pos.HasInsufficientMaterial() || pos.HalfMoveClock() >= 100 {
+		return draw, true
+	}
+
+	repetitions := 1
+	for _, hash := range hashes {
+		if hash == pos.Hash() {
+			repetitions++
+		}
+	}
+	if repetitions >= 3 {
+		return draw, true
+	}
+
+	if len(legalMoves(pos)) > 0 {
+		return draw, false
+	}
+
+	_, inCheck := pos.InCheck()
+	switch {
+	case !inCheck:
+		return draw, true
+	case pos.Turn() == chess.White:
+		return blackWin, true
+	default:
+		return whiteWin, true
+	}
+}
+
+// ordered returns the ratings made non-decreasing by replacing the ratings
+// of the levels out of order with their mean, as the levels that hardly
+// differ are measured out of order within the error margins.
+// The full strength keeps its rating.
+func ordered(elo [maxSkillLevel + 1]int) [maxSkillLevel + 1]int {
+	type block struct{ sum, count int }
+	blocks := make([]block, 0, maxSkillLevel)
+	for _, rating := range elo[:maxSkillLevel] {
+		blocks = append(blocks, block{rating, 1})
+		for n := len(blocks); n > 1 && blocks[n-2].sum*blocks[n-1].count > blocks[n-1].sum*blocks[n-2].count; n-- {
+			blocks[n-2] = block{blocks[n-2].sum + blocks[n-1].sum, blocks[n-2].count + blocks[n-1].count}
+			blocks = blocks[:n-1]
+		}
+	}
+
+	var fitted [maxSkillLevel + 1]int
+	level := 0
+	for _, b := range blocks {
+		mean := int(math.Round(float64(b.sum) / float64(b.count)))
+		for range b.count {
+			fitted[level] = min(mean, elo[maxSkillLevel])
+			level++
+		}
+	}
+	fitted[maxSkillLevel] = elo[maxSkillLevel]
+	return fitted
+}
+
+// String implements the fmt.Stringer interface.
+func (r result) String() string {
+	switch r {
+	case whiteWin:
+		return "1-0"
+	case blackWin:
+		return "0-1"
+	default:
+		return "1/2-1/2"
+	}
+}
+
+// colorName returns the name of the color playing first or second.
+func colorName(white bool) string {
+	if white {
+		return "white"
+	}
+	return "black"
+}
+
+// legalMoves returns the legal moves of the position.
+func legalMoves(pos *chess.Position) []chess.Move {
+	var ml chess.MoveList
+	checkData, _ := pos.InCheck()
+	return pos.AppendLegalMoves(ml[:0], checkData)
+}
+
+// eloDiff returns the rating difference matching the score of a match,
+// and the margin of its 95% confidence interval.
+//
+// Scores of 0 and 1 are counted as half a point from them,
+// as the difference is otherwise infinite.
+func eloDiff(score float64, games int) (float64, float64) {
+	half := 0.5 / float64(games)
+	score = min(max(score, half), 1-half)
+
+	diff := -400 * math.Log10(1/score-1)
+	stdErr := math.Sqrt(score * (1 - score) / float64(games))
+	margin := 1.96 * stdErr * 400 / math.Ln10 / (score * (1 - score))
+	return diff, margin
+}
+
+// ratings returns the rating of each level from the rating differences
+// of each level with the next one, the full strength being rated search.MaxElo.
+func ratings(diffs [maxSkillLevel]float64) [maxSkillLevel + 1]int {
+	var elo [maxSkillLevel + 1]int
+	rating := float64(search.MaxElo)
+	elo[maxSkillLevel] = search.MaxElo
+	for level := maxSkillLevel - 1; level >= 0; level-- {
+		rating += diffs[level]
+		elo[level] = int(math.Round(rating))
+	}
+	return elo
+}
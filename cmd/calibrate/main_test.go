@@ -0,0 +1,125 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+func TestOutcome(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		fen    string
+		moves  []string
+		result result
+		over   bool
+	}{
+		{"starting position", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", nil, draw, false},
+		{"white mates", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", []string{"a1a8"}, whiteWin, true},
+		{"black mates", "rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", []string{"d8h4"}, blackWin, true},
+		{"stalemate", "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", nil, draw, true},
+		{"insufficient material", "8/8/4k3/8/8/4K3/8/8 w - - 0 1", nil, draw, true},
+		{"fifty-move rule", "8/8/4k3/8/8/4K3/4R3/8 w - - 99 80", []string{"e3d3"}, draw, true},
+		{"threefold repetition", "8/8/4k3/8/8/4K3/4R3/8 w - - 0 1", []string{"e3d3", "e6d6", "d3e3", "d6e6", "e3d3", "e6d6", "d3e3", "d6e6"}, draw, true},
+		{"twofold repetition", "8/8/4k3/8/8/4K3/4R3/8 w - - 0 1", []string{"e3d3", "e6d6", "d3e3", "d6e6"}, draw, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pos, err := chess.NewPosition(tt.fen)
+			require.NoError(t, err)
+
+			var hashes []chess.Hash
+			for _, s := range tt.moves {
+				m, err := chess.UCI{}.Decode(pos, s)
+				require.NoError(t, err)
+				hashes = append(hashes, pos.Hash())
+				require.True(t, pos.MakeMove(m))
+			}
+
+			r, over := outcome(pos, hashes)
+			assert.Equal(t, tt.over, over)
+			assert.Equal(t, tt.result, r)
+		})
+	}
+}
+
+func TestEloDiff(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		score  float64
+		games  int
+		diff   float64
+		margin float64
+	}{
+		{0.5, 100, 0, 68},
+		{0.75, 100, 191, 79},
+		{0.25, 100, -191, 79},
+		{1, 10, 512, 494},
+		{0, 10, -512, 494},
+	}
+
+	for _, tt := range tests {
+		diff, margin := eloDiff(tt.score, tt.games)
+		assert.InDelta(t, tt.diff, diff, 1, tt.score)
+		assert.InDelta(t, tt.margin, margin, 1, tt.score)
+	}
+}
+
+func TestRatings(t *testing.T) {
+	t.Parallel()
+	var diffs [maxSkillLevel]float64
+	for level := range diffs {
+		diffs[level] = -50.4
+	}
+
+	elo := ratings(diffs)
+	assert.Equal(t, 2400, elo[maxSkillLevel])
+	assert.Equal(t, 2350, elo[maxSkillLevel-1])
+	assert.Equal(t, 1392, elo[0])
+}
+
+func TestOrdered(t *testing.T) {
+	t.Parallel()
+	elo := [maxSkillLevel + 1]int{
+		700, 800, 1000, 1100, 1300, 1360, 1450, 1510, 1630, 1660,
+		1853, 1844, 1870, 1879, 1940, 1966, 2113, 2184, 2331, 2242,
+		2400,
+	}
+	want := [maxSkillLevel + 1]int{
+		700, 800, 1000, 1100, 1300, 1360, 1450, 1510, 1630, 1660,
+		1849, 1849, 1870, 1879, 1940, 1966, 2113, 2184, 2287, 2287,
+		2400,
+	}
+	assert.Equal(t, want, ordered(elo))
+
+	// three levels out of order and a level above the full strength
+	elo[3], elo[4], elo[5] = 1400, 1200, 1100
+	elo[19] = 2500
+	want[3], want[4], want[5] = 1233, 1233, 1233
+	want[18], want[19] = 2331, 2400
+	assert.Equal(t, want, ordered(elo))
+}
+
+func TestCalibration_Opening(t *testing.T) {
+	t.Parallel()
+	c := calibration{plies: 8, rand: rand.New(rand.NewSource(1))}
+
+	for range 10 {
+		moves := c.opening()
+		require.Len(t, moves, 8)
+
+		pos := chess.StartingPosition()
+		for _, m := range moves {
+			require.True(t, pos.MakeMove(m))
+		}
+		_, over := outcome(pos, nil)
+		assert.False(t, over)
+	}
+}
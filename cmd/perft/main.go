@@ -0,0 +1,188 @@
+// Package main runs perft test suites.
+//
+// The suite is an EPD file where each line holds a position followed by
+// the expected number of nodes at each depth:
+//
+//	rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 ;D1 20 ;D2 400 ;D3 8902
+//
+// Usage:
+//
+//	perft [flags] <suite.epd>
+//
+// Mismatches are reported with the divide output of the failing depth.
+// Exits with a non-zero status when a position fails.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+func main() {
+	chess960 := flag.Bool("chess960", false, "decode positions as Shredder-FEN (Chess960 castling)")
+	maxDepth := flag.Int("depth", 0, "maximum depth to test, 0 tests all depths")
+	hash := flag.Int("hash", 64, "hash table size in MB, 0 disables it")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of goroutines dividing the root moves")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <suite.epd>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	r := runner{
+		w:        os.Stdout,
+		notation: chess.FEN{},
+		maxDepth: *maxDepth,
+		options:  []chess.PerftOption{chess.WithPerftHash(*hash), chess.WithPerftWorkers(*workers)},
+	}
+	if *chess960 {
+		r.notation = chess.ShredderFEN{}
+	}
+
+	failed, err := r.run(f)
+	_ = f.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// runner runs the positions of a perft suite.
+type runner struct {
+	w        io.Writer
+	notation chess.Notation
+	options  []chess.PerftOption
+	maxDepth int
+}
+
+// run runs all the positions of the suite and returns the number of failed positions.
+func (r runner) run(suite io.Reader) (int, error) {
+	var total, failed int
+	start := time.Now()
+
+	scanner := bufio.NewScanner(suite)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		entry, err := parseEntry(text)
+		if err != nil {
+			return failed, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		pos, err := r.notation.Decode(entry.fen)
+		if err != nil {
+			return failed, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		total++
+		if !r.runEntry(pos, entry, line) {
+			failed++
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return failed, err
+	}
+
+	fmt.Fprintf(r.w, "\npassed %d/%d positions in %v\n", total-failed, total, time.Since(start).Round(time.Millisecond))
+	return failed, nil
+}
+
+// runEntry runs the depths of a single position and reports the first mismatch.
+func (r runner) runEntry(pos *chess.Position, entry suiteEntry, line int) bool {
+	for _, d := range entry.depths {
+		if r.maxDepth > 0 && d.depth > r.maxDepth {
+			break
+		}
+
+		result := pos.Perft(d.depth, r.options...)
+		if result.Nodes() != d.nodes {
+			fmt.Fprintf(r.w, "FAIL line %d: %s\n", line, entry.fen)
+			fmt.Fprintf(r.w, "     depth %d: got %d nodes, want %d\n\n", d.depth, result.Nodes(), d.nodes)
+			fmt.Fprintf(r.w, "%v\n\n", result)
+			return false
+		}
+	}
+
+	fmt.Fprintf(r.w, "ok   line %d: %s\n", line, entry.fen)
+	return true
+}
+
+// suiteEntry represents a position of a perft suite.
+type suiteEntry struct {
+	fen    string
+	depths []suiteDepth
+}
+
+// suiteDepth holds the expected number of nodes at a depth.
+type suiteDepth struct {
+	depth int
+	nodes int
+}
+
+var errMissingDepths = errors.New("missing expected node counts")
+
+// parseEntry parses an EPD line of a perft suite.
+//
+// The position may omit the half move clock and full move number.
+func parseEntry(line string) (suiteEntry, error) {
+	parts := strings.Split(line, ";")
+	if len(parts) < 2 {
+		return suiteEntry{}, errMissingDepths
+	}
+
+	fen := strings.Join(strings.Fields(parts[0]), " ")
+	if len(strings.Fields(fen)) == 4 {
+		fen += " 0 1"
+	}
+
+	entry := suiteEntry{fen: fen}
+	for _, part := range parts[1:] {
+		fields := strings.Fields(part)
+		if len(fields) != 2 || !strings.HasPrefix(fields[0], "D") {
+			return suiteEntry{}, fmt.Errorf("invalid depth field (%s)", strings.TrimSpace(part))
+		}
+
+		depth, err := strconv.Atoi(fields[0][1:])
+		if err != nil {
+			return suiteEntry{}, fmt.Errorf("invalid depth field (%s): %w", strings.TrimSpace(part), err)
+		}
+
+		nodes, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return suiteEntry{}, fmt.Errorf("invalid depth field (%s): %w", strings.TrimSpace(part), err)
+		}
+
+		entry.depths = append(entry.depths, suiteDepth{depth, nodes})
+	}
+
+	return entry, nil
+}
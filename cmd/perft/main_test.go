@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+const startPos = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+func TestParseEntry(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		line  string
+		entry suiteEntry
+		err   string
+	}{
+		{
+			name:  "full fen",
+			line:  startPos + " ;D1 20 ;D2 400",
+			entry: suiteEntry{startPos, []suiteDepth{{1, 20}, {2, 400}}},
+		},
+		{
+			name:  "without clocks",
+			line:  "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - ;D1 20",
+			entry: suiteEntry{startPos, []suiteDepth{{1, 20}}},
+		},
+		{
+			name:  "extra spaces",
+			line:  "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR  w KQkq -   0 1;  D1   20 ",
+			entry: suiteEntry{startPos, []suiteDepth{{1, 20}}},
+		},
+		{
+			name: "missing depths",
+			line: startPos,
+			err:  errMissingDepths.Error(),
+		},
+		{
+			name: "missing node count",
+			line: startPos + " ;D1",
+			err:  "invalid depth field (D1)",
+		},
+		{
+			name: "empty field",
+			line: startPos + " ;D1 20 ;",
+			err:  "invalid depth field ()",
+		},
+		{
+			name: "unknown field",
+			line: startPos + " ;N1 20",
+			err:  "invalid depth field (N1 20)",
+		},
+		{
+			name: "invalid depth",
+			line: startPos + " ;Dx 20",
+			err:  `invalid depth field (Dx 20): strconv.Atoi: parsing "x": invalid syntax`,
+		},
+		{
+			name: "invalid node count",
+			line: startPos + " ;D1 twenty",
+			err:  `invalid depth field (D1 twenty): strconv.Atoi: parsing "twenty": invalid syntax`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			entry, err := parseEntry(tt.line)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.entry, entry)
+		})
+	}
+}
+
+func TestRunner_RunEntry(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		depths   []suiteDepth
+		maxDepth int
+		ok       bool
+		output   string
+	}{
+		{"all depths", []suiteDepth{{1, 20}, {2, 400}, {3, 8902}}, 0, true, "ok   line 1"},
+		{"mismatch", []suiteDepth{{1, 20}, {2, 401}}, 0, false, "depth 2: got 400 nodes, want 401"},
+		{"mismatch beyond max depth", []suiteDepth{{1, 20}, {2, 401}}, 1, true, "ok   line 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			r := runner{w: &buf, notation: chess.FEN{}, maxDepth: tt.maxDepth}
+
+			ok := r.runEntry(chess.StartingPosition(), suiteEntry{startPos, tt.depths}, 1)
+			assert.Equal(t, tt.ok, ok)
+			assert.Contains(t, buf.String(), tt.output)
+		})
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		suite  []string
+		failed int
+		err    string
+	}{
+		{
+			name: "passing suite",
+			suite: []string{
+				"# perft suite",
+				startPos + " ;D1 20 ;D2 400",
+				"",
+				"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - ;D1 48 ;D2 2039",
+			},
+		},
+		{
+			name:   "failing position",
+			suite:  []string{startPos + " ;D1 20", startPos + " ;D1 21"},
+			failed: 1,
+		},
+		{
+			name:  "malformed line",
+			suite: []string{startPos + " ;D1 20", startPos + " ;D1"},
+			err:   "line 2: invalid depth field (D1)",
+		},
+		{
+			name:  "invalid position",
+			suite: []string{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP w KQkq - 0 1 ;D1 20"},
+			err:   "line 1: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			r := runner{w: &buf, notation: chess.FEN{}}
+
+			failed, err := r.run(strings.NewReader(strings.Join(tt.suite, "\n")))
+			assert.Equal(t, tt.failed, failed)
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.True(t, strings.HasPrefix(err.Error(), tt.err), err.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Contains(t, buf.String(), "passed")
+		})
+	}
+}
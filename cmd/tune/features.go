@@ -0,0 +1,390 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/leonhfr/orca/chess"
+	"github.com/leonhfr/orca/search"
+)
+
+// entry holds a position of the dataset as the coefficients of the weights
+// in its evaluation, from White's point of view.
+type entry struct {
+	result float64
+	coefs  []coef
+}
+
+// coef is the coefficient of a weight in the evaluation of a position,
+// the middle and end game parts being tapered by the phase of the position.
+type coef struct {
+	index int32
+	value float32
+}
+
+// extractor extracts the coefficients of the weights in the evaluation.
+//
+// Mirrors the evaluation of the search package.
+type extractor struct {
+	params  *search.EvalParams
+	weights []*int32
+	index   map[*int32]int
+}
+
+// newExtractor returns an extractor of the weights.
+func newExtractor(params *search.EvalParams) *extractor {
+	weights := flatten(reflect.ValueOf(params).Elem(), nil)
+	index := make(map[*int32]int, len(weights))
+	for i, w := range weights {
+		index[w] = i
+	}
+
+	return &extractor{
+		params:  params,
+		weights: weights,
+		index:   index,
+	}
+}
+
+// flatten appends the addresses of the weights held in the value.
+func flatten(v reflect.Value, weights []*int32) []*int32 {
+	switch v.Kind() {
+	case reflect.Int32:
+		return append(weights, v.Addr().Interface().(*int32))
+	case reflect.Array, reflect.Slice:
+		for i := range v.Len() {
+			weights = flatten(v.Index(i), weights)
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			weights = flatten(v.Field(i), weights)
+		}
+	}
+	return weights
+}
+
+// features accumulates the coefficients of the weights in a position.
+type features struct {
+	x      *extractor
+	phase  float64
+	values map[int]float64
+}
+
+// add adds the middle and end game coefficients of the weight.
+func (f *features) add(w *int32, mg, eg float64) {
+	f.values[f.x.index[w]] += (f.phase*mg + (24-f.phase)*eg) / 24
+}
+
+// addBoth adds the coefficient of a weight applying to both game phases.
+func (f *features) addBoth(w *int32, value float64) {
+	f.add(w, value, value)
+}
+
+// coefs returns the non-zero coefficients, sorted by weight.
+func (f *features) coefs() []coef {
+	coefs := make([]coef, 0, len(f.values))
+	for i, v := range f.values {
+		if v != 0 {
+			coefs = append(coefs, coef{int32(i), float32(v)})
+		}
+	}
+	slices.SortFunc(coefs, func(a, b coef) int { return int(a.index - b.index) })
+	return coefs
+}
+
+// extract returns the entry of the position.
+//
+// Returns false if the evaluation scores the position with a specialized function.
+func (x *extractor) extract(pos *chess.Position, result float64) (entry, bool) {
+	p := x.params
+	knights, bishops, rooks, queens := pos.CountPieces()
+	if knights+bishops+rooks+queens <= 2 {
+		return entry{}, false
+	}
+
+	phase := min(knights+bishops+2*rooks+4*queens, 24)
+	f := &features{x: x, phase: float64(phase), values: make(map[int]float64)}
+
+	var mgMaterial, egMaterial [2]int32
+	pos.BoardMap(func(piece chess.Piece, _ chess.Square) {
+		c, pt := piece.Color(), piece.Type()
+		mgMaterial[c] += p.PieceValuesMG[pt]
+		egMaterial[c] += p.PieceValuesEG[pt]
+		f.add(&p.PieceValuesMG[pt], sign(c), 0)
+		f.add(&p.PieceValuesEG[pt], 0, sign(c))
+	})
+
+	pos.PawnMap(func(piece chess.Piece, sq chess.Square, properties chess.PawnProperty) {
+		c, s := piece.Color(), sign(piece.Color())
+		rank, file := humanRank(c, sq), sq.File()
+
+		f.add(&p.PieceSquareMG[chess.Pawn][rank][file], s, 0)
+		f.add(&p.PieceSquareEG[chess.Pawn][rank][file], 0, s)
+
+		if properties.HasProperty(chess.Doubled) {
+			f.add(&p.DoubledPenaltyMG[file], s, 0)
+			f.add(&p.DoubledPenaltyEG[file], 0, s)
+		}
+
+		if properties.HasProperty(chess.Isolani) {
+			f.add(&p.IsolaniPenaltyMG[file], s, 0)
+			f.add(&p.IsolaniPenaltyEG[file], 0, s)
+		}
+
+		if properties.HasProperty(chess.Backward) {
+			f.add(&p.BackwardPenaltyMG[file], s, 0)
+			f.add(&p.BackwardPenaltyEG[file], 0, s)
+		}
+
+		if properties.HasProperty(chess.Phalanx) {
+			f.add(&p.PhalanxBonusMG[rank], s, 0)
+			f.add(&p.PhalanxBonusEG[rank], 0, s)
+		}
+
+		if properties.HasProperty(chess.Supported) {
+			f.add(&p.SupportedBonusMG[rank], s, 0)
+			f.add(&p.SupportedBonusEG[rank], 0, s)
+		}
+
+		if properties.HasProperty(chess.Candidate) {
+			f.add(&p.CandidateBonusMG[rank], s, 0)
+			f.add(&p.CandidateBonusEG[rank], 0, s)
+		}
+
+		if properties.HasProperty(chess.Passed) {
+			f.add(&p.PassedBonusMG[rank][file], s, 0)
+			f.add(&p.PassedBonusEG[rank][file], 0, s)
+			f.extractPassed(pos, c, sq)
+		}
+	})
+
+	var bishopCount, queenCount [2]int
+	var earlyQueen [2]bool
+	fd := pos.FileData()
+	pos.PieceMap(func(piece chess.Piece, sq chess.Square, mobility int, properties chess.PieceProperty) {
+		c, pt, s := piece.Color(), piece.Type(), sign(piece.Color())
+		rank, file := humanRank(c, sq), sq.File()
+
+		f.add(&p.PieceSquareMG[pt][rank][file], s, 0)
+		f.add(&p.PieceSquareEG[pt][rank][file], 0, s)
+		f.add(&p.MobilityMG[pt][mobility], s, 0)
+		f.add(&p.MobilityEG[pt][mobility], 0, s)
+
+		if properties.HasProperty(chess.Trapped) {
+			f.addBoth(&p.TrappedPiecePenalty[pt], s)
+		}
+
+		if properties.HasProperty(chess.Lost) {
+			f.addBoth(&p.LostPiecePenalty[pt], s)
+		}
+
+		if pt == chess.Rook {
+			switch {
+			case sq.Rank() == penultimateRank(c):
+				f.addBoth(&p.RookPenultimateRank, s)
+			case fd.OnOpenFile(sq):
+				f.addBoth(&p.RookOpenFile, s)
+			case fd.OnHalfOpenFile(sq, c.Other()):
+				f.addBoth(&p.RookHalfOpenFile, s)
+			}
+
+			if properties.HasProperty(chess.Connected) {
+				f.addBoth(&p.ConnectedRooks, s)
+			}
+		}
+
+		if properties.HasProperty(chess.Outpost) {
+			f.add(&p.OutpostBonusMG[pt], s, 0)
+			f.add(&p.OutpostBonusEG[pt], 0, s)
+		}
+
+		if properties.HasProperty(chess.AttackedByPawn) {
+			f.add(&p.PawnThreatMG[pt], s, 0)
+			f.add(&p.PawnThreatEG[pt], 0, s)
+		}
+
+		if properties.HasProperty(chess.Threatened) {
+			f.add(&p.PieceThreatMG[pt], s, 0)
+			f.add(&p.PieceThreatEG[pt], 0, s)
+		}
+
+		if properties.HasProperty(chess.Hanging) {
+			f.add(&p.HangingPenaltyMG, s, 0)
+			f.add(&p.HangingPenaltyEG, 0, s)
+		}
+
+		switch pt {
+		case chess.Bishop:
+			bishopCount[c]++
+		case chess.Queen:
+			queenCount[c]++
+			earlyQueen[c] = sq != queenSquare(c)
+		}
+	})
+
+	for c := chess.Black; c <= chess.White; c++ {
+		if earlyQueen[c] && queenCount[c] == 1 && !pos.Chess960Setup(c) {
+			f.add(&p.EarlyQueenMG, sign(c)*float64(undevelopedMinors(pos, c)), 0)
+		}
+	}
+
+	for c := chess.Black; c <= chess.White; c++ {
+		if bishopCount[c] >= 2 {
+			f.add(&p.BishopPairMG, sign(c), 0)
+			f.add(&p.BishopPairEG, 0, sign(c))
+		}
+	}
+
+	// the king safety factor is held constant during the tuning
+	initialMaterialValue := 8*p.PieceValuesMG[chess.Pawn] +
+		2*p.PieceValuesMG[chess.Knight] +
+		2*p.PieceValuesMG[chess.Bishop] +
+		2*p.PieceValuesMG[chess.Rook] +
+		p.PieceValuesMG[chess.Queen] +
+		p.PieceValuesMG[chess.King]
+
+	pos.KingMap(func(piece chess.Piece, sq chess.Square, shieldDefects, openFiles, halfOpenFiles int) {
+		c, s := piece.Color(), sign(piece.Color())
+		rank, file := humanRank(c, sq), sq.File()
+
+		other := c.Other()
+		material := (int32(phase)*mgMaterial[other] + (24-int32(phase))*egMaterial[other]) / 24
+		factor := s * float64(material/initialMaterialValue)
+
+		f.add(&p.PieceSquareMG[chess.King][rank][file], s, 0)
+		f.add(&p.PieceSquareEG[chess.King][rank][file], 0, s)
+		f.add(&p.ShieldDefectsMG[shieldDefects], factor, 0)
+		f.add(&p.ShieldDefectsEG[shieldDefects], 0, factor)
+		f.add(&p.OpenFilePenaltyMG, float64(openFiles)*factor, 0)
+		f.add(&p.OpenFilePenaltyEG, 0, float64(openFiles)*factor)
+		f.add(&p.HalfOpenFilePenaltyMG, float64(halfOpenFiles)*factor, 0)
+		f.add(&p.HalfOpenFilePenaltyEG, 0, float64(halfOpenFiles)*factor)
+
+		// the attack units are held constant during the tuning
+		if ka := pos.KingAttacks(c); ka.Attackers >= 2 {
+			f.add(&p.KingSafetyMG[attackUnits(p, ka)], s, 0)
+		}
+	})
+
+	f.add(&p.Tempo, sign(pos.Turn()), 0)
+
+	return entry{result: result, coefs: f.coefs()}, true
+}
+
+// extractPassed adds the coefficients of the passed pawn against the kings and the pieces.
+func (f *features) extractPassed(pos *chess.Position, c chess.Color, sq chess.Square) {
+	p, s := f.x.params, sign(c)
+	rank := humanRank(c, sq)
+
+	stop, promotion := sq+8, chess.Square(sq.File())+chess.A8
+	if c == chess.Black {
+		stop, promotion = sq-8, chess.Square(sq.File())
+	}
+
+	f.add(&p.PassedKingOwnEG[rank], 0, s*float64(distance(pos.KingSquare(c), stop)))
+	f.add(&p.PassedKingEnemyEG[rank], 0, s*float64(distance(pos.KingSquare(c.Other()), stop)))
+
+	if pos.PieceAt(stop) != chess.NoPiece {
+		f.add(&p.BlockedPassedMG[rank], s, 0)
+		f.add(&p.BlockedPassedEG[rank], 0, s)
+		return
+	}
+
+	if pos.CountColorPieces(c.Other()) > 0 {
+		return
+	}
+
+	for next := sq; next != promotion; {
+		if c == chess.White {
+			next += 8
+		} else {
+			next -= 8
+		}
+		if pos.PieceAt(next) != chess.NoPiece {
+			return
+		}
+	}
+
+	kingDistance := distance(pos.KingSquare(c.Other()), promotion)
+	if pos.Turn() != c {
+		kingDistance--
+	}
+
+	if min(distance(sq, promotion), 5) < kingDistance {
+		f.add(&p.UnstoppablePassedEG, 0, s)
+	}
+}
+
+// attackUnits returns the attack units of the enemy on the king zone,
+// bounded by the size of the king safety table.
+func attackUnits(p *search.EvalParams, ka chess.KingAttack) int {
+	var units int32
+	for pt := chess.Pawn; pt <= chess.King; pt++ {
+		units += int32(ka.Attacks[pt])*p.KingAttackUnits[pt] + int32(ka.SafeChecks[pt])*p.SafeCheckUnits[pt]
+	}
+	units += int32(ka.WeakSquares) * p.WeakSquareUnits
+	return int(min(max(units, 0), int32(len(p.KingSafetyMG)-1)))
+}
+
+// sign returns 1 for White and -1 for Black.
+func sign(c chess.Color) float64 {
+	if c == chess.White {
+		return 1
+	}
+	return -1
+}
+
+// humanRank returns the index of the rank of the square
+// in the tables laid out from White's point of view.
+func humanRank(c chess.Color, sq chess.Square) int {
+	if c == chess.Black {
+		return int(sq.Rank())
+	}
+	return 7 - int(sq.Rank())
+}
+
+// queenSquare returns the starting square of the queen of the color in classic chess.
+func queenSquare(c chess.Color) chess.Square {
+	if c == chess.Black {
+		return chess.D8
+	}
+	return chess.D1
+}
+
+// undevelopedMinors returns the number of minor pieces of the color on their starting squares in classic chess.
+func undevelopedMinors(pos *chess.Position, c chess.Color) int {
+	squares := []chess.Square{chess.B1, chess.C1, chess.F1, chess.G1}
+	if c == chess.Black {
+		squares = []chess.Square{chess.B8, chess.C8, chess.F8, chess.G8}
+	}
+
+	var count int
+	for _, sq := range squares {
+		if p := pos.PieceAt(sq); p != chess.NoPiece && p.Color() == c &&
+			(p.Type() == chess.Knight || p.Type() == chess.Bishop) {
+			count++
+		}
+	}
+	return count
+}
+
+// penultimateRank returns the penultimate rank of the color.
+func penultimateRank(c chess.Color) chess.Rank {
+	if c == chess.Black {
+		return chess.Rank2
+	}
+	return chess.Rank7
+}
+
+// distance returns the number of king moves between the squares.
+func distance(a, b chess.Square) int {
+	return max(abs(int(a.File())-int(b.File())), abs(int(a.Rank())-int(b.Rank())))
+}
+
+// abs returns the absolute value of the integer.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonhfr/orca/chess"
+	"github.com/leonhfr/orca/search"
+)
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w HAha - 0 1",
+		"r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w HA - 1 8",
+		"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1",
+		"rnbqkbnQ/pppp1p1p/8/4p3/8/8/PPPP1PPP/RNBQKBNR b HAa - 0 5",
+		"qbb1rkrn/1ppppppp/p7/7n/8/P2P4/1PP1PPPP/QBBRNKRN w GDgd - 0 9",
+	}
+
+	for i, fen := range fens {
+		t.Run(fen, func(t *testing.T) {
+			t.Parallel()
+			r := rand.New(rand.NewSource(int64(i)))
+			params := search.DefaultEvalParams()
+			x := newExtractor(&params)
+			e := search.NewEngine(search.WithEvalParams(params))
+
+			pos, err := chess.ShredderFEN{}.Decode(fen)
+			require.NoError(t, err)
+
+			for range 80 {
+				if entry, ok := x.extract(pos, 0.5); ok {
+					var score float64
+					for _, c := range entry.coefs {
+						score += float64(c.value) * float64(*x.weights[c.index])
+					}
+
+					trace := e.Trace(pos)
+					want := float64(trace.Classic)
+					if pos.Turn() == chess.Black {
+						want = -want
+					}
+					// the evaluation rounds the tapered score down to an integer
+					require.LessOrEqual(t, math.Abs(score-want), 1.0, pos.String())
+				}
+
+				var ml chess.MoveList
+				checkData, _ := pos.InCheck()
+				moves := pos.AppendLegalMoves(ml[:0], checkData)
+				if len(moves) == 0 {
+					break
+				}
+				require.True(t, pos.MakeMove(moves[r.Intn(len(moves))]))
+			}
+		})
+	}
+}
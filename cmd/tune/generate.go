@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"go/format"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/leonhfr/orca/search"
+)
+
+//go:embed params.go.tmpl
+var paramsTemplate string
+
+// paramsField contains the data to pass a field of the weights to the template.
+type paramsField struct {
+	Name  string
+	Type  string // empty for scalar weights
+	Value string
+}
+
+// writeParams writes the weights as the Go source of the default weights,
+// or as JSON when the path has the .json extension.
+func writeParams(path string, params search.EvalParams) error {
+	format := formatParams
+	if filepath.Ext(path) == ".json" {
+		format = formatJSON
+	}
+
+	code, err := format(params)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, code, 0o644)
+}
+
+// formatJSON formats the weights as a JSON object readable by search.ReadEvalParams.
+func formatJSON(params search.EvalParams) ([]byte, error) {
+	data, err := json.MarshalIndent(params, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// formatParams formats the weights to valid Go code using the template.
+func formatParams(params search.EvalParams) ([]byte, error) {
+	var fields []paramsField
+	v := reflect.ValueOf(params)
+	for i := range v.NumField() {
+		field := paramsField{Name: v.Type().Field(i).Name, Value: literal(v.Field(i))}
+		if kind := v.Field(i).Kind(); kind == reflect.Array || kind == reflect.Slice {
+			field.Type = v.Field(i).Type().String()
+		}
+		fields = append(fields, field)
+	}
+
+	t := template.Must(template.New("").Parse(paramsTemplate))
+
+	var code bytes.Buffer
+	err := t.Execute(&code, struct {
+		Fields []paramsField
+	}{
+		Fields: fields,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(code.Bytes())
+}
+
+// literal returns the Go literal of the value, without its type.
+//
+// Arrays of weights are written on a single line, other arrays
+// with an element per line.
+func literal(v reflect.Value) string {
+	if v.Kind() == reflect.Int32 {
+		return strconv.FormatInt(v.Int(), 10)
+	}
+
+	elems := make([]string, v.Len())
+	for i := range elems {
+		elems[i] = literal(v.Index(i))
+	}
+
+	if elem := v.Type().Elem().Kind(); elem == reflect.Int32 {
+		return "{" + strings.Join(elems, ", ") + "}"
+	}
+
+	return "{\n" + strings.Join(elems, ",\n") + ",\n}"
+}
@@ -0,0 +1,201 @@
+// Package main tunes the evaluation weights with the Texel method.
+//
+// The dataset holds one position per line, followed by the result of the game
+// it was taken from, as in the EPD or FEN datasets commonly used for tuning:
+//
+//	rnbqkb1r/pp2pppp/3p1n2/8/3NP3/8/PPP2PPP/RNBQKB1R w KQkq - c9 "1/2-1/2";
+//	rnbqkb1r/pp2pppp/3p1n2/8/3NP3/8/PPP2PPP/RNBQKB1R w KQkq - 1 5 [0.5]
+//
+// Results are read as 1-0, 0-1 and 1/2-1/2, or as a score of 1.0, 0.5 or 0.0,
+// from White's point of view.
+//
+// Usage:
+//
+//	tune [flags] <dataset>...
+//
+// The weights start from the current evaluation. The coefficient of each weight
+// in the evaluation of the positions is extracted once, then the weights are
+// optimised by gradient descent on the mean squared error between the results
+// and the evaluations mapped to expected scores by a sigmoid. Endgames with
+// at most two pieces besides kings and pawns are skipped, as the evaluation
+// scores them with specialized functions.
+//
+// The tuned weights are written as the Go source of the default weights
+// of the evaluation, or as JSON loadable with the EvalFile option
+// when the output file has the .json extension.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leonhfr/orca/chess"
+	"github.com/leonhfr/orca/search"
+)
+
+func main() {
+	output := flag.String("o", "search/evaluation_defaults.go", "output Go source file, or JSON file with the .json extension")
+	epochs := flag.Int("epochs", 1000, "number of passes of gradient descent over the dataset")
+	rate := flag.Float64("rate", 1, "learning rate, in centipawns")
+	k := flag.Float64("k", 0, "scaling constant of the sigmoid, 0 fits it to the dataset")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <dataset>...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*output, *epochs, *rate, *k, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run tunes the weights on the datasets and writes them to the output file.
+func run(output string, epochs int, rate, k float64, paths []string) error {
+	params := search.DefaultEvalParams()
+	x := newExtractor(&params)
+
+	var entries []entry
+	for _, path := range paths {
+		var err error
+		if entries, err = readDataset(path, x, entries); err != nil {
+			return err
+		}
+	}
+
+	if len(entries) == 0 {
+		return errNoPositions
+	}
+
+	t := newTuner(entries, x.weights)
+	if k == 0 {
+		k = t.fitK()
+	}
+	fmt.Printf("%d positions, %d weights, k = %.4f, error = %.6f\n", len(entries), len(x.weights), k, t.error(k))
+
+	start := time.Now()
+	for epoch := 1; epoch <= epochs; epoch++ {
+		t.step(k, rate)
+		if epoch%50 == 0 || epoch == epochs {
+			fmt.Printf("epoch %d, error = %.6f, %v\n", epoch, t.error(k), time.Since(start).Round(time.Second))
+		}
+	}
+
+	t.round()
+	if err := writeParams(output, params); err != nil {
+		return err
+	}
+
+	fmt.Printf("weights written to %s\n", output)
+	return nil
+}
+
+var errNoPositions = errors.New("no positions to tune on")
+
+// readDataset appends the positions of the dataset to the entries.
+//
+// Positions that the evaluation scores with specialized functions are skipped.
+func readDataset(path string, x *extractor, entries []entry) ([]entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return entries, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fen, result, err := parseLine(text)
+		if err != nil {
+			return entries, fmt.Errorf("%s: line %d: %w", path, line, err)
+		}
+
+		pos, err := chess.NewPosition(fen)
+		if err != nil {
+			return entries, fmt.Errorf("%s: line %d: %w", path, line, err)
+		}
+
+		if e, ok := x.extract(pos, result); ok {
+			entries = append(entries, e)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return entries, nil
+}
+
+var errMissingResult = errors.New("missing result")
+
+// parseLine parses a line of the dataset into a FEN and the result of the game.
+//
+// The position may omit the half move clock and full move number.
+func parseLine(line string) (string, float64, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 5 {
+		return "", 0, errMissingResult
+	}
+
+	fen := strings.Join(fields[:4], " ")
+	rest := fields[4:]
+	if len(rest) > 2 && isNumber(rest[0]) && isNumber(rest[1]) {
+		fen += " " + rest[0] + " " + rest[1]
+		rest = rest[2:]
+	} else {
+		fen += " 0 1"
+	}
+
+	for _, field := range rest {
+		if result, ok := parseResult(field); ok {
+			return fen, result, nil
+		}
+	}
+
+	return "", 0, errMissingResult
+}
+
+// parseResult parses a result from White's point of view.
+func parseResult(field string) (float64, bool) {
+	switch field = strings.Trim(field, `"[];`); field {
+	case "1-0":
+		return 1, true
+	case "0-1":
+		return 0, true
+	case "1/2-1/2":
+		return 0.5, true
+	}
+
+	if !strings.Contains(field, ".") {
+		return 0, false
+	}
+
+	result, err := strconv.ParseFloat(field, 64)
+	if err != nil || result < 0 || result > 1 {
+		return 0, false
+	}
+
+	return result, true
+}
+
+// isNumber returns whether the field is a non-negative integer.
+func isNumber(field string) bool {
+	_, err := strconv.ParseUint(field, 10, 32)
+	return err == nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLine(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		line   string
+		fen    string
+		result float64
+		err    error
+	}{
+		{
+			name:   "epd",
+			line:   `rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - c9 "1/2-1/2";`,
+			fen:    "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1",
+			result: 0.5,
+		},
+		{
+			name:   "fen with clocks",
+			line:   "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3 [1.0]",
+			fen:    "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3",
+			result: 1,
+		},
+		{
+			name:   "fen without clocks",
+			line:   "8/8/4k3/8/8/4K3/4P3/8 w - - 0-1",
+			fen:    "8/8/4k3/8/8/4K3/4P3/8 w - - 0 1",
+			result: 0,
+		},
+		{
+			name: "missing result",
+			line: "8/8/4k3/8/8/4K3/4P3/8 w - - 0 1",
+			err:  errMissingResult,
+		},
+		{
+			name: "short line",
+			line: "8/8/4k3/8/8/4K3/4P3/8 w -",
+			err:  errMissingResult,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fen, result, err := parseLine(tt.line)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.fen, fen)
+			assert.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func TestParseResult(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		field  string
+		result float64
+		ok     bool
+	}{
+		{"1-0", 1, true},
+		{"0-1", 0, true},
+		{"1/2-1/2", 0.5, true},
+		{`"1-0";`, 1, true},
+		{"[0.5]", 0.5, true},
+		{"0.25", 0.25, true},
+		{"1", 0, false},
+		{"2.0", 0, false},
+		{"-0.5", 0, false},
+		{"c9", 0, false},
+		{"*", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			t.Parallel()
+			result, ok := parseResult(tt.field)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.result, result)
+		})
+	}
+}
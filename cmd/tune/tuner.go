@@ -0,0 +1,147 @@
+package main
+
+import (
+	"math"
+	"runtime"
+	"sync"
+)
+
+// Parameters of the Adam optimiser.
+const (
+	adamBeta1   = 0.9
+	adamBeta2   = 0.999
+	adamEpsilon = 1e-8
+)
+
+// tuner optimises the weights with the Adam variant of gradient descent.
+type tuner struct {
+	entries []entry
+	targets []*int32  // weights written back when rounding
+	weights []float64 // weights being optimised
+	m, v    []float64 // moments of the gradient
+	steps   int
+	workers int
+}
+
+// newTuner returns a tuner of the weights, starting from their current values.
+func newTuner(entries []entry, targets []*int32) *tuner {
+	weights := make([]float64, len(targets))
+	for i, w := range targets {
+		weights[i] = float64(*w)
+	}
+
+	return &tuner{
+		entries: entries,
+		targets: targets,
+		weights: weights,
+		m:       make([]float64, len(weights)),
+		v:       make([]float64, len(weights)),
+		workers: runtime.NumCPU(),
+	}
+}
+
+// evaluate returns the evaluation of the entry with the current weights.
+func (t *tuner) evaluate(e *entry) float64 {
+	var score float64
+	for _, c := range e.coefs {
+		score += float64(c.value) * t.weights[c.index]
+	}
+	return score
+}
+
+// sigmoid maps a score to the expected result of the game.
+func sigmoid(k, score float64) float64 {
+	return 1 / (1 + math.Pow(10, -k*score/400))
+}
+
+// error returns the mean squared error of the dataset.
+func (t *tuner) error(k float64) float64 {
+	sums := make([]float64, t.workers)
+	t.parallel(func(worker int, e *entry) {
+		d := e.result - sigmoid(k, t.evaluate(e))
+		sums[worker] += d * d
+	})
+
+	var sum float64
+	for _, s := range sums {
+		sum += s
+	}
+	return sum / float64(len(t.entries))
+}
+
+// fitK returns the scaling constant of the sigmoid minimising the error
+// with the current weights, by ternary search.
+func (t *tuner) fitK() float64 {
+	lo, hi := 0.0, 10.0
+	for range 50 {
+		a, b := lo+(hi-lo)/3, hi-(hi-lo)/3
+		if t.error(a) < t.error(b) {
+			hi = b
+		} else {
+			lo = a
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// step performs an epoch of gradient descent over the whole dataset.
+func (t *tuner) step(k, rate float64) {
+	gradients := make([][]float64, t.workers)
+	for i := range gradients {
+		gradients[i] = make([]float64, len(t.weights))
+	}
+
+	t.parallel(func(worker int, e *entry) {
+		s := sigmoid(k, t.evaluate(e))
+		// derivative of the squared error, without the constant factors applied below
+		d := (s - e.result) * s * (1 - s)
+		g := gradients[worker]
+		for _, c := range e.coefs {
+			g[c.index] += d * float64(c.value)
+		}
+	})
+
+	t.steps++
+	scale := 2 * k * math.Ln10 / 400 / float64(len(t.entries))
+	for i := range t.weights {
+		var g float64
+		for _, gradient := range gradients {
+			g += gradient[i]
+		}
+		g *= scale
+
+		t.m[i] = adamBeta1*t.m[i] + (1-adamBeta1)*g
+		t.v[i] = adamBeta2*t.v[i] + (1-adamBeta2)*g*g
+		m := t.m[i] / (1 - math.Pow(adamBeta1, float64(t.steps)))
+		v := t.v[i] / (1 - math.Pow(adamBeta2, float64(t.steps)))
+		t.weights[i] -= rate * m / (math.Sqrt(v) + adamEpsilon)
+	}
+}
+
+// round writes the weights back, rounded to the nearest integer.
+func (t *tuner) round() {
+	for i, w := range t.targets {
+		*w = int32(math.Round(t.weights[i]))
+	}
+}
+
+// parallel calls the function on every entry, from several workers.
+func (t *tuner) parallel(fn func(worker int, e *entry)) {
+	var wg sync.WaitGroup
+	chunk := (len(t.entries) + t.workers - 1) / t.workers
+	for worker := range t.workers {
+		start, end := worker*chunk, min((worker+1)*chunk, len(t.entries))
+		if start >= end {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				fn(worker, &t.entries[i])
+			}
+		}()
+	}
+	wg.Wait()
+}
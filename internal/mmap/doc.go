@@ -0,0 +1,4 @@
+// Package mmap maps files in memory read-only.
+//
+// On platforms without memory mapping, files are read in memory instead.
+package mmap
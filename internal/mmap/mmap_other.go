@@ -0,0 +1,20 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package mmap
+
+import (
+	"io"
+	"os"
+)
+
+// Map reads the file in memory on platforms without memory mapping.
+//
+// Returns the data and a no-op release function.
+func Map(f *os.File) ([]byte, func() error, error) {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, func() error { return nil }, nil
+}
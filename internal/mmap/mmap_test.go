@@ -0,0 +1,39 @@
+package mmap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMap(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"data", []byte("orca")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "file")
+			require.NoError(t, os.WriteFile(path, tt.data, 0o600))
+
+			f, err := os.Open(path)
+			require.NoError(t, err)
+			defer f.Close()
+
+			data, release, err := Map(f)
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.data), len(data))
+			assert.Equal(t, string(tt.data), string(data))
+			assert.NoError(t, release())
+		})
+	}
+}
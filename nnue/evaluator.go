@@ -0,0 +1,256 @@
+package nnue
+
+import "github.com/leonhfr/orca/chess"
+
+// accumulator holds the outputs of the feature transformer
+// of a position for both perspectives.
+type accumulator struct {
+	values   [2][accumulatorSize]int16
+	psqt     [2][PSQTBuckets]int32
+	dirty    chess.DirtyPieces // pieces moved by the move leading to the position
+	computed [2]bool
+}
+
+// Evaluator evaluates the positions reached from a root position
+// with a network, updating the accumulators incrementally.
+//
+// The evaluator implements chess.MoveHook: the accumulators follow the moves
+// made and unmade on the position it is set on. They are computed lazily,
+// from the last computed accumulator when the king of the perspective stays
+// in its bucket, from scratch otherwise.
+type Evaluator struct {
+	net   *Network
+	stack []accumulator
+	ply   int
+}
+
+// NewEvaluator returns an evaluator using the network.
+func NewEvaluator(net *Network) *Evaluator {
+	return &Evaluator{
+		net:   net,
+		stack: make([]accumulator, 1, 128),
+	}
+}
+
+// Reset computes the accumulators of the root position.
+func (ev *Evaluator) Reset(pos *chess.Position) {
+	ev.ply = 0
+	ev.refresh(pos, chess.Black)
+	ev.refresh(pos, chess.White)
+}
+
+// MakeMove implements the chess.MoveHook interface.
+func (ev *Evaluator) MakeMove(dirty chess.DirtyPieces) {
+	ev.ply++
+	if ev.ply == len(ev.stack) {
+		ev.stack = append(ev.stack, accumulator{})
+	}
+
+	a := &ev.stack[ev.ply]
+	a.dirty = dirty
+	a.computed = [2]bool{}
+}
+
+// UnmakeMove implements the chess.MoveHook interface.
+func (ev *Evaluator) UnmakeMove() {
+	ev.ply--
+}
+
+// Evaluate returns the score of the position in centipawns,
+// from the point of view of the side to move.
+//
+// The position must be the one reached by the moves made since the last reset.
+func (ev *Evaluator) Evaluate(pos *chess.Position) int32 {
+	ev.update(pos, chess.Black)
+	ev.update(pos, chess.White)
+
+	a := &ev.stack[ev.ply]
+	us, them := pos.Turn(), pos.Turn().Other()
+	bucket := (pos.PieceCount() - 1) / 4
+
+	psqt := (a.psqt[us][bucket] - a.psqt[them][bucket]) / 2
+	return psqt + ev.net.propagate(&a.values[us], &a.values[them])/outputScale
+}
+
+// update computes the accumulator of the perspective for the current position.
+func (ev *Evaluator) update(pos *chess.Position, c chess.Color) {
+	i := ev.ply
+	for ; i > 0 && !ev.stack[i].computed[c]; i-- {
+		if changesKingBucket(ev.stack[i].dirty, c) {
+			ev.refresh(pos, c)
+			return
+		}
+	}
+
+	if !ev.stack[i].computed[c] {
+		ev.refresh(pos, c)
+		return
+	}
+
+	kb := kingBucket(orient(c, pos.KingSquare(c)))
+	for ; i < ev.ply; i++ {
+		ev.apply(&ev.stack[i], &ev.stack[i+1], c, kb)
+	}
+}
+
+// refresh computes the accumulator of the perspective from scratch.
+func (ev *Evaluator) refresh(pos *chess.Position, c chess.Color) {
+	a := &ev.stack[ev.ply]
+	a.values[c] = ev.net.featureBiases
+	a.psqt[c] = [PSQTBuckets]int32{}
+
+	kb := kingBucket(orient(c, pos.KingSquare(c)))
+	pos.BoardMap(func(p chess.Piece, sq chess.Square) {
+		ev.net.addFeature(a, c, perspectiveFeature(c, kb, p, sq))
+	})
+	a.computed[c] = true
+}
+
+// apply computes the accumulator of the perspective from the previous one.
+func (ev *Evaluator) apply(prev, next *accumulator, c chess.Color, kb int) {
+	next.values[c] = prev.values[c]
+	next.psqt[c] = prev.psqt[c]
+
+	for _, dp := range next.dirty.Pieces[:next.dirty.Len] {
+		if dp.From != chess.NoSquare {
+			ev.net.subFeature(next, c, perspectiveFeature(c, kb, dp.Piece, dp.From))
+		}
+		if dp.To != chess.NoSquare {
+			ev.net.addFeature(next, c, perspectiveFeature(c, kb, dp.Piece, dp.To))
+		}
+	}
+	next.computed[c] = true
+}
+
+// addFeature adds the weights of the feature to the accumulator of the perspective.
+func (n *Network) addFeature(a *accumulator, c chess.Color, feature int) {
+	values := &a.values[c]
+	weights := n.featureWeights[feature*accumulatorSize : (feature+1)*accumulatorSize]
+	for i := range values {
+		values[i] += weights[i]
+	}
+
+	psqt := &a.psqt[c]
+	psqtWeights := n.psqtWeights[feature*PSQTBuckets : (feature+1)*PSQTBuckets]
+	for i := range psqt {
+		psqt[i] += psqtWeights[i]
+	}
+}
+
+// subFeature subtracts the weights of the feature from the accumulator of the perspective.
+func (n *Network) subFeature(a *accumulator, c chess.Color, feature int) {
+	values := &a.values[c]
+	weights := n.featureWeights[feature*accumulatorSize : (feature+1)*accumulatorSize]
+	for i := range values {
+		values[i] -= weights[i]
+	}
+
+	psqt := &a.psqt[c]
+	psqtWeights := n.psqtWeights[feature*PSQTBuckets : (feature+1)*PSQTBuckets]
+	for i := range psqt {
+		psqt[i] -= psqtWeights[i]
+	}
+}
+
+// propagate returns the output of the hidden layers, before scaling.
+func (n *Network) propagate(us, them *[accumulatorSize]int16) int32 {
+	var input [2 * accumulatorSize]uint8
+	for i, v := range us {
+		input[i] = uint8(clamp(int32(v)))
+	}
+	for i, v := range them {
+		input[accumulatorSize+i] = uint8(clamp(int32(v)))
+	}
+
+	hidden1 := n.hidden1Biases
+	affine(hidden1[:], input[:], n.hidden1Weights)
+	var activated1 [hidden1Size]uint8
+	activate(activated1[:], hidden1[:])
+
+	hidden2 := n.hidden2Biases
+	affine(hidden2[:], activated1[:], n.hidden2Weights)
+	var activated2 [hidden2Size]uint8
+	activate(activated2[:], hidden2[:])
+
+	output := [1]int32{n.outputBias}
+	affine(output[:], activated2[:], n.outputWeights)
+	return output[0]
+}
+
+// affine adds the weighted inputs to the outputs.
+//
+// Inactive inputs are skipped, most of them being zero.
+func affine(outputs []int32, inputs []uint8, weights []int8) {
+	size := len(outputs)
+	for i, x := range inputs {
+		if x == 0 {
+			continue
+		}
+
+		row := weights[i*size : (i+1)*size]
+		for j := range outputs {
+			outputs[j] += int32(x) * int32(row[j])
+		}
+	}
+}
+
+// activate scales down and clips the outputs of a hidden layer.
+func activate(activated []uint8, outputs []int32) {
+	for i, v := range outputs {
+		activated[i] = uint8(clamp(v >> weightShift))
+	}
+}
+
+// clamp clips the value to the range of the activations.
+func clamp(v int32) int32 {
+	return min(max(v, 0), activationMax)
+}
+
+// changesKingBucket returns whether the move changes the king bucket of the perspective.
+func changesKingBucket(dirty chess.DirtyPieces, c chess.Color) bool {
+	for _, dp := range dirty.Pieces[:dirty.Len] {
+		if dp.Piece.Type() == chess.King && dp.Piece.Color() == c &&
+			kingBucket(orient(c, dp.From)) != kingBucket(orient(c, dp.To)) {
+			return true
+		}
+	}
+	return false
+}
+
+// perspectiveFeature returns the index of the feature of a piece
+// relative to the perspective.
+func perspectiveFeature(c chess.Color, kb int, p chess.Piece, sq chess.Square) int {
+	kind := int(p.Type())
+	if p.Color() != c {
+		kind += 6
+	}
+	return featureIndex(kb, kind, orient(c, sq))
+}
+
+// featureIndex returns the index of the feature of a piece kind
+// on an oriented square in a king bucket.
+func featureIndex(kb, kind int, sq chess.Square) int {
+	return (kb*pieceKinds+kind)*64 + int(sq)
+}
+
+// orient returns the square as seen from the perspective,
+// the board being flipped vertically for Black.
+func orient(c chess.Color, sq chess.Square) chess.Square {
+	if c == chess.Black {
+		return sq ^ 56
+	}
+	return sq
+}
+
+// kingBucket returns the bucket of an oriented king square: on the queen side
+// or on the king side, on the first rank or further up the board.
+func kingBucket(sq chess.Square) int {
+	var kb int
+	if sq.File() >= chess.FileE {
+		kb++
+	}
+	if sq.Rank() > chess.Rank1 {
+		kb += 2
+	}
+	return kb
+}
@@ -0,0 +1,100 @@
+package nnue
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+func TestEvaluator_Incremental(t *testing.T) {
+	t.Parallel()
+	n := randomNetwork(2)
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1",
+	}
+
+	for i, fen := range fens {
+		t.Run(fen, func(t *testing.T) {
+			t.Parallel()
+			r := rand.New(rand.NewSource(int64(i)))
+			pos, err := chess.NewPosition(fen)
+			require.NoError(t, err)
+
+			ev := NewEvaluator(n)
+			ev.Reset(pos)
+			pos.SetMoveHook(ev)
+
+			for range 20 {
+				playout(t, r, pos, ev, 12)
+			}
+		})
+	}
+}
+
+// playout plays random moves, checking at each position that the incremental
+// evaluation matches the evaluation from scratch, then unmakes them.
+func playout(t *testing.T, r *rand.Rand, pos *chess.Position, ev *Evaluator, depth int) {
+	t.Helper()
+	fresh := NewEvaluator(ev.net)
+	fresh.Reset(pos)
+	require.Equal(t, fresh.Evaluate(pos), ev.Evaluate(pos), pos.String())
+
+	if depth == 0 {
+		return
+	}
+
+	var ml chess.MoveList
+	checkData, _ := pos.InCheck()
+	moves := pos.AppendLegalMoves(ml[:0], checkData)
+	if len(moves) == 0 {
+		return
+	}
+
+	move := moves[r.Intn(len(moves))]
+	meta, hash, pawnHash := pos.Metadata(), pos.Hash(), pos.PawnHash()
+	require.True(t, pos.MakeMove(move))
+	// skips the evaluation of some positions to exercise the lazy updates
+	if r.Intn(3) > 0 {
+		playout(t, r, pos, ev, depth-1)
+	}
+	pos.UnmakeMove(move, meta, hash, pawnHash)
+}
+
+func TestKingBucket(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		sq   chess.Square
+		want int
+	}{
+		{chess.A1, 0},
+		{chess.D1, 0},
+		{chess.E1, 1},
+		{chess.H1, 1},
+		{chess.C2, 2},
+		{chess.G8, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sq.String(), func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, kingBucket(tt.sq))
+		})
+	}
+}
+
+func BenchmarkEvaluator_Evaluate(b *testing.B) {
+	n := randomNetwork(3)
+	pos, _ := chess.NewPosition("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	ev := NewEvaluator(n)
+	ev.Reset(pos)
+	for n := 0; n < b.N; n++ {
+		_ = ev.Evaluate(pos)
+	}
+}
@@ -0,0 +1,216 @@
+// Package nnue provides an efficiently updatable neural network evaluation.
+//
+// The network evaluates a position from the point of view of the side to move.
+// Its inputs are HalfKA features: the pieces of both colors, kings included,
+// each relative to a bucket of the square of the king of the perspective.
+// The feature transformer sums the weights of the active features into an
+// accumulator per perspective, which is updated incrementally as moves are
+// made and unmade. The accumulators of the side to move and of the other side
+// go through two hidden layers to the output, to which the piece-square term
+// of the bucket selected by the number of pieces on the board is added.
+//
+// Networks are quantized: the feature transformer uses 16-bit weights,
+// the other layers 8-bit weights and 32-bit biases, with clipped ReLU
+// activations in [0, 127].
+//
+// The network file holds, in little-endian order:
+//
+//	magic              "ORCANNUE"
+//	version            uint32
+//	architecture       uint32 x 5: features, accumulator, hidden 1, hidden 2, PSQT buckets
+//	feature biases     int16 [accumulator]
+//	feature weights    int16 [features][accumulator]
+//	PSQT weights       int32 [features][PSQT buckets]
+//	hidden 1 biases    int32 [hidden 1]
+//	hidden 1 weights   int8  [2 * accumulator][hidden 1]
+//	hidden 2 biases    int32 [hidden 2]
+//	hidden 2 weights   int8  [hidden 1][hidden 2]
+//	output bias        int32
+//	output weights     int8  [hidden 2]
+//
+// Weights are ordered by input then by output. Files may be compressed with gzip.
+package nnue
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+const (
+	// kingBuckets is the number of buckets of the king square.
+	kingBuckets = 4
+	// pieceKinds is the number of kinds of pieces, by type and relative color.
+	pieceKinds = 12
+	// featureCount is the number of input features.
+	featureCount = kingBuckets * pieceKinds * 64
+	// accumulatorSize is the number of neurons of the feature transformer per perspective.
+	accumulatorSize = 256
+	// hidden1Size is the number of neurons of the first hidden layer.
+	hidden1Size = 32
+	// hidden2Size is the number of neurons of the second hidden layer.
+	hidden2Size = 32
+	// PSQTBuckets is the number of buckets of the piece-square term,
+	// selected by the number of pieces on the board.
+	PSQTBuckets = 8
+	// activationMax is the upper bound of the clipped ReLU activations.
+	activationMax = 127
+	// weightShift scales down the outputs of the hidden layers.
+	weightShift = 6
+	// outputScale scales down the output of the network to centipawns.
+	outputScale = 16
+	// version is the version of the network file format.
+	version = 1
+)
+
+// magic starts the network files.
+var magic = [8]byte{'O', 'R', 'C', 'A', 'N', 'N', 'U', 'E'}
+
+var (
+	errMagic        = errors.New("not a network file")
+	errVersion      = errors.New("unsupported network file version")
+	errArchitecture = errors.New("network architecture mismatch")
+	errTrailingData = errors.New("trailing data after the network")
+)
+
+// Network holds the quantized weights of a network.
+//
+// A network is read-only once loaded and safe for concurrent use.
+type Network struct {
+	featureBiases  [accumulatorSize]int16
+	featureWeights []int16 // by feature then neuron
+	psqtWeights    []int32 // by feature then bucket
+	hidden1Biases  [hidden1Size]int32
+	hidden1Weights []int8 // by input then neuron
+	hidden2Biases  [hidden2Size]int32
+	hidden2Weights []int8 // by input then neuron
+	outputBias     int32
+	outputWeights  []int8
+}
+
+// NewNetwork returns a network whose weights are all zero.
+func NewNetwork() *Network {
+	return &Network{
+		featureWeights: make([]int16, featureCount*accumulatorSize),
+		psqtWeights:    make([]int32, featureCount*PSQTBuckets),
+		hidden1Weights: make([]int8, 2*accumulatorSize*hidden1Size),
+		hidden2Weights: make([]int8, hidden1Size*hidden2Size),
+		outputWeights:  make([]int8, hidden2Size),
+	}
+}
+
+// SetPieceSquare sets the piece-square weight of a piece in a bucket.
+//
+// The piece is of the color of the perspective, on the square as seen
+// from White's point of view. The weight applies to all the king buckets,
+// and is negated for the pieces of the other color.
+func (n *Network) SetPieceSquare(pt chess.PieceType, sq chess.Square, bucket int, value int32) {
+	for kb := range kingBuckets {
+		own := featureIndex(kb, int(pt), sq)
+		other := featureIndex(kb, int(pt)+6, sq^56)
+		n.psqtWeights[own*PSQTBuckets+bucket] = value
+		n.psqtWeights[other*PSQTBuckets+bucket] = -value
+	}
+}
+
+// Load reads a network from a file.
+func Load(path string) (*Network, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Read reads a network, compressed with gzip or not.
+func Read(r io.Reader) (*Network, error) {
+	br := bufio.NewReader(r)
+	if header, err := br.Peek(2); err == nil && header[0] == 0x1f && header[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		br = bufio.NewReader(zr)
+	}
+
+	var m [8]byte
+	if err := read(br, &m); err != nil {
+		return nil, err
+	}
+	if m != magic {
+		return nil, errMagic
+	}
+
+	var header [6]uint32
+	if err := read(br, &header); err != nil {
+		return nil, err
+	}
+	if header[0] != version {
+		return nil, errVersion
+	}
+	if header != architecture() {
+		return nil, errArchitecture
+	}
+
+	n := NewNetwork()
+	for _, data := range n.sections() {
+		if err := read(br, data); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := br.ReadByte(); err != io.EOF {
+		return nil, errTrailingData
+	}
+
+	return n, nil
+}
+
+// Write writes the network, uncompressed.
+func (n *Network) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if err := binary.Write(bw, binary.LittleEndian, magic); err != nil {
+		return err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, architecture()); err != nil {
+		return err
+	}
+	for _, data := range n.sections() {
+		if err := binary.Write(bw, binary.LittleEndian, data); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// architecture returns the version and architecture header of the network files.
+func architecture() [6]uint32 {
+	return [6]uint32{version, featureCount, accumulatorSize, hidden1Size, hidden2Size, PSQTBuckets}
+}
+
+// sections returns the weights of the network in file order.
+func (n *Network) sections() []any {
+	return []any{
+		&n.featureBiases, n.featureWeights, n.psqtWeights,
+		&n.hidden1Biases, n.hidden1Weights,
+		&n.hidden2Biases, n.hidden2Weights,
+		&n.outputBias, n.outputWeights,
+	}
+}
+
+// read reads little-endian data, a partial read being an unexpected end of file.
+func read(r io.Reader, data any) error {
+	err := binary.Read(r, binary.LittleEndian, data)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,149 @@
+package nnue
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+// randomNetwork returns a network with random weights, small enough
+// for the activations to vary between the bounds of the clipped ReLU.
+func randomNetwork(seed int64) *Network {
+	r := rand.New(rand.NewSource(seed))
+	n := NewNetwork()
+	for i := range n.featureBiases {
+		n.featureBiases[i] = int16(r.Intn(64))
+	}
+	for i := range n.featureWeights {
+		n.featureWeights[i] = int16(r.Intn(41) - 20)
+	}
+	for i := range n.psqtWeights {
+		n.psqtWeights[i] = int32(r.Intn(2001) - 1000)
+	}
+	for i := range n.hidden1Biases {
+		n.hidden1Biases[i] = int32(r.Intn(2001) - 1000)
+	}
+	for i := range n.hidden1Weights {
+		n.hidden1Weights[i] = int8(r.Intn(21) - 10)
+	}
+	for i := range n.hidden2Biases {
+		n.hidden2Biases[i] = int32(r.Intn(2001) - 1000)
+	}
+	for i := range n.hidden2Weights {
+		n.hidden2Weights[i] = int8(r.Intn(81) - 40)
+	}
+	n.outputBias = int32(r.Intn(2001) - 1000)
+	for i := range n.outputWeights {
+		n.outputWeights[i] = int8(r.Intn(255) - 127)
+	}
+	return n
+}
+
+func TestNetwork_WriteRead(t *testing.T) {
+	t.Parallel()
+	want := randomNetwork(1)
+
+	var raw bytes.Buffer
+	require.NoError(t, want.Write(&raw))
+	got, err := Read(bytes.NewReader(raw.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, err = zw.Write(raw.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	got, err = Read(&compressed)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	path := filepath.Join(t.TempDir(), "network.nnue")
+	require.NoError(t, os.WriteFile(path, raw.Bytes(), 0o644))
+	got, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestRead_Errors(t *testing.T) {
+	t.Parallel()
+	var raw bytes.Buffer
+	require.NoError(t, NewNetwork().Write(&raw))
+	valid := raw.Bytes()
+
+	header := func(values ...uint32) []byte {
+		var b bytes.Buffer
+		b.Write(magic[:])
+		_ = binary.Write(&b, binary.LittleEndian, values)
+		return b.Bytes()
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, io.ErrUnexpectedEOF},
+		{"magic", []byte("NOTANNUEFILE"), errMagic},
+		{"version", header(2, featureCount, accumulatorSize, hidden1Size, hidden2Size, PSQTBuckets), errVersion},
+		{"architecture", header(version, featureCount, 512, hidden1Size, hidden2Size, PSQTBuckets), errArchitecture},
+		{"truncated", valid[:len(valid)-1], io.ErrUnexpectedEOF},
+		{"trailing data", append(bytes.Clone(valid), 0), errTrailingData},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			n, err := Read(bytes.NewReader(tt.data))
+			assert.Nil(t, n)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestNetwork_SetPieceSquare(t *testing.T) {
+	t.Parallel()
+	// the hidden layers without weights leave the piece-square term only
+	n := NewNetwork()
+	values := [...]int32{chess.Pawn: 100, chess.Knight: 300, chess.Bishop: 300, chess.Rook: 500, chess.Queen: 900}
+	for bucket := range PSQTBuckets {
+		for pt := chess.Pawn; pt <= chess.Queen; pt++ {
+			for sq := chess.A1; sq <= chess.H8; sq++ {
+				n.SetPieceSquare(pt, sq, bucket, values[pt])
+			}
+		}
+	}
+
+	tests := []struct {
+		fen  string
+		want int32
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 0},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR b KQkq - 0 1", 0},
+		{"rnb1kbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 900},
+		{"rnb1kbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR b KQkq - 0 1", -900},
+		{"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fen, func(t *testing.T) {
+			t.Parallel()
+			pos, err := chess.NewPosition(tt.fen)
+			require.NoError(t, err)
+			ev := NewEvaluator(n)
+			ev.Reset(pos)
+			score := ev.Evaluate(pos)
+			assert.Equal(t, tt.want, score)
+		})
+	}
+}
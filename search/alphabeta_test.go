@@ -50,12 +50,12 @@ func (si *searchInfo) alphaBeta(ctx context.Context, pos *chess.Position, alpha,
 	}
 
 	if depth == 0 {
-		return si.quiesce(ctx, pos, -beta, -alpha)
+		return si.quiesce(ctx, pos, -beta, -alpha, index)
 	}
 
 	if shouldNullMovePrune(pos, inCheck, depth) {
 		pos.MakeNullMove()
-		score, err := si.zeroWindow(ctx, pos, beta, depth-rNullMovePruning-1)
+		score, err := si.zeroWindow(ctx, pos, beta, depth-rNullMovePruning-1, index+1)
 		score = -score
 		pos.UnmakeNullMove(meta, hash)
 
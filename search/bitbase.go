@@ -0,0 +1,201 @@
+package search
+
+import "github.com/leonhfr/orca/chess"
+
+// kpkIndexes is the number of positions of the KPK bitbase:
+// side to move, pawn on the files A to D and ranks 2 to 7, white and black kings.
+const kpkIndexes = 2 * 24 * 64 * 64
+
+// kpkBitbase holds whether the KPK positions with the pawn on the files
+// A to D are won by the side with the pawn, as white.
+var kpkBitbase [kpkIndexes / 32]uint32
+
+// kpkResult represents the result of a KPK position during the retrograde analysis.
+type kpkResult uint8
+
+const (
+	kpkInvalid kpkResult = 0 // kpkInvalid is an illegal position.
+	kpkUnknown kpkResult = 1 // kpkUnknown is a position not classified yet.
+	kpkDraw    kpkResult = 2 // kpkDraw is a draw.
+	kpkWin     kpkResult = 4 // kpkWin is a win for white.
+)
+
+// kpkIndex returns the index of a KPK position in the bitbase.
+//
+// Layout of the index:
+//
+//	bits 0-5    white king square
+//	bits 6-11   black king square
+//	bit 12      side to move, set for black
+//	bits 13-14  file of the pawn, A to D
+//	bits 15-17  rank 7 minus the rank of the pawn
+func kpkIndex(stm chess.Color, wk, bk, p chess.Square) int {
+	var turn int
+	if stm == chess.Black {
+		turn = 1
+	}
+	return int(wk) | int(bk)<<6 | turn<<12 | int(p.File())<<13 | int(chess.Rank7-p.Rank())<<15
+}
+
+// probeKPK returns whether the KPK position is won by white.
+//
+// Expects the pawn to be white and on the files A to D.
+func probeKPK(stm chess.Color, wk, bk, p chess.Square) bool {
+	idx := kpkIndex(stm, wk, bk, p)
+	return kpkBitbase[idx/32]&(1<<(idx%32)) != 0
+}
+
+// kpkPosition holds a KPK position during the retrograde analysis.
+type kpkPosition struct {
+	stm    chess.Color
+	wk, bk chess.Square
+	p      chess.Square
+	result kpkResult
+}
+
+// initKPK generates the KPK bitbase by retrograde analysis.
+func initKPK() {
+	db := make([]kpkPosition, kpkIndexes)
+	for idx := range db {
+		db[idx] = newKPKPosition(idx)
+	}
+
+	for repeat := true; repeat; {
+		repeat = false
+		for idx := range db {
+			if db[idx].result == kpkUnknown && db[idx].classify(db) != kpkUnknown {
+				repeat = true
+			}
+		}
+	}
+
+	for idx := range db {
+		if db[idx].result == kpkWin {
+			kpkBitbase[idx/32] |= 1 << (idx % 32)
+		}
+	}
+}
+
+// newKPKPosition decodes the position and classifies the immediate results.
+func newKPKPosition(idx int) kpkPosition {
+	pos := kpkPosition{
+		wk: chess.Square(idx & 63),
+		bk: chess.Square(idx >> 6 & 63),
+		p:  square(chess.File(idx>>13&3), chess.Rank7-chess.Rank(idx>>15&7)),
+	}
+	if idx>>12&1 == 0 {
+		pos.stm = chess.White
+	}
+	push := pos.p + 8
+
+	switch {
+	case squareDistance(pos.wk, pos.bk) <= 1 || pos.wk == pos.p || pos.bk == pos.p ||
+		(pos.stm == chess.White && pawnAttacks(pos.p, pos.bk)):
+		// a king can be captured
+		pos.result = kpkInvalid
+	case pos.stm == chess.White && pos.p.Rank() == chess.Rank7 && pos.wk != push &&
+		(squareDistance(pos.bk, push) > 1 || squareDistance(pos.wk, push) == 1):
+		// the pawn promotes without being captured
+		pos.result = kpkWin
+	case pos.stm == chess.Black && (pos.stalemate() ||
+		(squareDistance(pos.bk, pos.p) == 1 && squareDistance(pos.wk, pos.p) > 1)):
+		// stalemate or the black king captures the pawn
+		pos.result = kpkDraw
+	default:
+		pos.result = kpkUnknown
+	}
+
+	return pos
+}
+
+// stalemate returns whether the black king has no move.
+func (pos kpkPosition) stalemate() bool {
+	for _, sq := range kingMoves[pos.bk] {
+		if squareDistance(sq, pos.wk) > 1 && !pawnAttacks(pos.p, sq) {
+			return false
+		}
+	}
+	return true
+}
+
+// classify classifies the position from the results of its children.
+//
+// White wins if a move wins and draws if all moves draw. Black draws
+// if a move draws and loses if all moves lose. Otherwise the position
+// remains unknown.
+func (pos *kpkPosition) classify(db []kpkPosition) kpkResult {
+	good, bad := kpkWin, kpkDraw
+	if pos.stm == chess.Black {
+		good, bad = kpkDraw, kpkWin
+	}
+
+	var r kpkResult
+	if pos.stm == chess.White {
+		for _, sq := range kingMoves[pos.wk] {
+			r |= db[kpkIndex(chess.Black, sq, pos.bk, pos.p)].result
+		}
+
+		push := pos.p + 8
+		if pos.p.Rank() < chess.Rank7 {
+			r |= db[kpkIndex(chess.Black, pos.wk, pos.bk, push)].result
+		}
+		if pos.p.Rank() == chess.Rank2 && push != pos.wk && push != pos.bk {
+			r |= db[kpkIndex(chess.Black, pos.wk, pos.bk, push+8)].result
+		}
+	} else {
+		for _, sq := range kingMoves[pos.bk] {
+			r |= db[kpkIndex(chess.White, pos.wk, sq, pos.p)].result
+		}
+	}
+
+	switch {
+	case r&good != 0:
+		pos.result = good
+	case r&kpkUnknown != 0:
+		pos.result = kpkUnknown
+	default:
+		pos.result = bad
+	}
+
+	return pos.result
+}
+
+// kingMoves holds the destination squares of a king, indexed by square.
+var kingMoves [64][]chess.Square
+
+// initKingMoves initializes kingMoves.
+func initKingMoves() {
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		for to := chess.A1; to <= chess.H8; to++ {
+			if squareDistance(sq, to) == 1 {
+				kingMoves[sq] = append(kingMoves[sq], to)
+			}
+		}
+	}
+}
+
+// pawnAttacks returns whether the white pawn attacks the square.
+func pawnAttacks(p, sq chess.Square) bool {
+	return sq.Rank() == p.Rank()+1 && absInt(int(sq.File())-int(p.File())) == 1
+}
+
+// square returns the square of the file and rank.
+func square(f chess.File, r chess.Rank) chess.Square {
+	return chess.Square(int(r)*8 + int(f))
+}
+
+// squareDistance returns the king distance between two squares.
+func squareDistance(a, b chess.Square) int {
+	return max(
+		absInt(int(a.File())-int(b.File())),
+		absInt(int(a.Rank())-int(b.Rank())),
+	)
+}
+
+// absInt returns the absolute value of the integer.
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
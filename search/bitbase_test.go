@@ -0,0 +1,95 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonhfr/orca/chess"
+	"github.com/leonhfr/orca/syzygy"
+)
+
+func TestProbeKPK(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		fen  string
+		want int32
+	}{
+		{"4k3/8/4K3/4P3/8/8/8/8 w - - 0 1", knownWin + 94 + 4},
+		{"4k3/8/4K3/4P3/8/8/8/8 b - - 0 1", -(knownWin + 94 + 4)},
+		{"8/8/8/4k3/8/8/4P3/4K3 w - - 0 1", draw},
+		{"8/1k6/8/8/8/8/P7/K7 w - - 0 1", draw},
+		{"8/8/8/8/4p3/4k3/8/4K3 w - - 0 1", -(knownWin + 94 + 4)},
+		{"8/8/8/8/4p3/4k3/8/4K3 b - - 0 1", knownWin + 94 + 4},
+		{"8/8/8/8/8/5k2/7p/7K w - - 0 1", draw},
+		{"8/8/8/8/8/2k5/1p6/3K4 b - - 0 1", knownWin + 94 + 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fen, func(t *testing.T) {
+			t.Parallel()
+			si := newSearchInfo(noTable{}, noPawnTable{})
+			assert.Equal(t, tt.want, si.evaluate(unsafeFEN(tt.fen)))
+		})
+	}
+}
+
+func TestKPKTablebase(t *testing.T) {
+	t.Parallel()
+	tb, err := syzygy.Open(syzygyDir)
+	require.NoError(t, err)
+	defer tb.Close()
+
+	var mismatches int
+	for idx := range kpkIndexes {
+		pos := newKPKPosition(idx)
+		if pos.result == kpkInvalid {
+			continue
+		}
+
+		board := make([]byte, 64)
+		board[pos.wk], board[pos.bk], board[pos.p] = 'K', 'k', 'P'
+		p, err := chess.NewPosition(boardFEN(board, pos.stm))
+		require.NoError(t, err)
+
+		wdl, ok := tb.ProbeWDL(p)
+		require.True(t, ok)
+
+		won := wdl == syzygy.Win
+		if pos.stm == chess.Black {
+			won = wdl == syzygy.Loss
+		}
+		if won != probeKPK(pos.stm, pos.wk, pos.bk, pos.p) {
+			mismatches++
+		}
+	}
+
+	assert.Zero(t, mismatches)
+}
+
+// boardFEN returns the FEN of the board, with the pieces by square.
+func boardFEN(board []byte, stm chess.Color) string {
+	var fen []byte
+	for r := 7; r >= 0; r-- {
+		empty := byte(0)
+		for f := range 8 {
+			if c := board[r*8+f]; c != 0 {
+				if empty > 0 {
+					fen = append(fen, '0'+empty)
+					empty = 0
+				}
+				fen = append(fen, c)
+			} else {
+				empty++
+			}
+		}
+		if empty > 0 {
+			fen = append(fen, '0'+empty)
+		}
+		if r > 0 {
+			fen = append(fen, '/')
+		}
+	}
+	return string(fen) + " " + stm.String() + " - - 0 1"
+}
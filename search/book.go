@@ -0,0 +1,391 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+
+	"github.com/leonhfr/orca/chess"
+	"github.com/leonhfr/orca/data/books"
+)
+
+// bookVerifyMargin is the score in centipawns by which a book move may fall
+// short of the best move of the verification search before being skipped.
+const bookVerifyMargin = 200
+
+// BookPolicy represents the selection policy of the book moves.
+type BookPolicy uint8
+
+const (
+	BookBest     BookPolicy = iota // BookBest selects the move with the highest weight.
+	BookWeighted                   // BookWeighted selects a random move weighted by the book weights and the temperature.
+	BookUniform                    // BookUniform selects a uniformly random move among the moves above the weight threshold.
+)
+
+// bookOptions holds the opening book options.
+type bookOptions struct {
+	policy      BookPolicy
+	temperature int // temperature in percent of the weighted policy
+	threshold   int // threshold in percent of the best weight of the uniform policy
+	depth       int // last full move at which the book is used
+	variety     int // number of best weighted moves considered, 0 considers all
+	verify      int // depth of the verification search, 0 disables it
+}
+
+// defaultBookOptions returns the default opening book options.
+func defaultBookOptions() bookOptions {
+	return bookOptions{
+		policy:      BookWeighted,
+		temperature: 100,
+		threshold:   50,
+		depth:       255,
+	}
+}
+
+// candidates returns the book moves that may be selected by the policy.
+//
+// Expects the moves to be sorted by decreasing weight.
+// Moves with a zero weight are never selected.
+func (bo bookOptions) candidates(moves []chess.WeightedMove) []chess.WeightedMove {
+	n := 0
+	for n < len(moves) && moves[n].Weight > 0 {
+		n++
+	}
+
+	if bo.variety > 0 {
+		n = min(n, bo.variety)
+	}
+
+	if bo.policy == BookUniform && n > 0 {
+		threshold := moves[0].Weight * bo.threshold / 100
+		for n > 0 && moves[n-1].Weight < threshold {
+			n--
+		}
+	}
+
+	return moves[:n]
+}
+
+// pick returns the index of the candidate selected by the policy.
+//
+// Returns -1 if there are no candidates.
+func (bo bookOptions) pick(moves []chess.WeightedMove) int {
+	if len(moves) == 0 {
+		return -1
+	}
+
+	switch bo.policy {
+	case BookUniform:
+		return rand.Intn(len(moves)) //nolint:gosec
+	case BookWeighted:
+		return weightedRandomIndex(moves, bo.temperature)
+	default:
+		return 0
+	}
+}
+
+// weightedRandomIndex randomly selects a move with probabilities proportional
+// to the weights raised to the power of 100/temperature.
+//
+// Low temperatures favor the moves with the highest weights.
+func weightedRandomIndex(moves []chess.WeightedMove, temperature int) int {
+	exponent := 100 / float64(max(temperature, 1))
+
+	var sum float64
+	weights := make([]float64, len(moves))
+	for i, move := range moves {
+		weights[i] = math.Pow(float64(move.Weight), exponent)
+		sum += weights[i]
+	}
+
+	if sum <= 0 || math.IsInf(sum, 0) {
+		return 0
+	}
+
+	r := rand.Float64() * sum //nolint:gosec
+	for i, w := range weights {
+		if r < w {
+			return i
+		}
+		r -= w
+	}
+
+	return len(moves) - 1
+}
+
+// loadBooks loads the opening books from the Polyglot files in priority order.
+//
+// Files are memory-mapped and binary searched so that loading
+// does not depend on the book size.
+//
+// When writable, files are read in memory instead so that book learning
+// can update them.
+//
+// Falls back to the embedded book when no file could be loaded.
+// Files that could not be loaded are skipped and their errors joined.
+func loadBooks(paths []string, writable bool) ([]*chess.Book, error) {
+	var loaded []*chess.Book
+	var errs []error
+	for _, path := range paths {
+		book, err := loadBookFile(path, writable)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		loaded = append(loaded, book)
+	}
+
+	if len(loaded) == 0 {
+		book, err := chess.NewSortedBook(books.Performance)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("embedded book: %w", err))
+		} else {
+			loaded = append(loaded, book)
+		}
+	}
+
+	return loaded, errors.Join(errs...)
+}
+
+// loadBookFile loads an opening book from a Polyglot file.
+func loadBookFile(path string, writable bool) (*chess.Book, error) {
+	open := chess.OpenBook
+	if writable {
+		open = chess.OpenWritableBook
+	}
+
+	book, err := open(path)
+	if err != nil {
+		return nil, fmt.Errorf("book file %s: %w", path, err)
+	}
+
+	return book, nil
+}
+
+// closeBooks releases the opening books.
+//
+// Expects the book mutex to be held.
+func (e *Engine) closeBooks() {
+	for _, book := range e.books {
+		_ = book.Close()
+	}
+	e.books = nil
+	e.bookPlayed = nil
+}
+
+// bookMove returns a move from the first opening book that knows the position,
+// selected according to the book options.
+//
+// Returns chess.NoMove if no book knows the position, if the game is past
+// the book depth, or if all the candidates failed the verification search.
+func (e *Engine) bookMove(ctx context.Context, pos *chess.Position) chess.Move {
+	if int(pos.FullMoves()) > e.book.depth {
+		return chess.NoMove
+	}
+
+	book, moves := e.lookupBooks(pos)
+	moves = e.book.candidates(moves)
+	var verifier *bookVerifier
+	if e.book.verify > 0 {
+		verifier = newBookVerifier(e, pos)
+		defer pos.SetMoveHook(nil)
+	}
+
+	for i := e.book.pick(moves); i >= 0; i = e.book.pick(moves) {
+		if verifier == nil || verifier.verify(ctx, moves[i].Move) {
+			e.recordBookMove(book, pos, moves[i].Move)
+			return moves[i].Move
+		}
+		moves = slices.Delete(moves, i, i+1)
+	}
+
+	return chess.NoMove
+}
+
+// lookupBooks returns the first opening book that knows the position and its moves.
+func (e *Engine) lookupBooks(pos *chess.Position) (*chess.Book, []chess.WeightedMove) {
+	e.bookMu.Lock()
+	defer e.bookMu.Unlock()
+
+	for _, book := range e.books {
+		if moves := book.Lookup(pos); len(moves) > 0 {
+			return book, moves
+		}
+	}
+
+	return nil, nil
+}
+
+// bookPlay represents a book move played by the engine.
+type bookPlay struct {
+	book *chess.Book
+	pos  chess.Position
+	move chess.Move
+}
+
+// recordBookMove records the book move played in the position
+// so that the book learns from the result of the game.
+//
+// Moves of read-only books are not recorded.
+func (e *Engine) recordBookMove(book *chess.Book, pos *chess.Position, move chess.Move) {
+	e.bookMu.Lock()
+	defer e.bookMu.Unlock()
+
+	if e.bookLearning && book.Writable() {
+		e.bookPlayed = append(e.bookPlayed, bookPlay{book, *pos, move})
+	}
+}
+
+// LearnBook updates the opening books with the result of the game
+// from the engine's point of view, for each book move played since
+// the last call. The recorded book moves are then forgotten.
+//
+// Book learning must be enabled with WithBookLearning.
+func (e *Engine) LearnBook(result chess.LearnResult) error {
+	e.bookMu.Lock()
+	defer e.bookMu.Unlock()
+	return e.learnBook(func(bookPlay) chess.LearnResult { return result })
+}
+
+// FinishGameWithResult ends the current game with its result from White's point of view.
+//
+// The opening books learn the result of each book move played during the game
+// from the point of view of the side that played it, see LearnBook.
+func (e *Engine) FinishGameWithResult(result chess.LearnResult) error {
+	e.bookMu.Lock()
+	defer e.bookMu.Unlock()
+
+	return e.learnBook(func(play bookPlay) chess.LearnResult {
+		if play.pos.Turn() == chess.Black {
+			return -result
+		}
+		return result
+	})
+}
+
+// FinishGame ends the current game at its final position.
+//
+// When the position is a checkmate or a draw by stalemate or insufficient
+// material, the opening books learn the result of the book moves played
+// during the game, see LearnBook. Otherwise the result is unknown
+// and the book moves played are forgotten.
+func (e *Engine) FinishGame(pos *chess.Position) error {
+	e.bookMu.Lock()
+	defer e.bookMu.Unlock()
+
+	result, ok := finalResult(pos, e.bookPlayed)
+	if !ok {
+		e.bookPlayed = nil
+		return nil
+	}
+
+	return e.learnBook(func(bookPlay) chess.LearnResult { return result })
+}
+
+// learnBook updates the opening books with the result of each book move played
+// and forgets the recorded book moves.
+//
+// Expects the book mutex to be held.
+func (e *Engine) learnBook(result func(play bookPlay) chess.LearnResult) error {
+	var errs []error
+	for _, play := range e.bookPlayed {
+		if err := play.book.Learn(&play.pos, play.move, result(play)); err != nil {
+			errs = append(errs, fmt.Errorf("book learning %s: %w", play.move, err))
+		}
+	}
+	e.bookPlayed = nil
+
+	return errors.Join(errs...)
+}
+
+// finalResult returns the result of the game ended in the position
+// from the point of view of the player of the book moves.
+//
+// Returns false if no book move was played or if the game is not over.
+func finalResult(pos *chess.Position, played []bookPlay) (chess.LearnResult, bool) {
+	if len(played) == 0 {
+		return chess.LearnDraw, false
+	}
+
+	if pos.HasInsufficientMaterial() {
+		return chess.LearnDraw, true
+	}
+
+	var ml chess.MoveList
+	checkData, inCheck := pos.InCheck()
+	switch {
+	case len(pos.AppendLegalMoves(ml[:0], checkData)) > 0:
+		return chess.LearnDraw, false
+	case !inCheck:
+		return chess.LearnDraw, true
+	case pos.Turn() == played[0].pos.Turn():
+		return chess.LearnLoss, true
+	default:
+		return chess.LearnWin, true
+	}
+}
+
+// bookVerifier verifies book moves with a short search.
+type bookVerifier struct {
+	si    *searchInfo
+	pos   *chess.Position
+	depth uint8
+	best  int32
+	ready bool // whether the best score has been searched
+}
+
+// newBookVerifier returns a new book verifier.
+func newBookVerifier(e *Engine, pos *chess.Position) *bookVerifier {
+	si := newSearchInfo(e.table, e.pawnTable)
+	si.evalTable = e.evalTable
+	si.weights = e.loadedEvalWeights()
+	si.useNetwork(e.loadedNetwork(), pos)
+	return &bookVerifier{
+		si:    si,
+		pos:   pos,
+		depth: uint8(min(e.book.verify, maxSearchDepth)),
+	}
+}
+
+// verify returns whether the book move scores within bookVerifyMargin
+// of the best move of the verification search.
+//
+// An interrupted search does not reject the move.
+func (bv *bookVerifier) verify(ctx context.Context, move chess.Move) bool {
+	if !bv.ready {
+		best, err := bv.si.principalVariation(ctx, bv.pos, -mate, mate, bv.depth, 0)
+		if err != nil {
+			return true
+		}
+		bv.best, bv.ready = best, true
+	}
+
+	meta := bv.pos.Metadata()
+	hash := bv.pos.Hash()
+	pawnHash := bv.pos.PawnHash()
+
+	if ok := bv.pos.MakeMove(move); !ok {
+		return false
+	}
+	score, err := bv.si.principalVariation(ctx, bv.pos, -mate, mate, bv.depth-1, 1)
+	bv.pos.UnmakeMove(move, meta, hash, pawnHash)
+
+	return err != nil || -score >= bv.best-bookVerifyMargin
+}
+
+// initBooks loads the opening books if the book files changed since the last call.
+func (e *Engine) initBooks() error {
+	e.bookMu.Lock()
+	defer e.bookMu.Unlock()
+
+	if e.books != nil {
+		return nil
+	}
+
+	var err error
+	e.books, err = loadBooks(e.bookFiles, e.bookLearning)
+	return err
+}
@@ -0,0 +1,297 @@
+package search
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+const laskerTrapFile = "../test/data/lasker-trap.bin"
+
+func TestLoadBooks(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	malformed := filepath.Join(dir, "malformed.bin")
+	assert.NoError(t, os.WriteFile(malformed, make([]byte, 20), 0o600))
+
+	tests := []struct {
+		name  string
+		args  []string
+		books int
+		err   string
+	}{
+		{"embedded", nil, 1, ""},
+		{"book file", []string{laskerTrapFile}, 1, ""},
+		{"several book files", []string{laskerTrapFile, laskerTrapFile}, 2, ""},
+		{
+			"malformed book file",
+			[]string{malformed, laskerTrapFile},
+			1,
+			"book file " + malformed + ": expected data to be multiple of 16 bytes",
+		},
+		{
+			"fallback to embedded",
+			[]string{malformed},
+			1,
+			"book file " + malformed + ": expected data to be multiple of 16 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			books, err := loadBooks(tt.args, false)
+			assert.Len(t, books, tt.books)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestBookMove(t *testing.T) {
+	t.Parallel()
+	// only known by the lasker trap book
+	fen := "rnbqk1nr/ppp2ppp/8/4P3/1bPp4/4P3/PP1B1PPP/RN1QKBNR b KQkq - 0 1"
+	pos, err := chess.FEN{}.Decode(fen)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"embedded", nil, chess.NoMove.String()},
+		{"priority order", []string{laskerTrapFile}, "d4e3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			e := NewEngine(WithBookFiles(tt.args...))
+			assert.NoError(t, e.Init())
+			assert.Equal(t, tt.want, e.bookMove(context.Background(), pos).String())
+		})
+	}
+}
+
+func TestWithBookFiles(t *testing.T) {
+	t.Parallel()
+	e := NewEngine()
+	assert.NoError(t, e.Init())
+	assert.Len(t, e.books, 1)
+
+	WithBookFiles(laskerTrapFile, laskerTrapFile)(e)
+	assert.Nil(t, e.books)
+	assert.NoError(t, e.Init())
+	assert.Len(t, e.books, 2)
+}
+
+func TestBookCandidates(t *testing.T) {
+	t.Parallel()
+	moves := []chess.WeightedMove{
+		{Move: chess.Move(1), Weight: 100},
+		{Move: chess.Move(2), Weight: 60},
+		{Move: chess.Move(3), Weight: 40},
+		{Move: chess.Move(4), Weight: 0},
+	}
+
+	tests := []struct {
+		name string
+		args bookOptions
+		want int
+	}{
+		{"zero weights", bookOptions{policy: BookWeighted}, 3},
+		{"variety", bookOptions{policy: BookWeighted, variety: 2}, 2},
+		{"threshold", bookOptions{policy: BookUniform, threshold: 50}, 2},
+		{"threshold and variety", bookOptions{policy: BookUniform, threshold: 30, variety: 1}, 1},
+		{"threshold ignored", bookOptions{policy: BookBest, threshold: 100}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, moves[:tt.want], tt.args.candidates(moves))
+		})
+	}
+}
+
+func TestBookPick(t *testing.T) {
+	t.Parallel()
+	moves := []chess.WeightedMove{
+		{Move: chess.Move(1), Weight: 2},
+		{Move: chess.Move(2), Weight: 1},
+	}
+
+	for _, policy := range []BookPolicy{BookBest, BookWeighted, BookUniform} {
+		bo := bookOptions{policy: policy, temperature: 100}
+		assert.Equal(t, -1, bo.pick(nil))
+		assert.Equal(t, 0, bo.pick(moves[:1]))
+	}
+
+	// the lowest temperature almost always selects the best weighted move
+	bo := bookOptions{policy: BookWeighted, temperature: 1}
+	for range 100 {
+		assert.Equal(t, 0, bo.pick(moves))
+	}
+
+	bo = bookOptions{policy: BookBest}
+	assert.Equal(t, 0, bo.pick(moves))
+}
+
+func TestBookDepth(t *testing.T) {
+	t.Parallel()
+	pos, err := chess.FEN{}.Decode("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 11")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		depth int
+		want  bool
+	}{
+		{10, false},
+		{11, true},
+	}
+
+	for _, tt := range tests {
+		e := NewEngine(WithBookDepth(tt.depth))
+		assert.NoError(t, e.Init())
+		assert.Equal(t, tt.want, e.bookMove(context.Background(), pos) != chess.NoMove)
+	}
+}
+
+func TestBookVerification(t *testing.T) {
+	t.Parallel()
+	pos, err := chess.FEN{}.Decode("4k3/8/8/2p5/8/8/8/3QK3 w - - 0 1")
+	assert.NoError(t, err)
+
+	// the queen is lost on d4
+	data := append(
+		polyglotEntry(pos.Hash(), chess.D1, chess.D4, 100),
+		polyglotEntry(pos.Hash(), chess.D1, chess.C1, 1)...,
+	)
+
+	tests := []struct {
+		name  string
+		depth int
+		want  string
+	}{
+		{"without verification", 0, "d1d4"},
+		{"with verification", 2, "d1c1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			book, err := chess.NewSortedBook(data)
+			assert.NoError(t, err)
+
+			e := NewEngine(WithBookPolicy(BookBest), WithBookVerification(tt.depth))
+			e.books = []*chess.Book{book}
+			assert.NoError(t, e.Init())
+
+			assert.Equal(t, tt.want, e.bookMove(context.Background(), pos).String())
+		})
+	}
+}
+
+func TestBookLearning(t *testing.T) {
+	t.Parallel()
+	pos := chess.StartingPosition()
+	path := filepath.Join(t.TempDir(), "book.bin")
+	assert.NoError(t, os.WriteFile(path, polyglotEntry(pos.Hash(), chess.E2, chess.E4, 8), 0o600))
+
+	// scholar's mate
+	mated, err := chess.FEN{}.Decode("r1bqkb1r/pppp1Qpp/2n2n2/4p3/2B1P3/8/PPPP1PPP/RNB1K1NR b KQkq - 0 4")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		learning bool
+		final    *chess.Position
+		want     chess.WeightedMove
+	}{
+		{"disabled", false, mated, chess.WeightedMove{Weight: 8}},
+		{"game not over", true, pos, chess.WeightedMove{Weight: 8}},
+		{"win", true, mated, chess.WeightedMove{Weight: 9, Learn: 1<<16 | 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the tests share the book file
+			e := NewEngine(WithBookFiles(path), WithBookLearning(tt.learning))
+			assert.NoError(t, e.Init())
+			move := e.bookMove(context.Background(), pos)
+			assert.Equal(t, "e2e4", move.String())
+			assert.NoError(t, e.FinishGame(tt.final))
+			assert.Empty(t, e.bookPlayed)
+			e.Close()
+
+			book, err := chess.OpenBook(path)
+			assert.NoError(t, err)
+			defer book.Close()
+
+			tt.want.Move = move
+			assert.Equal(t, []chess.WeightedMove{tt.want}, book.Lookup(pos))
+		})
+	}
+}
+
+func TestFinishGameWithResult(t *testing.T) {
+	t.Parallel()
+	pos := chess.StartingPosition()
+	e2e4, err := chess.UCI{}.Decode(pos, "e2e4")
+	assert.NoError(t, err)
+	afterE4 := chess.StartingPosition()
+	assert.True(t, afterE4.MakeMove(e2e4))
+
+	bb := chess.NewBookBuilder()
+	bb.AddEntry(chess.BookEntry{Key: pos.Hash(), Move: uint16(chess.E4) | uint16(chess.E2)<<6, Weight: 8})
+	bb.AddEntry(chess.BookEntry{Key: afterE4.Hash(), Move: uint16(chess.E5) | uint16(chess.E7)<<6, Weight: 8})
+	path := filepath.Join(t.TempDir(), "book.bin")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	_, err = bb.WriteTo(f)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	// the engine plays the book moves of both sides
+	e := NewEngine(WithBookFiles(path), WithBookLearning(true))
+	assert.NoError(t, e.Init())
+	assert.Equal(t, "e2e4", e.bookMove(context.Background(), pos).String())
+	assert.Equal(t, "e7e5", e.bookMove(context.Background(), afterE4).String())
+	assert.NoError(t, e.FinishGameWithResult(chess.LearnWin))
+	assert.Empty(t, e.bookPlayed)
+	e.Close()
+
+	book, err := chess.OpenBook(path)
+	assert.NoError(t, err)
+	defer book.Close()
+
+	white := book.Lookup(pos)
+	if assert.Len(t, white, 1) {
+		assert.Equal(t, 9, white[0].Weight)
+		assert.Equal(t, uint32(1<<16|2), white[0].Learn)
+	}
+	black := book.Lookup(afterE4)
+	if assert.Len(t, black, 1) {
+		assert.Equal(t, 7, black[0].Weight)
+		assert.Equal(t, uint32(1<<16), black[0].Learn)
+	}
+}
+
+// polyglotEntry returns a Polyglot entry.
+func polyglotEntry(hash chess.Hash, from, to chess.Square, weight uint16) []byte {
+	entry := make([]byte, 16)
+	binary.BigEndian.PutUint64(entry[0:8], uint64(hash))
+	binary.BigEndian.PutUint16(entry[8:10], uint16(to)|uint16(from)<<6)
+	binary.BigEndian.PutUint16(entry[10:12], weight)
+	return entry
+}
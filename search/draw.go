@@ -0,0 +1,70 @@
+package search
+
+import "github.com/leonhfr/orca/chess"
+
+// fiftyMoveClock is the half move clock at which a game is drawn by the fifty-move rule.
+const fiftyMoveClock = 100
+
+// drawScore returns the score of a draw from the point of view of the side to move.
+//
+// With a positive contempt, draws score below equality for the side to move
+// at the root and above it for the opponent, so that the engine avoids them.
+func (si *searchInfo) drawScore(pos *chess.Position) int32 {
+	if pos.Turn() == si.rootColor {
+		return draw - si.contempt
+	}
+	return draw + si.contempt
+}
+
+// tableScore returns the score of a transposition table entry
+// from the point of view of the side to move.
+//
+// Draws scored without searching moves, such as stalemates, are stored
+// without contempt so that the entries are shared by both colors.
+// The contempt is applied when their score is returned.
+func (si *searchInfo) tableScore(pos *chess.Position, entry searchEntry) int32 {
+	score := entry.score()
+	if entry.nodeType() == exact && entry.best == chess.NoMove && score == draw {
+		return si.drawScore(pos)
+	}
+	return score
+}
+
+// isDraw records the position at the given ply and returns whether
+// it is drawn by the fifty-move rule or by repetition.
+//
+// Positions repeat positions of the search since the last null move,
+// or of the game before the root when no null move was made.
+// The root is never a draw so that the search returns a move.
+func (si *searchInfo) isDraw(pos *chess.Position, index uint8) bool {
+	hash := pos.Hash()
+	si.keys[index] = hash
+	if index == 0 {
+		return false
+	}
+
+	clock := int(pos.HalfMoveClock())
+	if clock >= fiftyMoveClock {
+		return true
+	}
+
+	for ply := int(index) - 2; ply >= int(index)-clock; ply -= 2 {
+		var key chess.Hash
+		switch {
+		case ply >= int(si.nullPly):
+			key = si.keys[ply]
+		case ply >= 0 || si.nullPly > 0:
+			return false // before a null move
+		case len(si.game)+ply < 0:
+			return false
+		default:
+			key = si.game[len(si.game)+ply]
+		}
+
+		if key == hash {
+			return true
+		}
+	}
+
+	return false
+}
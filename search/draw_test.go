@@ -0,0 +1,165 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+func TestDrawScore(t *testing.T) {
+	t.Parallel()
+	si := newSearchInfo(noTable{}, noPawnTable{})
+	si.rootColor, si.contempt = chess.White, 20
+
+	assert.Equal(t, int32(-20), si.drawScore(unsafeFEN("4k3/8/8/8/8/8/8/4K3 w - - 0 1")))
+	assert.Equal(t, int32(20), si.drawScore(unsafeFEN("4k3/8/8/8/8/8/8/4K3 b - - 0 1")))
+}
+
+func TestIsDraw(t *testing.T) {
+	t.Parallel()
+	start := chess.StartingPosition()
+	moves := []string{"g1f3", "g8f6", "f3g1", "f6g8"}
+
+	tests := []struct {
+		name    string
+		fen     string
+		game    int   // number of moves played before the root
+		nullPly uint8 // ply following the null move
+		want    bool
+	}{
+		{"root", "", 4, 0, false},
+		{"search repetition", "", 0, 0, true},
+		{"game repetition", "", 2, 0, true},
+		{"repetition before null move", "", 0, 1, false},
+		{"fifty-move rule", "4k3/8/8/8/8/8/8/R3K3 w - - 100 80", 0, 0, true},
+		{"no repetition", "4k3/8/8/8/8/8/8/R3K3 w - - 99 80", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			si := newSearchInfo(noTable{}, noPawnTable{})
+			si.nullPly = tt.nullPly
+
+			if tt.fen != "" {
+				assert.False(t, si.isDraw(unsafeFEN(tt.fen), 0))
+				assert.Equal(t, tt.want, si.isDraw(unsafeFEN(tt.fen), 1))
+				return
+			}
+
+			pos := unsafeFEN(start.String())
+			for i, m := range moves {
+				if i < tt.game {
+					si.game = append(si.game, pos.Hash())
+				} else {
+					si.isDraw(pos, uint8(i-tt.game))
+				}
+				require.True(t, pos.MakeMove(unsafeMove(pos, m)))
+			}
+
+			assert.Equal(t, tt.want, si.isDraw(pos, uint8(len(moves)-tt.game)))
+		})
+	}
+}
+
+func TestSearch_Contempt(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		options []Option
+		want    int
+	}{
+		{"no contempt", nil, draw},
+		{"contempt", []Option{WithContempt(30)}, -30},
+		{"negative contempt", []Option{WithContempt(-30)}, 30},
+		{"analyse mode", []Option{WithContempt(30), WithAnalyseMode(true)}, draw},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			e := NewEngine(tt.options...)
+
+			var output Output
+			for o := range e.Search(context.Background(), unsafeFEN("8/8/8/4k3/8/8/8/4KN2 b - - 0 1"), 1, 0) {
+				output = o
+			}
+
+			assert.Equal(t, tt.want, output.Score)
+		})
+	}
+}
+
+func TestSearch_Contempt_SharedTable(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		fens [2]string // the pawn of the second position is taken or promotes
+	}{
+		{"white then black", [2]string{"8/3k4/8/8/8/4K3/8/8 w - - 0 1", "8/3P4/4k3/8/8/4K3/8/8 b - - 0 1"}},
+		{"black then white", [2]string{"8/8/4k3/8/8/8/3K4/8 b - - 0 1", "8/8/4k3/8/8/4K3/3p4/8 w - - 0 1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			e := NewEngine(WithContempt(30), WithTableSize(1))
+
+			for _, fen := range tt.fens {
+				var output Output
+				for o := range e.Search(context.Background(), unsafeFEN(fen), 4, 0) {
+					output = o
+				}
+
+				// the draw stored by the first search is scored for the root color of the second one
+				assert.Equal(t, -30, output.Score, fen)
+			}
+		})
+	}
+}
+
+func TestSearch_History(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		history bool
+	}{
+		{"without history", false},
+		{"with history", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			// White is lost but can repeat the position after g1f3
+			pos := unsafeFEN("2rq3k/8/8/8/8/8/8/K5N1 w - - 0 1")
+			var history []chess.Hash
+			for _, m := range []string{"g1f3", "h8h7", "f3g1", "h7h8"} {
+				history = append(history, pos.Hash())
+				require.True(t, pos.MakeMove(unsafeMove(pos, m)))
+			}
+
+			e := NewEngine()
+			if tt.history {
+				e.SetHistory(history)
+			}
+
+			var output Output
+			for o := range e.Search(context.Background(), pos, 2, 0) {
+				output = o
+			}
+
+			require.NotEmpty(t, output.PV)
+			if tt.history {
+				assert.Equal(t, draw, output.Score)
+				assert.Equal(t, "g1f3", output.PV[0].String())
+			} else {
+				assert.Less(t, output.Score, -500)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package search
+
+import (
+	"strings"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+// knownWin is the score of a won endgame known without search.
+//
+// Above any evaluation, below the tablebase wins.
+const knownWin int32 = 10_000
+
+// Scale factors of the evaluation, in 64ths.
+const (
+	scaleDraw            = 0  // scaleDraw scales drawn endgames to a draw.
+	scaleOppositeBishops = 16 // scaleOppositeBishops scales endgames with opposite-coloured bishops only.
+	scaleNormal          = 64 // scaleNormal does not scale the evaluation.
+)
+
+// material holds the pieces of a position with few pieces.
+type material struct {
+	counts  [2][chess.NoPieceType]int
+	kings   [2]chess.Square
+	bishops [2]chess.Square // square of a bishop, if any
+	pawns   [2][8]chess.Square
+}
+
+// newMaterial returns the material of the position.
+func newMaterial(pos *chess.Position) material {
+	var m material
+	pos.BoardMap(func(p chess.Piece, sq chess.Square) {
+		c := p.Color()
+		switch p.Type() {
+		case chess.King:
+			m.kings[c] = sq
+		case chess.Bishop:
+			m.bishops[c] = sq
+		case chess.Pawn:
+			m.pawns[c][m.counts[c][chess.Pawn]] = sq
+		}
+		m.counts[c][p.Type()]++
+	})
+	return m
+}
+
+// pawnSquares returns the squares of the pawns of the color.
+func (m *material) pawnSquares(c chess.Color) []chess.Square {
+	return m.pawns[c][:m.counts[c][chess.Pawn]]
+}
+
+// pieces returns the number of knights, bishops, rooks and queens of the color.
+func (m *material) pieces(c chess.Color) int {
+	return m.counts[c][chess.Knight] + m.counts[c][chess.Bishop] +
+		m.counts[c][chess.Rook] + m.counts[c][chess.Queen]
+}
+
+// bare returns whether the color only has its king.
+func (m *material) bare(c chess.Color) bool {
+	return m.pieces(c) == 0 && m.counts[c][chess.Pawn] == 0
+}
+
+// materialKey is the signature of the material of a position:
+// the number of pawns, knights, bishops, rooks and queens of each color.
+type materialKey uint64
+
+// key returns the material signature.
+func (m *material) key() materialKey {
+	var key materialKey
+	for c := chess.Black; c <= chess.White; c++ {
+		for pt := chess.Pawn; pt < chess.King; pt++ {
+			key = key<<4 | materialKey(m.counts[c][pt])
+		}
+	}
+	return key
+}
+
+// endgameFunc returns the score of an endgame from the point of view
+// of the strong side.
+//
+// The material is passed by value so that it does not escape to the heap.
+type endgameFunc func(m material, strong, stm chess.Color) int32
+
+// endgame is a specialized evaluation function of a material signature.
+type endgame struct {
+	strong chess.Color
+	eval   endgameFunc
+}
+
+// endgames holds the specialized evaluation functions, keyed by material signature.
+var endgames = newEndgames(map[string]endgameFunc{
+	"KPvK":  evaluateKPK,
+	"KQvK":  evaluateKXK,
+	"KRvK":  evaluateKXK,
+	"KBNvK": evaluateKBNK,
+})
+
+// newEndgames registers the evaluation functions for both colors.
+//
+// Signatures list the pieces of the strong side, then of the weak side.
+func newEndgames(funcs map[string]endgameFunc) map[materialKey]endgame {
+	registry := make(map[materialKey]endgame, 2*len(funcs))
+	for signature, eval := range funcs {
+		for strong := chess.Black; strong <= chess.White; strong++ {
+			var m material
+			sides := strings.Split(signature, "v")
+			for i, c := range []chess.Color{strong, strong.Other()} {
+				for _, r := range sides[i] {
+					if i := strings.IndexRune("PNBRQ", r); i >= 0 {
+						m.counts[c][i]++
+					}
+				}
+			}
+			registry[m.key()] = endgame{strong, eval}
+		}
+	}
+	return registry
+}
+
+// evaluateEndgame returns the score of the position from the point of view
+// of the side to move, if its material has a specialized evaluation function.
+func (m *material) evaluateEndgame(stm chess.Color) (int32, bool) {
+	eg, ok := endgames[m.key()]
+	if !ok {
+		return 0, false
+	}
+
+	score := eg.eval(*m, eg.strong, stm)
+	if stm != eg.strong {
+		score = -score
+	}
+	return score, true
+}
+
+// evaluateKPK evaluates king and pawn versus king with the KPK bitbase.
+func evaluateKPK(m material, strong, stm chess.Color) int32 {
+	// the bitbase holds the white pawn on the files A to D
+	wk, bk, p := m.kings[strong], m.kings[strong.Other()], m.pawns[strong][0]
+	turn := stm
+	if strong == chess.Black {
+		wk, bk, p = wk^56, bk^56, p^56
+		turn = stm.Other()
+	}
+	if p.File() > chess.FileD {
+		wk, bk, p = wk^7, bk^7, p^7
+	}
+
+	if !probeKPK(turn, wk, bk, p) {
+		return draw
+	}
+
+	// the bonus on the rank pushes the pawn
+	return knownWin + defaultEvalWeights.pestoEGPieceValues[chess.Pawn] + int32(p.Rank())
+}
+
+// evaluateKXK evaluates a major piece versus a bare king.
+//
+// Drives the weak king to the edge and the strong king next to it.
+func evaluateKXK(m material, strong, _ chess.Color) int32 {
+	weak := strong.Other()
+	score := knownWin +
+		pushToEdge(m.kings[weak]) +
+		pushClose(m.kings[strong], m.kings[weak])
+
+	for pt := chess.Knight; pt < chess.King; pt++ {
+		score += int32(m.counts[strong][pt]) * defaultEvalWeights.pestoEGPieceValues[pt]
+	}
+
+	return score
+}
+
+// evaluateKBNK evaluates bishop and knight versus a bare king.
+//
+// Drives the weak king to a corner of the colour of the bishop
+// and the strong king next to it.
+func evaluateKBNK(m material, strong, _ chess.Color) int32 {
+	weak := strong.Other()
+	sq := m.kings[weak]
+	if !sameSquareColor(m.bishops[strong], chess.A1) {
+		// mirrored to the corners of the dark squares
+		sq ^= 7
+	}
+
+	return knownWin +
+		defaultEvalWeights.pestoEGPieceValues[chess.Bishop] + defaultEvalWeights.pestoEGPieceValues[chess.Knight] +
+		kbnkCornerWeight*pushToCorner(sq) +
+		pushClose(m.kings[strong], m.kings[weak])
+}
+
+// kbnkCornerWeight is the weight of the distance to the mating corner in KBNK.
+const kbnkCornerWeight = 50
+
+// pushToEdge returns a bonus growing as the square nears the edge of the board.
+func pushToEdge(sq chess.Square) int32 {
+	fd := int32(min(sq.File(), 7-sq.File()))
+	rd := int32(min(sq.Rank(), 7-sq.Rank()))
+	return 90 - 7*(fd*fd+rd*rd)/2
+}
+
+// pushToCorner returns a bonus growing as the square nears A1 or H8.
+func pushToCorner(sq chess.Square) int32 {
+	return int32(absInt(7 - int(sq.Rank()) - int(sq.File())))
+}
+
+// pushClose returns a bonus growing as the squares get closer.
+func pushClose(a, b chess.Square) int32 {
+	return 140 - 20*int32(squareDistance(a, b))
+}
+
+// scaleFactor returns the scale factor of the evaluation of drawish endgames.
+func (m *material) scaleFactor() int32 {
+	switch {
+	case m.rookEndgame():
+		return scaleDraw
+	case m.wrongRookPawn(chess.White), m.wrongRookPawn(chess.Black):
+		return scaleDraw
+	case m.oppositeBishops():
+		return scaleOppositeBishops
+	default:
+		return scaleNormal
+	}
+}
+
+// rookEndgame returns whether both sides only have a rook.
+func (m *material) rookEndgame() bool {
+	for c := chess.Black; c <= chess.White; c++ {
+		if m.pieces(c) != 1 || m.counts[c][chess.Rook] != 1 || m.counts[c][chess.Pawn] > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// wrongRookPawn returns whether the color only has a bishop and rook pawns
+// of the same file whose promotion square is not of the colour of the bishop,
+// while the bare king of the other color defends the promotion square.
+func (m *material) wrongRookPawn(c chess.Color) bool {
+	if m.pieces(c) != 1 || m.counts[c][chess.Bishop] != 1 || m.counts[c][chess.Pawn] == 0 || !m.bare(c.Other()) {
+		return false
+	}
+
+	f := m.pawns[c][0].File()
+	if f != chess.FileA && f != chess.FileH {
+		return false
+	}
+	for _, p := range m.pawnSquares(c) {
+		if p.File() != f {
+			return false
+		}
+	}
+
+	promotion := square(f, chess.Rank8)
+	if c == chess.Black {
+		promotion = square(f, chess.Rank1)
+	}
+
+	return !sameSquareColor(m.bishops[c], promotion) && squareDistance(m.kings[c.Other()], promotion) <= 1
+}
+
+// oppositeBishops returns whether both sides only have a bishop and pawns,
+// the bishops being on squares of opposite colours.
+func (m *material) oppositeBishops() bool {
+	for c := chess.Black; c <= chess.White; c++ {
+		if m.pieces(c) != 1 || m.counts[c][chess.Bishop] != 1 {
+			return false
+		}
+	}
+	return !sameSquareColor(m.bishops[chess.White], m.bishops[chess.Black])
+}
+
+// sameSquareColor returns whether the squares have the same colour.
+func sameSquareColor(a, b chess.Square) bool {
+	return (int(a.File())+int(a.Rank()))%2 == (int(b.File())+int(b.Rank()))%2
+}
@@ -0,0 +1,99 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaleFactor(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		fen  string
+		want int32
+	}{
+		{"rook endgame", "8/8/3k4/8/3r4/8/3RK3/8 w - - 0 1", scaleDraw},
+		{"rook endgame with pawns", "8/8/3k4/8/3r4/8/3RKP2/8 w - - 0 1", scaleNormal},
+		{"wrong rook pawn", "k7/8/8/P7/8/8/3B4/4K3 w - - 0 1", scaleDraw},
+		{"wrong rook pawns", "1k6/8/P7/P7/8/8/3B4/4K3 b - - 0 1", scaleDraw},
+		{"wrong rook pawn, black", "4k3/8/8/8/7p/4b3/8/6K1 w - - 0 1", scaleDraw},
+		{"right bishop", "k7/8/8/P7/8/8/4B3/4K3 w - - 0 1", scaleNormal},
+		{"king far from the corner", "8/8/4k3/P7/8/8/3B4/4K3 w - - 0 1", scaleNormal},
+		{"not a rook pawn", "k7/8/8/1P6/8/8/3B4/4K3 w - - 0 1", scaleNormal},
+		{"opposite bishops", "4k3/5p2/4b3/8/8/2B5/5P2/4K3 w - - 0 1", scaleOppositeBishops},
+		{"same coloured bishops", "4k3/5p2/3b4/8/8/2B5/5P2/4K3 w - - 0 1", scaleNormal},
+		{"bishop and rook", "4k3/5p2/3b4/8/8/2R5/5P2/4K3 w - - 0 1", scaleNormal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m := newMaterial(unsafeFEN(tt.fen))
+			assert.Equal(t, tt.want, m.scaleFactor())
+		})
+	}
+}
+
+func TestEvaluate_Scaled(t *testing.T) {
+	t.Parallel()
+	si := newSearchInfo(noTable{}, noPawnTable{})
+	assert.Equal(t, int32(draw), si.evaluate(unsafeFEN("8/8/3k4/8/3r4/8/3RK3/8 w - - 0 1")))
+	assert.Equal(t, int32(draw), si.evaluate(unsafeFEN("k7/8/8/P7/8/8/3B4/4K3 w - - 0 1")))
+}
+
+func TestEvaluateEndgame(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		fen  string
+		ok   bool
+	}{
+		{"KQvK", "8/8/3k4/8/8/8/3QK3/8 w - - 0 1", true},
+		{"KvKR", "8/8/3k4/8/3r4/8/4K3/8 w - - 0 1", true},
+		{"KBNvK", "8/8/3k4/8/8/8/2BNK3/8 b - - 0 1", true},
+		{"KPvK", "8/8/3k4/8/8/8/3PK3/8 w - - 0 1", true},
+		{"KBvK", "8/8/3k4/8/8/8/3BK3/8 w - - 0 1", false},
+		{"KRvKR", "8/8/3k4/8/3r4/8/3RK3/8 w - - 0 1", false},
+		{"KRPvK", "8/8/3k4/8/8/8/3RKP2/8 w - - 0 1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pos := unsafeFEN(tt.fen)
+			m := newMaterial(pos)
+			score, ok := m.evaluateEndgame(pos.Turn())
+			assert.Equal(t, tt.ok, ok)
+			if ok && tt.name != "KPvK" {
+				assert.Greater(t, absInt(int(score)), int(knownWin))
+			}
+		})
+	}
+}
+
+func TestEvaluateEndgame_MopUp(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		better string
+		worse  string
+	}{
+		{"KQvK edge", "3k4/8/3K4/8/8/8/8/Q7 w - - 0 1", "8/8/8/3k4/8/3K4/8/Q7 w - - 0 1"},
+		{"KRvK kings close", "3k4/8/3K4/8/8/8/8/R7 w - - 0 1", "3k4/8/8/8/8/8/3K4/R7 w - - 0 1"},
+		{"KvKR edge", "3K4/8/3k4/8/8/8/8/r7 b - - 0 1", "8/8/8/3K4/8/3k4/8/r7 b - - 0 1"},
+		{"KBNvK dark corner", "7k/8/5K2/8/8/8/8/2BN4 w - - 0 1", "k7/8/2K5/8/8/8/8/2BN4 w - - 0 1"},
+		{"KBNvK light corner", "k7/8/2K5/8/8/8/8/3BN3 w - - 0 1", "7k/8/5K2/8/8/8/8/3BN3 w - - 0 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			better, worse := unsafeFEN(tt.better), unsafeFEN(tt.worse)
+			mb, mw := newMaterial(better), newMaterial(worse)
+			sb, _ := mb.evaluateEndgame(better.Turn())
+			sw, _ := mw.evaluateEndgame(worse.Turn())
+			assert.Greater(t, sb, sw)
+		})
+	}
+}
@@ -1,61 +1,150 @@
 package search
 
-import "github.com/leonhfr/orca/chess"
+import (
+	"math/bits"
+
+	"github.com/leonhfr/orca/chess"
+)
 
 // evaluate returns the score of a position.
+//
+// Endgames with a specialized evaluation function, such as king and pawn
+// versus king with the KPK bitbase, skip the evaluation.
+// Drawish endgames are scaled down. Other positions are evaluated
+// by the NNUE network when one is used.
+//
+// The scores are cached in the evaluation transposition table.
+// The noise of the skill level is added to the cached scores.
 func (si *searchInfo) evaluate(pos *chess.Position) int32 {
+	hash := pos.Hash()
+	entry, inCache := si.evalTable.get(hash)
+	score := entry.score()
+	if !inCache {
+		score = si.evaluateTrace(pos, nil)
+		si.evalTable.set(hash, score)
+	}
+
+	if si.noise > 0 {
+		score += si.evalNoise(hash)
+	}
+	return score
+}
+
+// evaluateTrace returns the score of a position,
+// recording the terms of the classic evaluation in the trace if not nil.
+func (si *searchInfo) evaluateTrace(pos *chess.Position, t *EvalTrace) int32 {
+	w := si.weights
 	player := pos.Turn()
 	knights, bishops, rooks, queens := pos.CountPieces()
 	phase := int32(knights + bishops + 2*rooks + 4*queens)
 	phase = min(phase, 24) // in case of early promotion
 
+	var scale int32 = scaleNormal
+	if knights+bishops+rooks+queens <= 2 {
+		m := newMaterial(pos)
+		if score, ok := m.evaluateEndgame(player); ok {
+			t.setSpecialized()
+			return score
+		}
+		scale = m.scaleFactor()
+	}
+	t.setPhase(phase, scale)
+
+	if si.nnue != nil {
+		return si.nnue.Evaluate(pos) * scale / scaleNormal
+	}
+
 	var mgMaterial [2]int32
 	var egMaterial [2]int32
 
 	pawnCount := pos.PawnCount()
-	mgMaterial[chess.Black] = int32(pawnCount[chess.Black]) * pestoMGPieceValues[chess.Pawn]
-	mgMaterial[chess.White] = int32(pawnCount[chess.White]) * pestoMGPieceValues[chess.Pawn]
-	egMaterial[chess.Black] = int32(pawnCount[chess.Black]) * pestoEGPieceValues[chess.Pawn]
-	egMaterial[chess.White] = int32(pawnCount[chess.White]) * pestoEGPieceValues[chess.Pawn]
-
-	mg, eg := si.evaluatePawns(pos)
+	mgMaterial[chess.Black] = int32(pawnCount[chess.Black]) * w.pestoMGPieceValues[chess.Pawn]
+	mgMaterial[chess.White] = int32(pawnCount[chess.White]) * w.pestoMGPieceValues[chess.Pawn]
+	egMaterial[chess.Black] = int32(pawnCount[chess.Black]) * w.pestoEGPieceValues[chess.Pawn]
+	egMaterial[chess.White] = int32(pawnCount[chess.White]) * w.pestoEGPieceValues[chess.Pawn]
+
+	var bishopCount, queenCount [2]int
+	var earlyQueen [2]bool
+	mg, eg, passed := si.evaluatePawns(pos, t)
+	mgPassed, egPassed := si.evaluatePassed(pos, passed, t)
+	mg += mgPassed
+	eg += egPassed
 	fd := pos.FileData()
 
 	pos.PieceMap(func(p chess.Piece, sq chess.Square, mobility int, properties chess.PieceProperty) {
 		c := p.Color()
 		pt := p.Type()
 
-		mgMaterial[c] += pestoMGPieceValues[pt]
-		egMaterial[c] += pestoEGPieceValues[pt]
+		mgMaterial[c] += w.pestoMGPieceValues[pt]
+		egMaterial[c] += w.pestoEGPieceValues[pt]
 
-		mgValue := pestoMGPieceSquareTable[p][sq]
-		egValue := pestoEGPieceSquareTable[p][sq]
+		mgValue := w.pestoMGPieceSquareTable[p][sq]
+		egValue := w.pestoEGPieceSquareTable[p][sq]
+		t.add(TermPieceSquare, c, mgValue, egValue)
 
-		mgValue += mobilityTermsMG[pt][mobility]
-		egValue += mobilityTermsEG[pt][mobility]
+		mgValue += w.mobilityTermsMG[pt][mobility]
+		egValue += w.mobilityTermsEG[pt][mobility]
+		t.add(TermMobility, c, w.mobilityTermsMG[pt][mobility], w.mobilityTermsEG[pt][mobility])
 
 		if properties.HasProperty(chess.Trapped) {
-			mgValue += trappedPiecePenalty[pt]
-			egValue += trappedPiecePenalty[pt]
+			mgValue += w.trappedPiecePenalty[pt]
+			egValue += w.trappedPiecePenalty[pt]
+			t.add(TermTrappedPieces, c, w.trappedPiecePenalty[pt], w.trappedPiecePenalty[pt])
 		}
 
 		if properties.HasProperty(chess.Lost) {
-			mgValue += lostPiecePenalty[pt]
-			egValue += lostPiecePenalty[pt]
+			mgValue += w.lostPiecePenalty[pt]
+			egValue += w.lostPiecePenalty[pt]
+			t.add(TermLostPieces, c, w.lostPiecePenalty[pt], w.lostPiecePenalty[pt])
 		}
 
 		if pt == chess.Rook {
+			var bonus int32
 			switch {
 			case sq.Rank() == rookPenultimateRank[c]:
-				mgValue += rookPenultimateRankBonus
-				egValue += rookPenultimateRankBonus
+				bonus = w.rookPenultimateRankBonus
 			case fd.OnOpenFile(sq):
-				mgValue += rookOpenFileBonus
-				egValue += rookOpenFileBonus
+				bonus = w.rookOpenFileBonus
 			case fd.OnHalfOpenFile(sq, c.Other()):
-				mgValue += rookHalfOpenFileBonus
-				egValue += rookHalfOpenFileBonus
+				bonus = w.rookHalfOpenFileBonus
 			}
+			if properties.HasProperty(chess.Connected) {
+				bonus += w.connectedRooksBonus
+			}
+			mgValue += bonus
+			egValue += bonus
+			t.add(TermRooks, c, bonus, bonus)
+		}
+
+		if properties.HasProperty(chess.Outpost) {
+			mgValue += w.outpostBonusMG[pt]
+			egValue += w.outpostBonusEG[pt]
+			t.add(TermOutposts, c, w.outpostBonusMG[pt], w.outpostBonusEG[pt])
+		}
+
+		var mgThreats, egThreats int32
+		if properties.HasProperty(chess.AttackedByPawn) {
+			mgThreats += w.pawnThreatMG[pt]
+			egThreats += w.pawnThreatEG[pt]
+		}
+		if properties.HasProperty(chess.Threatened) {
+			mgThreats += w.pieceThreatMG[pt]
+			egThreats += w.pieceThreatEG[pt]
+		}
+		if properties.HasProperty(chess.Hanging) {
+			mgThreats += w.hangingPenaltyMG
+			egThreats += w.hangingPenaltyEG
+		}
+		mgValue += mgThreats
+		egValue += egThreats
+		t.add(TermThreats, c, mgThreats, egThreats)
+
+		switch pt {
+		case chess.Bishop:
+			bishopCount[c]++
+		case chess.Queen:
+			queenCount[c]++
+			earlyQueen[c] = sq != queenSquare[c]
 		}
 
 		if c == chess.White {
@@ -67,6 +156,34 @@ func (si *searchInfo) evaluate(pos *chess.Position) int32 {
 		}
 	})
 
+	for c := chess.Black; c <= chess.White; c++ {
+		if bishopCount[c] < 2 {
+			continue
+		}
+		t.add(TermBishopPair, c, w.bishopPairMG, w.bishopPairEG)
+		if c == chess.White {
+			mg += w.bishopPairMG
+			eg += w.bishopPairEG
+		} else {
+			mg -= w.bishopPairMG
+			eg -= w.bishopPairEG
+		}
+	}
+
+	for c := chess.Black; c <= chess.White; c++ {
+		// the starting squares are unknown in Chess960, and a second queen is promoted
+		if !earlyQueen[c] || queenCount[c] > 1 || pos.Chess960Setup(c) {
+			continue
+		}
+		penalty := int32(undevelopedMinors(pos, c)) * w.earlyQueenMG
+		t.add(TermDevelopment, c, penalty, 0)
+		if c == chess.White {
+			mg += penalty
+		} else {
+			mg -= penalty
+		}
+	}
+
 	materialValue := [2]int32{
 		taperedEval(mgMaterial[chess.Black], egMaterial[chess.Black], phase),
 		taperedEval(mgMaterial[chess.White], egMaterial[chess.White], phase),
@@ -75,16 +192,25 @@ func (si *searchInfo) evaluate(pos *chess.Position) int32 {
 	pos.KingMap(func(p chess.Piece, sq chess.Square, shieldDefects, openFiles, halfOpenFiles int) {
 		c := p.Color()
 
-		factor := materialValue[c.Other()] / initialMaterialValue
+		factor := materialValue[c.Other()] / w.initialMaterialValue
+
+		mgValue := w.pestoMGPieceSquareTable[p][sq]
+		egValue := w.pestoEGPieceSquareTable[p][sq]
+		t.add(TermPieceSquare, c, mgValue, egValue)
 
-		mgValue := pestoMGPieceSquareTable[p][sq]
-		egValue := pestoEGPieceSquareTable[p][sq]
+		mgSafety := w.shieldDefectsPenaltyMG[shieldDefects] * factor
+		egSafety := w.shieldDefectsPenaltyEG[shieldDefects] * factor
 
-		mgValue += shieldDefectsPenaltyMG[shieldDefects] * factor
-		egValue += shieldDefectsPenaltyEG[shieldDefects] * factor
+		mgSafety += (int32(openFiles)*w.openFilePenaltyMG + int32(halfOpenFiles)*w.halfOpenFilePenaltyMG) * factor
+		egSafety += (int32(openFiles)*w.openFilePenaltyEG + int32(halfOpenFiles)*w.halfOpenFilePenaltyEG) * factor
 
-		mgValue += (int32(openFiles)*openFilePenaltyMG + int32(halfOpenFiles)*halfOpenFilePenaltyMG) * factor
-		egValue += (int32(openFiles)*openFilePenaltyEG + int32(halfOpenFiles)*halfOpenFilePenaltyEG) * factor
+		if ka := pos.KingAttacks(c); ka.Attackers >= minKingAttackers {
+			mgSafety += w.kingSafetyMG[w.attackUnits(ka)]
+		}
+		t.add(TermKingSafety, c, mgSafety, egSafety)
+
+		mgValue += mgSafety
+		egValue += egSafety
 
 		if c == chess.White {
 			mg += mgValue
@@ -97,46 +223,80 @@ func (si *searchInfo) evaluate(pos *chess.Position) int32 {
 
 	mg += mgMaterial[chess.White] - mgMaterial[chess.Black]
 	eg += egMaterial[chess.White] - egMaterial[chess.Black]
+	t.add(TermMaterial, chess.White, mgMaterial[chess.White], egMaterial[chess.White])
+	t.add(TermMaterial, chess.Black, mgMaterial[chess.Black], egMaterial[chess.Black])
 
 	if player == chess.Black {
 		mg, eg = -mg, -eg
 	}
-	mg += tempo
+	mg += w.tempo
+	t.add(TermTempo, player, w.tempo, 0)
 
-	return taperedEval(mg, eg, phase)
+	return taperedEval(mg, eg, phase) * scale / scaleNormal
 }
 
 // evaluatePawns evaluate the pawn structure.
 //
-// Always returns the evaluation from White's point of view.
-func (si *searchInfo) evaluatePawns(pos *chess.Position) (int32, int32) {
+// Always returns the evaluation from White's point of view,
+// along with the squares of the passed pawns.
+// The pawn transposition table is skipped when tracing.
+func (si *searchInfo) evaluatePawns(pos *chess.Position, t *EvalTrace) (int32, int32, uint64) {
+	w := si.weights
 	pawnHash := pos.PawnHash()
 
-	if entry, inCache := si.pawnTable.get(pawnHash); inCache {
-		return entry.mg(), entry.eg()
+	if entry, inCache := si.pawnTable.get(pawnHash); inCache && t == nil {
+		return entry.mg(), entry.eg(), entry.passed
 	}
 
 	var mg, eg int32
+	var passed uint64
 	pos.PawnMap(func(p chess.Piece, sq chess.Square, properties chess.PawnProperty) {
 		color, file := p.Color(), sq.File()
 
-		mgValue := pestoMGPieceSquareTable[p][sq]
-		egValue := pestoEGPieceSquareTable[p][sq]
+		mgValue := w.pestoMGPieceSquareTable[p][sq]
+		egValue := w.pestoEGPieceSquareTable[p][sq]
+		t.add(TermPieceSquare, color, mgValue, egValue)
 
+		var mgStructure, egStructure int32
 		if properties.HasProperty(chess.Doubled) {
-			mgValue += doubledPenaltyMG[file]
-			egValue += doubledPenaltyEG[file]
+			mgStructure += w.doubledPenaltyMG[file]
+			egStructure += w.doubledPenaltyEG[file]
 		}
 
 		if properties.HasProperty(chess.Isolani) {
-			mgValue += isolaniPenaltyMG[file]
-			egValue += isolaniPenaltyEG[file]
+			mgStructure += w.isolaniPenaltyMG[file]
+			egStructure += w.isolaniPenaltyEG[file]
+		}
+
+		if properties.HasProperty(chess.Backward) {
+			mgStructure += w.backwardPenaltyMG[file]
+			egStructure += w.backwardPenaltyEG[file]
+		}
+
+		if properties.HasProperty(chess.Phalanx) {
+			mgStructure += w.phalanxBonusMG[color][sq]
+			egStructure += w.phalanxBonusEG[color][sq]
+		}
+
+		if properties.HasProperty(chess.Supported) {
+			mgStructure += w.supportedBonusMG[color][sq]
+			egStructure += w.supportedBonusEG[color][sq]
+		}
+
+		if properties.HasProperty(chess.Candidate) {
+			mgStructure += w.candidateBonusMG[color][sq]
+			egStructure += w.candidateBonusEG[color][sq]
 		}
 
 		if properties.HasProperty(chess.Passed) {
-			mgValue += passedBonusMG[color][sq]
-			egValue += passedBonusEG[color][sq]
+			mgStructure += w.passedBonusMG[color][sq]
+			egStructure += w.passedBonusEG[color][sq]
+			passed |= 1 << sq
 		}
+		t.add(TermPawnStructure, color, mgStructure, egStructure)
+
+		mgValue += mgStructure
+		egValue += egStructure
 
 		if color == chess.White {
 			mg += mgValue
@@ -147,100 +307,187 @@ func (si *searchInfo) evaluatePawns(pos *chess.Position) (int32, int32) {
 		}
 	})
 
-	si.pawnTable.set(pawnHash, mg, eg)
+	si.pawnTable.set(pawnHash, mg, eg, passed)
+
+	return mg, eg, passed
+}
+
+// evaluatePassed evaluates the passed pawns against the pieces of the position:
+// the distances of the kings to their stop squares, the blocked passed pawns
+// and, when the opponent has no pieces left, the passed pawns the enemy king cannot catch.
+//
+// Always returns the evaluation from White's point of view.
+func (si *searchInfo) evaluatePassed(pos *chess.Position, passed uint64, t *EvalTrace) (int32, int32) {
+	w := si.weights
+
+	var mg, eg int32
+	for ; passed > 0; passed &= passed - 1 {
+		sq := chess.Square(bits.TrailingZeros64(passed))
+		color := pos.PieceAt(sq).Color()
+		stop, promotion := passedPath(color, sq)
+
+		mgValue := int32(0)
+		egValue := w.passedKingOwnEG[color][sq]*int32(squareDistance(pos.KingSquare(color), stop)) +
+			w.passedKingEnemyEG[color][sq]*int32(squareDistance(pos.KingSquare(color.Other()), stop))
+
+		if pos.PieceAt(stop) != chess.NoPiece {
+			mgValue += w.blockedPassedMG[color][sq]
+			egValue += w.blockedPassedEG[color][sq]
+		} else if isUnstoppable(pos, color, sq, promotion) {
+			egValue += w.unstoppablePassedEG
+		}
+		t.add(TermPassedPawns, color, mgValue, egValue)
+
+		if color == chess.White {
+			mg += mgValue
+			eg += egValue
+		} else {
+			mg -= mgValue
+			eg -= egValue
+		}
+	}
 
 	return mg, eg
 }
 
-func taperedEval(mg, eg, phase int32) int32 {
-	return (phase*mg + (24-phase)*eg) / 24
+// passedPath returns the stop square and the promotion square of a pawn.
+func passedPath(c chess.Color, sq chess.Square) (chess.Square, chess.Square) {
+	if c == chess.White {
+		return sq + 8, chess.Square(sq.File()) + chess.A8
+	}
+	return sq - 8, chess.Square(sq.File())
 }
 
-// tempo is a bonus for the player having the right to move.
-// Only applied for middle game.
-const tempo = 6
-
-var (
-	initialMaterialValue    int32
-	pestoMGPieceValues      = [6]int32{82, 337, 365, 477, 1025, 0}       // Middle game piece material values. Indexed by piece type.
-	pestoEGPieceValues      = [6]int32{94, 281, 297, 512, 936, 0}        // End game piece material values. Indexed by piece type.
-	pestoMGPieceSquareTable = [12][64]int32{}                            // Middle game piece square table. Indexed by square and piece.
-	pestoEGPieceSquareTable = [12][64]int32{}                            // End game piece square table. Indexed by square and piece.
-	doubledPenaltyMG        = [8]int32{-10, -6, -6, -6, -6, -6, -6, -10} // Middle game penalty for double pawns. Indexed by file.
-	doubledPenaltyEG        = [8]int32{-5, -3, -3, -3, -3, -3, -3, -5}   // End game penalty for double pawns. Indexed by file.
-	isolaniPenaltyMG        = [8]int32{-10, -6, -6, -6, -6, -6, -6, -10} // Middle game penalty for isolated pawns. Indexed by file.
-	isolaniPenaltyEG        = [8]int32{-5, -3, -3, -3, -3, -3, -3, -5}   // End game penalty for isolated pawns. Indexed by file.
-	passedBonusMG           = [2][64]int32{}                             // Middle game bonus for passed pawns. Indexed by square and color.
-	passedBonusEG           = [2][64]int32{}                             // End game bonus for passed pawns. Indexed by square and color.
-	shieldDefectsPenaltyMG  = [4]int32{0, -20, -40, -60}                 // Middle game penalty for shield defects.
-	shieldDefectsPenaltyEG  = [4]int32{0, 0, 0, 0}                       // End game penalty for shield defects.
-	trappedPiecePenalty     = [6]int32{0, -20, -50, -40, 0, 0}           // Penalty for trapped pieces. Indexed by piece type.
-	lostPiecePenalty        = [6]int32{0, 0, -150, 0, 0, 0}              // Penalty for lost pieces. Indexed by piece type.
-)
+// isUnstoppable returns whether the passed pawn promotes before the enemy king
+// catches it, according to the rule of the square.
+//
+// Only applies when the opponent has no pieces left and the path of the pawn is free.
+func isUnstoppable(pos *chess.Position, c chess.Color, sq, promotion chess.Square) bool {
+	if pos.CountColorPieces(c.Other()) > 0 {
+		return false
+	}
 
-const (
-	openFilePenaltyMG     = -20
-	openFilePenaltyEG     = 0
-	halfOpenFilePenaltyMG = -10
-	halfOpenFilePenaltyEG = 0
-)
+	for s := sq; s != promotion; {
+		if c == chess.White {
+			s += 8
+		} else {
+			s -= 8
+		}
+		if pos.PieceAt(s) != chess.NoPiece {
+			return false
+		}
+	}
 
-var rookPenultimateRank = [2]chess.Rank{chess.Rank2, chess.Rank7} // rookPenultimateRank indicates the rook penultimate rank. Indexed by color.
+	distance := min(squareDistance(sq, promotion), 5) // double push from the second rank
+	kingDistance := squareDistance(pos.KingSquare(c.Other()), promotion)
+	if pos.Turn() != c {
+		kingDistance--
+	}
 
-const (
-	rookPenultimateRankBonus = 80 // Bonus for rooks on penultimate rank.
-	rookOpenFileBonus        = 60 // Bonus for rooks on open files.
-	rookHalfOpenFileBonus    = 40 // Bonus for rooks on half open files.
-)
+	return distance < kingDistance
+}
 
-var (
-	mobilityTermsMG = [6][]int32{
-		{}, // pawns
-		{
-			-104,
-			-45, -22, -8, 6, 11, 19, 20, 45,
-		},
-		{
-			-99,
-			-46, -16, -4, 6, 14, 17, 19, 19, 27, 26,
-			52, 55, 83,
-		},
-		{
-			-127,
-			-56, -25, -12, -10, -12, -11, -4, 4, 9, 11,
-			19, 19, 37, 97,
-		},
-		{
-			-111,
-			-253, -127, -46, -20, -9, -1, 2, 8, 10, 15,
-			17, 20, 23, 22, 21, 24, 16, 13, 18, 25,
-			38, 34, 28, 10, 7, -42, -23,
-		},
+// attackUnits returns the attack units of the enemy on the king zone,
+// bounded by the size of the king safety table.
+func (w *evalWeights) attackUnits(ka chess.KingAttack) int {
+	var units int32
+	for pt := chess.Pawn; pt <= chess.King; pt++ {
+		units += int32(ka.Attacks[pt])*w.kingAttackUnits[pt] + int32(ka.SafeChecks[pt])*w.safeCheckUnits[pt]
 	}
-	mobilityTermsEG = [6][]int32{
-		{}, // pawns
-		{
-			-139,
-			-114, -37, 3, 15, 34, 38, 37, 17,
-		},
-		{
-			-186,
-			-124, -54, -14, 1, 20, 35, 39, 49, 48, 48,
-			32, 47, 2,
-		},
-		{
-			-148,
-			-127, -85, -28, 2, 27, 42, 46, 52, 55, 64,
-			68, 73, 60, 15,
-		},
-		{
-			-273,
-			-401, -228, -236, -173, -86, -35, -1, 8, 31, 37,
-			55, 46, 57, 58, 64, 62, 65, 63, 48, 30,
-			8, -12, -29, -44, -79, -30, -50,
-		},
+	units += int32(ka.WeakSquares) * w.weakSquareUnits
+	return int(min(max(units, 0), int32(len(w.kingSafetyMG)-1)))
+}
+
+// undevelopedMinors returns the number of minor pieces of the color on their starting squares in classic chess.
+func undevelopedMinors(pos *chess.Position, c chess.Color) int {
+	var count int
+	for _, sq := range minorSquares[c] {
+		if p := pos.PieceAt(sq); p != chess.NoPiece && p.Color() == c &&
+			(p.Type() == chess.Knight || p.Type() == chess.Bishop) {
+			count++
+		}
 	}
-)
+	return count
+}
+
+func taperedEval(mg, eg, phase int32) int32 {
+	return (phase*mg + (24-phase)*eg) / 24
+}
+
+// evalWeights holds the weights of the evaluation, laid out for fast lookups.
+type evalWeights struct {
+	tempo                    int32         // Bonus for the player having the right to move. Only applied for middle game.
+	initialMaterialValue     int32         // Middle game material value of the starting position.
+	pestoMGPieceValues       [6]int32      // Middle game piece material values. Indexed by piece type.
+	pestoEGPieceValues       [6]int32      // End game piece material values. Indexed by piece type.
+	pestoMGPieceSquareTable  [12][64]int32 // Middle game piece square table. Indexed by square and piece.
+	pestoEGPieceSquareTable  [12][64]int32 // End game piece square table. Indexed by square and piece.
+	doubledPenaltyMG         [8]int32      // Middle game penalty for double pawns. Indexed by file.
+	doubledPenaltyEG         [8]int32      // End game penalty for double pawns. Indexed by file.
+	isolaniPenaltyMG         [8]int32      // Middle game penalty for isolated pawns. Indexed by file.
+	isolaniPenaltyEG         [8]int32      // End game penalty for isolated pawns. Indexed by file.
+	passedBonusMG            [2][64]int32  // Middle game bonus for passed pawns. Indexed by square and color.
+	passedBonusEG            [2][64]int32  // End game bonus for passed pawns. Indexed by square and color.
+	backwardPenaltyMG        [8]int32      // Middle game penalty for backward pawns. Indexed by file.
+	backwardPenaltyEG        [8]int32      // End game penalty for backward pawns. Indexed by file.
+	phalanxBonusMG           [2][64]int32  // Middle game bonus for pawns in phalanx. Indexed by square and color.
+	phalanxBonusEG           [2][64]int32  // End game bonus for pawns in phalanx. Indexed by square and color.
+	supportedBonusMG         [2][64]int32  // Middle game bonus for supported pawns. Indexed by square and color.
+	supportedBonusEG         [2][64]int32  // End game bonus for supported pawns. Indexed by square and color.
+	candidateBonusMG         [2][64]int32  // Middle game bonus for candidate passed pawns. Indexed by square and color.
+	candidateBonusEG         [2][64]int32  // End game bonus for candidate passed pawns. Indexed by square and color.
+	blockedPassedMG          [2][64]int32  // Middle game penalty for blocked passed pawns. Indexed by square and color.
+	blockedPassedEG          [2][64]int32  // End game penalty for blocked passed pawns. Indexed by square and color.
+	passedKingOwnEG          [2][64]int32  // End game weight of the own king distance to passed pawns. Indexed by square and color.
+	passedKingEnemyEG        [2][64]int32  // End game weight of the enemy king distance to passed pawns. Indexed by square and color.
+	unstoppablePassedEG      int32         // End game bonus for unstoppable passed pawns.
+	shieldDefectsPenaltyMG   [4]int32      // Middle game penalty for shield defects.
+	shieldDefectsPenaltyEG   [4]int32      // End game penalty for shield defects.
+	trappedPiecePenalty      [6]int32      // Penalty for trapped pieces. Indexed by piece type.
+	lostPiecePenalty         [6]int32      // Penalty for lost pieces. Indexed by piece type.
+	mobilityTermsMG          [6][]int32    // Middle game mobility terms. Indexed by piece type and mobility.
+	mobilityTermsEG          [6][]int32    // End game mobility terms. Indexed by piece type and mobility.
+	openFilePenaltyMG        int32         // Middle game penalty for open files next to the king.
+	openFilePenaltyEG        int32         // End game penalty for open files next to the king.
+	halfOpenFilePenaltyMG    int32         // Middle game penalty for half open files next to the king.
+	halfOpenFilePenaltyEG    int32         // End game penalty for half open files next to the king.
+	kingAttackUnits          [6]int32      // Attack units per attacked square of the king zone. Indexed by piece type.
+	safeCheckUnits           [6]int32      // Attack units per safe check. Indexed by piece type.
+	weakSquareUnits          int32         // Attack units per weak square of the king zone.
+	kingSafetyMG             [100]int32    // Middle game penalty for attacks on the king zone. Indexed by attack units.
+	rookPenultimateRankBonus int32         // Bonus for rooks on penultimate rank.
+	rookOpenFileBonus        int32         // Bonus for rooks on open files.
+	rookHalfOpenFileBonus    int32         // Bonus for rooks on half open files.
+	connectedRooksBonus      int32         // Bonus for rooks defended by a friendly rook.
+	outpostBonusMG           [6]int32      // Middle game bonus for outposts. Indexed by piece type.
+	outpostBonusEG           [6]int32      // End game bonus for outposts. Indexed by piece type.
+	bishopPairMG             int32         // Middle game bonus for the bishop pair.
+	bishopPairEG             int32         // End game bonus for the bishop pair.
+	pawnThreatMG             [6]int32      // Middle game penalty for pieces attacked by pawns. Indexed by piece type.
+	pawnThreatEG             [6]int32      // End game penalty for pieces attacked by pawns. Indexed by piece type.
+	pieceThreatMG            [6]int32      // Middle game penalty for pieces attacked by lower value pieces. Indexed by piece type.
+	pieceThreatEG            [6]int32      // End game penalty for pieces attacked by lower value pieces. Indexed by piece type.
+	hangingPenaltyMG         int32         // Middle game penalty for hanging pieces.
+	hangingPenaltyEG         int32         // End game penalty for hanging pieces.
+	earlyQueenMG             int32         // Middle game penalty per undeveloped minor piece once the queen has moved.
+}
+
+// defaultEvalWeights holds the compiled-in weights of the evaluation.
+var defaultEvalWeights *evalWeights
+
+// minKingAttackers is the number of pieces attacking the king zone
+// from which the king safety table applies.
+const minKingAttackers = 2
+
+var queenSquare = [2]chess.Square{chess.D8, chess.D1} // queenSquare indicates the queen starting square in classic chess. Indexed by color.
+
+// minorSquares indicates the starting squares of the minor pieces in classic chess. Indexed by color.
+var minorSquares = [2][4]chess.Square{
+	{chess.B8, chess.C8, chess.F8, chess.G8},
+	{chess.B1, chess.C1, chess.F1, chess.G1},
+}
+
+var rookPenultimateRank = [2]chess.Rank{chess.Rank2, chess.Rank7} // rookPenultimateRank indicates the rook penultimate rank. Indexed by color.
 
 // incMateDistance increases the distance to the mate by a count of one.
 //
@@ -0,0 +1,458 @@
+package search
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+// EvalParams holds the weights of the evaluation.
+//
+// Weights suffixed by MG apply to the middle game and weights suffixed by EG
+// to the end game, the others to both. Tables indexed by rank and file are
+// laid out from White's point of view, starting with the eighth rank,
+// and mirrored for Black.
+type EvalParams struct {
+	PieceValuesMG         [6]int32       // Indexed by piece type.
+	PieceValuesEG         [6]int32       // Indexed by piece type.
+	PieceSquareMG         [6][8][8]int32 // Indexed by piece type, rank and file.
+	PieceSquareEG         [6][8][8]int32 // Indexed by piece type, rank and file.
+	MobilityMG            [6][]int32     // Indexed by piece type and mobility.
+	MobilityEG            [6][]int32     // Indexed by piece type and mobility.
+	DoubledPenaltyMG      [8]int32       // Indexed by file.
+	DoubledPenaltyEG      [8]int32       // Indexed by file.
+	IsolaniPenaltyMG      [8]int32       // Indexed by file.
+	IsolaniPenaltyEG      [8]int32       // Indexed by file.
+	PassedBonusMG         [8][8]int32    // Indexed by rank and file.
+	PassedBonusEG         [8][8]int32    // Indexed by rank and file.
+	BackwardPenaltyMG     [8]int32       // Indexed by file.
+	BackwardPenaltyEG     [8]int32       // Indexed by file.
+	PhalanxBonusMG        [8]int32       // Indexed by rank.
+	PhalanxBonusEG        [8]int32       // Indexed by rank.
+	SupportedBonusMG      [8]int32       // Indexed by rank.
+	SupportedBonusEG      [8]int32       // Indexed by rank.
+	CandidateBonusMG      [8]int32       // Indexed by rank.
+	CandidateBonusEG      [8]int32       // Indexed by rank.
+	BlockedPassedMG       [8]int32       // Passed pawns with an occupied stop square. Indexed by rank.
+	BlockedPassedEG       [8]int32       // Passed pawns with an occupied stop square. Indexed by rank.
+	PassedKingOwnEG       [8]int32       // Per square between the own king and the stop square. Indexed by rank.
+	PassedKingEnemyEG     [8]int32       // Per square between the enemy king and the stop square. Indexed by rank.
+	UnstoppablePassedEG   int32          // Bonus for passed pawns out of reach of the enemy king without pieces.
+	ShieldDefectsMG       [4]int32       // Indexed by number of missing shield pawns.
+	ShieldDefectsEG       [4]int32       // Indexed by number of missing shield pawns.
+	OpenFilePenaltyMG     int32          // Per open file next to the king.
+	OpenFilePenaltyEG     int32          // Per open file next to the king.
+	HalfOpenFilePenaltyMG int32          // Per half open file next to the king.
+	HalfOpenFilePenaltyEG int32          // Per half open file next to the king.
+	KingAttackUnits       [6]int32       // Attack units per attacked square of the king zone. Indexed by piece type.
+	SafeCheckUnits        [6]int32       // Attack units per safe check. Indexed by piece type.
+	WeakSquareUnits       int32          // Attack units per weak square of the king zone.
+	KingSafetyMG          [100]int32     // Indexed by attack units, when at least two pieces attack the king zone.
+	TrappedPiecePenalty   [6]int32       // Indexed by piece type.
+	LostPiecePenalty      [6]int32       // Indexed by piece type.
+	RookPenultimateRank   int32          // Bonus for rooks on the penultimate rank.
+	RookOpenFile          int32          // Bonus for rooks on open files.
+	RookHalfOpenFile      int32          // Bonus for rooks on half open files.
+	ConnectedRooks        int32          // Bonus for rooks defended by a friendly rook.
+	OutpostBonusMG        [6]int32       // Indexed by piece type.
+	OutpostBonusEG        [6]int32       // Indexed by piece type.
+	BishopPairMG          int32          // Bonus for the side with at least two bishops.
+	BishopPairEG          int32          // Bonus for the side with at least two bishops.
+	PawnThreatMG          [6]int32       // Pieces attacked by enemy pawns. Indexed by piece type.
+	PawnThreatEG          [6]int32       // Pieces attacked by enemy pawns. Indexed by piece type.
+	PieceThreatMG         [6]int32       // Pieces attacked by enemy pieces of lower value. Indexed by piece type.
+	PieceThreatEG         [6]int32       // Pieces attacked by enemy pieces of lower value. Indexed by piece type.
+	HangingPenaltyMG      int32          // Per piece attacked and not defended.
+	HangingPenaltyEG      int32          // Per piece attacked and not defended.
+	EarlyQueenMG          int32          // Per minor piece on its starting square once the queen has moved.
+	Tempo                 int32          // Bonus for the side to move, middle game only.
+}
+
+// DefaultEvalParams returns the default weights of the evaluation.
+func DefaultEvalParams() EvalParams {
+	return defaultEvalParams.clone()
+}
+
+// clone returns a deep copy of the weights.
+func (p EvalParams) clone() EvalParams {
+	for pt := range p.MobilityMG {
+		p.MobilityMG[pt] = slices.Clone(p.MobilityMG[pt])
+		p.MobilityEG[pt] = slices.Clone(p.MobilityEG[pt])
+	}
+	return p
+}
+
+// WithEvalParams sets the weights of the evaluation.
+//
+// The weights are validated on the next call to Init,
+// the default weights being used when they are invalid.
+func WithEvalParams(params EvalParams) Option {
+	return func(e *Engine) {
+		e.evalMu.Lock()
+		defer e.evalMu.Unlock()
+		params = params.clone()
+		e.evalParams = &params
+		e.evalFile = ""
+		e.evalWeights = nil
+	}
+}
+
+// WithEvalFile sets the path of a file holding weights of the evaluation,
+// as read by ReadEvalParams.
+//
+// The file is loaded on the next call to Init. An empty path restores the default weights,
+// which are also used when the file could not be loaded.
+func WithEvalFile(path string) Option {
+	return func(e *Engine) {
+		e.evalMu.Lock()
+		defer e.evalMu.Unlock()
+		e.evalFile = path
+		e.evalParams = nil
+		e.evalWeights = nil
+	}
+}
+
+// initEvalWeights loads the weights of the evaluation if they changed since the last call.
+//
+// Reports whether the weights changed.
+func (e *Engine) initEvalWeights() (bool, error) {
+	e.evalMu.Lock()
+	defer e.evalMu.Unlock()
+
+	if e.evalWeights != nil {
+		return false, nil
+	}
+
+	e.evalWeights = defaultEvalWeights
+
+	var params EvalParams
+	var err error
+	switch {
+	case e.evalParams != nil:
+		params, err = *e.evalParams, e.evalParams.Validate()
+	case e.evalFile != "":
+		params, err = readEvalFile(e.evalFile)
+	default:
+		return true, nil
+	}
+
+	if err != nil {
+		return true, fmt.Errorf("eval: %w", err)
+	}
+
+	e.evalWeights = newEvalWeights(params)
+	return true, nil
+}
+
+// loadedEvalWeights returns the weights of the evaluation.
+func (e *Engine) loadedEvalWeights() *evalWeights {
+	e.evalMu.Lock()
+	defer e.evalMu.Unlock()
+
+	if e.evalWeights == nil {
+		return defaultEvalWeights
+	}
+	return e.evalWeights
+}
+
+// readEvalFile reads the weights of the evaluation from a file.
+func readEvalFile(path string) (EvalParams, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return EvalParams{}, err
+	}
+	defer f.Close()
+
+	params, err := ReadEvalParams(f)
+	if err != nil {
+		return EvalParams{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return params, nil
+}
+
+// ReadEvalParams reads weights of the evaluation, as JSON or text.
+//
+// The JSON object maps the names of the fields of EvalParams to their weights,
+// tables being nested arrays:
+//
+//	{"PieceValuesMG": [82, 337, 365, 477, 1025, 0], "Tempo": 6}
+//
+// The text format holds a field per line, its name followed by its weights
+// in the order of the tables, lines starting with # being ignored:
+//
+//	PieceValuesMG 82 337 365 477 1025 0
+//	Tempo 6
+//
+// Fields left out keep their default weights.
+// The shapes of the tables must match the ones of the defaults.
+func ReadEvalParams(r io.Reader) (EvalParams, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return EvalParams{}, err
+	}
+
+	params := DefaultEvalParams()
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		err = params.decodeJSON(data)
+	} else {
+		err = params.decodeText(data)
+	}
+	if err != nil {
+		return EvalParams{}, err
+	}
+
+	if err := params.Validate(); err != nil {
+		return EvalParams{}, err
+	}
+
+	return params, nil
+}
+
+var (
+	errEvalField    = errors.New("unknown field")
+	errEvalShape    = errors.New("invalid table shape")
+	errEvalWeight   = errors.New("invalid weight")
+	errEvalMaterial = errors.New("middle game material of the starting position must be positive")
+)
+
+// Validate returns an error if the weights cannot be used by the evaluation.
+//
+// The mobility tables must hold a weight for every mobility of the pieces.
+func (p *EvalParams) Validate() error {
+	for pt := chess.Pawn; pt <= chess.King; pt++ {
+		for _, mobility := range []struct {
+			name          string
+			weights, want []int32
+		}{
+			{"MobilityMG", p.MobilityMG[pt], defaultEvalParams.MobilityMG[pt]},
+			{"MobilityEG", p.MobilityEG[pt], defaultEvalParams.MobilityEG[pt]},
+		} {
+			if len(mobility.weights) != len(mobility.want) {
+				return fmt.Errorf("%s[%d]: %w: expected %d weights, got %d",
+					mobility.name, pt, errEvalShape, len(mobility.want), len(mobility.weights))
+			}
+		}
+	}
+
+	if p.initialMaterialValue() <= 0 {
+		return errEvalMaterial
+	}
+
+	return nil
+}
+
+// decodeJSON sets the fields of the JSON object.
+func (p *EvalParams) decodeJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	v := reflect.ValueOf(p).Elem()
+	for _, name := range names {
+		field := v.FieldByName(name)
+		if !field.IsValid() {
+			return fmt.Errorf("%s: %w", name, errEvalField)
+		}
+
+		var value any
+		if err := json.Unmarshal(fields[name], &value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		if err := setJSONValue(name, field, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setJSONValue sets the weights of the field from a decoded JSON value
+// of the same shape.
+func setJSONValue(name string, field reflect.Value, value any) error {
+	if field.Kind() == reflect.Int32 {
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) || n < math.MinInt32 || n > math.MaxInt32 {
+			return fmt.Errorf("%s: %w: %v", name, errEvalWeight, value)
+		}
+		field.SetInt(int64(n))
+		return nil
+	}
+
+	elems, ok := value.([]any)
+	if !ok || len(elems) != field.Len() {
+		return fmt.Errorf("%s: %w: expected an array of %d elements", name, errEvalShape, field.Len())
+	}
+
+	for i, elem := range elems {
+		if err := setJSONValue(fmt.Sprintf("%s[%d]", name, i), field.Index(i), elem); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeText sets the fields of the text format.
+func (p *EvalParams) decodeText(data []byte) error {
+	v := reflect.ValueOf(p).Elem()
+	for i, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		name := fields[0]
+		field := v.FieldByName(name)
+		if !field.IsValid() {
+			return fmt.Errorf("line %d: %s: %w", i+1, name, errEvalField)
+		}
+
+		weights := flattenWeights(field, nil)
+		if len(fields)-1 != len(weights) {
+			return fmt.Errorf("line %d: %s: %w: expected %d weights, got %d", i+1, name, errEvalShape, len(weights), len(fields)-1)
+		}
+
+		for j, w := range weights {
+			n, err := strconv.ParseInt(fields[j+1], 10, 32)
+			if err != nil {
+				return fmt.Errorf("line %d: %s: %w: %s", i+1, name, errEvalWeight, fields[j+1])
+			}
+			*w = int32(n)
+		}
+	}
+
+	return nil
+}
+
+// flattenWeights appends the addresses of the weights held in the value.
+func flattenWeights(v reflect.Value, weights []*int32) []*int32 {
+	switch v.Kind() {
+	case reflect.Int32:
+		return append(weights, v.Addr().Interface().(*int32))
+	case reflect.Array, reflect.Slice:
+		for i := range v.Len() {
+			weights = flattenWeights(v.Index(i), weights)
+		}
+	}
+	return weights
+}
+
+// newEvalWeights returns the weights used by the evaluation.
+//
+// The default piece-square tables are the ones of the
+// PeSTO (Piece-Square Tables Only) evaluation function by Ronald Friedrich,
+// interpolated by current game stage between the middle game and end game values.
+//
+// Source: https://www.chessprogramming.org/PeSTO%27s_Evaluation_Function
+func newEvalWeights(p EvalParams) *evalWeights {
+	p = p.clone()
+
+	w := &evalWeights{
+		tempo:                    p.Tempo,
+		pestoMGPieceValues:       p.PieceValuesMG,
+		pestoEGPieceValues:       p.PieceValuesEG,
+		doubledPenaltyMG:         p.DoubledPenaltyMG,
+		doubledPenaltyEG:         p.DoubledPenaltyEG,
+		isolaniPenaltyMG:         p.IsolaniPenaltyMG,
+		isolaniPenaltyEG:         p.IsolaniPenaltyEG,
+		backwardPenaltyMG:        p.BackwardPenaltyMG,
+		backwardPenaltyEG:        p.BackwardPenaltyEG,
+		unstoppablePassedEG:      p.UnstoppablePassedEG,
+		shieldDefectsPenaltyMG:   p.ShieldDefectsMG,
+		shieldDefectsPenaltyEG:   p.ShieldDefectsEG,
+		trappedPiecePenalty:      p.TrappedPiecePenalty,
+		lostPiecePenalty:         p.LostPiecePenalty,
+		mobilityTermsMG:          p.MobilityMG,
+		mobilityTermsEG:          p.MobilityEG,
+		openFilePenaltyMG:        p.OpenFilePenaltyMG,
+		openFilePenaltyEG:        p.OpenFilePenaltyEG,
+		halfOpenFilePenaltyMG:    p.HalfOpenFilePenaltyMG,
+		halfOpenFilePenaltyEG:    p.HalfOpenFilePenaltyEG,
+		kingAttackUnits:          p.KingAttackUnits,
+		safeCheckUnits:           p.SafeCheckUnits,
+		weakSquareUnits:          p.WeakSquareUnits,
+		kingSafetyMG:             p.KingSafetyMG,
+		rookPenultimateRankBonus: p.RookPenultimateRank,
+		rookOpenFileBonus:        p.RookOpenFile,
+		rookHalfOpenFileBonus:    p.RookHalfOpenFile,
+		connectedRooksBonus:      p.ConnectedRooks,
+		outpostBonusMG:           p.OutpostBonusMG,
+		outpostBonusEG:           p.OutpostBonusEG,
+		bishopPairMG:             p.BishopPairMG,
+		bishopPairEG:             p.BishopPairEG,
+		pawnThreatMG:             p.PawnThreatMG,
+		pawnThreatEG:             p.PawnThreatEG,
+		pieceThreatMG:            p.PieceThreatMG,
+		pieceThreatEG:            p.PieceThreatEG,
+		hangingPenaltyMG:         p.HangingPenaltyMG,
+		hangingPenaltyEG:         p.HangingPenaltyEG,
+		earlyQueenMG:             p.EarlyQueenMG,
+		initialMaterialValue:     p.initialMaterialValue(),
+	}
+
+	for piece := chess.BlackPawn; piece <= chess.WhiteKing; piece++ {
+		for sq := chess.A1; sq <= chess.H8; sq++ {
+			rank, file := humanRank(piece.Color(), sq), sq.File()
+			w.pestoMGPieceSquareTable[piece][sq] = p.PieceSquareMG[piece.Type()][rank][file]
+			w.pestoEGPieceSquareTable[piece][sq] = p.PieceSquareEG[piece.Type()][rank][file]
+		}
+	}
+
+	for c := chess.Black; c <= chess.White; c++ {
+		for sq := chess.A1; sq <= chess.H8; sq++ {
+			rank, file := humanRank(c, sq), sq.File()
+			w.passedBonusMG[c][sq] = p.PassedBonusMG[rank][file]
+			w.passedBonusEG[c][sq] = p.PassedBonusEG[rank][file]
+			w.phalanxBonusMG[c][sq] = p.PhalanxBonusMG[rank]
+			w.phalanxBonusEG[c][sq] = p.PhalanxBonusEG[rank]
+			w.supportedBonusMG[c][sq] = p.SupportedBonusMG[rank]
+			w.supportedBonusEG[c][sq] = p.SupportedBonusEG[rank]
+			w.candidateBonusMG[c][sq] = p.CandidateBonusMG[rank]
+			w.candidateBonusEG[c][sq] = p.CandidateBonusEG[rank]
+			w.blockedPassedMG[c][sq] = p.BlockedPassedMG[rank]
+			w.blockedPassedEG[c][sq] = p.BlockedPassedEG[rank]
+			w.passedKingOwnEG[c][sq] = p.PassedKingOwnEG[rank]
+			w.passedKingEnemyEG[c][sq] = p.PassedKingEnemyEG[rank]
+		}
+	}
+
+	return w
+}
+
+// initialMaterialValue returns the middle game material value of the starting position.
+func (p *EvalParams) initialMaterialValue() int32 {
+	return 8*p.PieceValuesMG[chess.Pawn] +
+		2*p.PieceValuesMG[chess.Knight] +
+		2*p.PieceValuesMG[chess.Bishop] +
+		2*p.PieceValuesMG[chess.Rook] +
+		p.PieceValuesMG[chess.Queen] +
+		p.PieceValuesMG[chess.King]
+}
+
+// humanRank returns the index of the rank of the square
+// in the tables laid out from White's point of view.
+func humanRank(c chess.Color, sq chess.Square) int {
+	if c == chess.Black {
+		return int(sq.Rank())
+	}
+	return 7 - int(sq.Rank())
+}
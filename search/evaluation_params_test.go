@@ -0,0 +1,148 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+func TestDefaultEvalParams(t *testing.T) {
+	t.Parallel()
+	p := DefaultEvalParams()
+	p.MobilityMG[chess.Knight][0] = 1
+
+	assert.NotEqual(t, p.MobilityMG[chess.Knight][0], defaultEvalParams.MobilityMG[chess.Knight][0])
+	assert.Equal(t, defaultEvalParams.MobilityMG[chess.Knight][0], defaultEvalWeights.mobilityTermsMG[chess.Knight][0])
+	assert.NoError(t, p.Validate())
+}
+
+func TestNewEvalWeights(t *testing.T) {
+	t.Parallel()
+	p := DefaultEvalParams()
+	w := newEvalWeights(p)
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		mirror := sq ^ 56
+		assert.Equal(t, w.pestoMGPieceSquareTable[chess.WhiteKnight][sq], w.pestoMGPieceSquareTable[chess.BlackKnight][mirror])
+		assert.Equal(t, w.passedBonusEG[chess.White][sq], w.passedBonusEG[chess.Black][mirror])
+	}
+	assert.Equal(t, p.PieceSquareMG[chess.Knight][0][0], w.pestoMGPieceSquareTable[chess.WhiteKnight][chess.A8])
+	assert.Equal(t, p.PieceSquareMG[chess.Knight][0][0], w.pestoMGPieceSquareTable[chess.BlackKnight][chess.A1])
+	assert.Equal(t, *defaultEvalWeights, *w)
+}
+
+func TestReadEvalParams(t *testing.T) {
+	t.Parallel()
+	modified := DefaultEvalParams()
+	modified.PieceValuesMG[chess.Pawn] = 100
+	modified.MobilityEG[chess.Knight][8] = 20
+	modified.Tempo = 10
+
+	tests := []struct {
+		name string
+		args string
+		want EvalParams
+		err  error
+	}{
+		{
+			name: "json",
+			args: `{
+				"PieceValuesMG": [100, 337, 365, 477, 1025, 0],
+				"MobilityEG": [[], [-139, -114, -37, 3, 15, 34, 38, 37, 20], [-186, -124, -54, -14, 1, 20, 35, 39, 49, 48, 48, 32, 47, 2], [-148, -127, -85, -28, 2, 27, 42, 46, 52, 55, 64, 68, 73, 60, 15], [-273, -401, -228, -236, -173, -86, -35, -1, 8, 31, 37, 55, 46, 57, 58, 64, 62, 65, 63, 48, 30, 8, -12, -29, -44, -79, -30, -50], []],
+				"Tempo": 10
+			}`,
+			want: modified,
+		},
+		{
+			name: "text",
+			args: strings.Join([]string{
+				"# tuned weights",
+				"PieceValuesMG 100 337 365 477 1025 0",
+				"",
+				"MobilityEG -139 -114 -37 3 15 34 38 37 20 -186 -124 -54 -14 1 20 35 39 49 48 48 32 47 2 -148 -127 -85 -28 2 27 42 46 52 55 64 68 73 60 15 -273 -401 -228 -236 -173 -86 -35 -1 8 31 37 55 46 57 58 64 62 65 63 48 30 8 -12 -29 -44 -79 -30 -50",
+				"Tempo 10",
+			}, "\n"),
+			want: modified,
+		},
+		{"empty", "", DefaultEvalParams(), nil},
+		{"json unknown field", `{"Tempi": 10}`, EvalParams{}, errEvalField},
+		{"json short table", `{"PieceValuesMG": [100, 337, 365, 477, 1025]}`, EvalParams{}, errEvalShape},
+		{"json long table", `{"PieceValuesMG": [100, 337, 365, 477, 1025, 0, 0]}`, EvalParams{}, errEvalShape},
+		{"json short mobility", `{"MobilityMG": [[], [1], [], [], [], []]}`, EvalParams{}, errEvalShape},
+		{"json nested shape", `{"PassedBonusMG": [0, 0, 0, 0, 0, 0, 0, 0]}`, EvalParams{}, errEvalShape},
+		{"json fractional weight", `{"Tempo": 1.5}`, EvalParams{}, errEvalWeight},
+		{"text unknown field", "Tempi 10", EvalParams{}, errEvalField},
+		{"text short table", "PieceValuesMG 100 337", EvalParams{}, errEvalShape},
+		{"text invalid weight", "Tempo ten", EvalParams{}, errEvalWeight},
+		{"no material", "PieceValuesMG 0 0 0 0 0 0", EvalParams{}, errEvalMaterial},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ReadEvalParams(strings.NewReader(tt.args))
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWithEvalFile(t *testing.T) {
+	t.Parallel()
+	pos := unsafeFEN("r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "eval.txt")
+	require.NoError(t, os.WriteFile(path, []byte("Tempo 106\n"), 0o600))
+
+	tests := []struct {
+		name  string
+		opt   Option
+		tempo int32
+		err   bool
+	}{
+		{"default", WithEvalFile(""), 0, false},
+		{"file", WithEvalFile(path), 100, false},
+		{"params", WithEvalParams(EvalParams{}), 0, true},
+		{"missing file", WithEvalFile(filepath.Join(dir, "missing.txt")), 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			e := NewEngine(tt.opt)
+			err := e.Init()
+			if tt.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			si := newSearchInfo(noTable{}, noPawnTable{})
+			want := si.evaluate(pos) + tt.tempo
+			si.weights = e.loadedEvalWeights()
+			assert.Equal(t, want, si.evaluate(pos))
+		})
+	}
+}
+
+func TestWithEvalParams_Search(t *testing.T) {
+	t.Parallel()
+	params := DefaultEvalParams()
+	params.PieceValuesMG[chess.Queen] = 0
+	params.PieceValuesEG[chess.Queen] = 0
+
+	e := NewEngine(WithEvalParams(params))
+	require.NoError(t, e.Init())
+
+	var score int
+	for o := range e.Search(context.Background(), unsafeFEN("rnb1kbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"), 1, 0) {
+		score = o.Score
+	}
+	assert.Less(t, score, 500)
+}
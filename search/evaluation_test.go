@@ -15,13 +15,13 @@ var testPositions = []struct {
 	eg    int32
 }{
 	{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 6, 0, 0},
-	{"2r3k1/1q1nbppp/r3p3/3pP3/pPpP4/P1Q2N2/2RN1PPP/2R4K b - b3 0 23", 135, 7, -34},
-	{"r2qk2r/pp1n1ppp/2pbpn2/3p4/2PP4/1PNQPN2/P4PPP/R1B1K2R w KQkq - 1 9", -71, -4, 2},
-	{"r3k2r/ppqn1ppp/2pbpn2/3p4/2PP4/1PNQPN2/P2B1PPP/R3K2R w KQkq - 3 10", -56, -4, 2},
+	{"2r3k1/1q1nbppp/r3p3/3pP3/pPpP4/P1Q2N2/2RN1PPP/2R4K b - b3 0 23", 114, 1, -38},
+	{"r2qk2r/pp1n1ppp/2pbpn2/3p4/2PP4/1PNQPN2/P4PPP/R1B1K2R w KQkq - 1 9", -61, 14, 16},
+	{"r3k2r/ppqn1ppp/2pbpn2/3p4/2PP4/1PNQPN2/P2B1PPP/R3K2R w KQkq - 3 10", -39, 14, 16},
 	{"r1bqkbnr/ppp1pppp/2n5/3p4/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3", -120, -3, -3},
-	{"r1bqkbnr/ppp1p1pp/2n5/3pPp2/8/5N2/PPPP1PPP/RNBQKB1R w KQkq f6 0 4", -71, 21, 10},
-	{"r1bqkbnr/ppp1p1pp/2n5/3pPp2/3N4/8/PPPP1PPP/RNBQKB1R b KQkq - 1 4", -160, 21, 10},
-	{"r7/1Pp5/2P3p1/8/6pb/4p1kB/4P1p1/6K1 w - - 0 1", -855, 46, 27},
+	{"r1bqkbnr/ppp1p1pp/2n5/3pPp2/8/5N2/PPPP1PPP/RNBQKB1R w KQkq f6 0 4", -65, 27, 14},
+	{"r1bqkbnr/ppp1p1pp/2n5/3pPp2/3N4/8/PPPP1PPP/RNBQKB1R b KQkq - 1 4", -141, 27, 14},
+	{"r7/1Pp5/2P3p1/8/6pb/4p1kB/4P1p1/6K1 w - - 0 1", -734, 76, 52},
 }
 
 func TestEvaluate(t *testing.T) {
@@ -43,13 +43,63 @@ func TestEvaluatePawns(t *testing.T) {
 			t.Parallel()
 			si := newSearchInfo(noTable{}, noPawnTable{})
 			pos := unsafeFEN(tt.fen)
-			mg, eg := si.evaluatePawns(pos)
+			mg, eg, _ := si.evaluatePawns(pos, nil)
 			assert.Equal(t, tt.mg, mg)
 			assert.Equal(t, tt.eg, eg)
 		})
 	}
 }
 
+func TestAttackUnits(t *testing.T) {
+	t.Parallel()
+	w := defaultEvalWeights
+	tests := []struct {
+		name string
+		ka   chess.KingAttack
+		want int
+	}{
+		{"no attack", chess.KingAttack{}, 0},
+		{"knight and queen", chess.KingAttack{Attackers: 2, Attacks: [6]int{0, 2, 0, 0, 3, 0}, SafeChecks: [6]int{0, 1, 0, 0, 0, 0}, WeakSquares: 2}, 23},
+		{"bounded", chess.KingAttack{Attackers: 4, Attacks: [6]int{0, 0, 0, 0, 30, 0}}, 99},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, w.attackUnits(tt.ka))
+		})
+	}
+}
+
+func TestIsUnstoppable(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		fen  string
+		sq   chess.Square
+		want bool
+	}{
+		{"outside the square", "7k/8/8/1P6/8/8/8/4K3 b - - 0 1", chess.B5, true},
+		{"inside the square", "4k3/8/8/1P6/8/8/8/4K3 b - - 0 1", chess.B5, false},
+		{"inside the square before moving", "5k2/8/8/1P6/8/8/8/4K3 b - - 0 1", chess.B5, false},
+		{"outside the square after moving", "5k2/8/8/1P6/8/8/8/4K3 w - - 0 1", chess.B5, true},
+		{"double push", "7k/8/8/8/8/8/1P6/4K3 w - - 0 1", chess.B2, true},
+		{"opponent pieces", "7k/6n1/8/1P6/8/8/8/4K3 b - - 0 1", chess.B5, false},
+		{"blocked path", "7k/1K6/8/1P6/8/8/8/8 b - - 0 1", chess.B5, false},
+		{"black pawn", "4K3/8/8/8/1p6/8/8/7k w - - 0 1", chess.B4, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pos := unsafeFEN(tt.fen)
+			c := pos.PieceAt(tt.sq).Color()
+			_, promotion := passedPath(c, tt.sq)
+			assert.Equal(t, tt.want, isUnstoppable(pos, c, tt.sq, promotion))
+		})
+	}
+}
+
 func BenchmarkEvaluate(b *testing.B) {
 	for _, bb := range testPositions {
 		b.Run(bb.fen, func(b *testing.B) {
@@ -0,0 +1,199 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leonhfr/orca/chess"
+	"github.com/leonhfr/orca/nnue"
+)
+
+// EvalTerm represents a term of the classic evaluation.
+type EvalTerm uint8
+
+const (
+	TermMaterial      EvalTerm = iota // TermMaterial is the material.
+	TermPieceSquare                   // TermPieceSquare is the piece-square tables.
+	TermMobility                      // TermMobility is the mobility of the pieces.
+	TermTrappedPieces                 // TermTrappedPieces is the penalty of the trapped pieces.
+	TermLostPieces                    // TermLostPieces is the penalty of the lost pieces.
+	TermRooks                         // TermRooks is the bonus of the rooks on the penultimate rank, on open files or connected.
+	TermOutposts                      // TermOutposts is the bonus of the knights and bishops on outposts.
+	TermBishopPair                    // TermBishopPair is the bonus of the bishop pair.
+	TermThreats                       // TermThreats is the penalty of the pieces attacked by pawns or lower value pieces, and of the hanging pieces.
+	TermDevelopment                   // TermDevelopment is the penalty of the early queen moves.
+	TermPawnStructure                 // TermPawnStructure is the doubled, isolated, backward, connected, candidate and passed pawns.
+	TermPassedPawns                   // TermPassedPawns is the passed pawns against the kings and the pieces.
+	TermKingSafety                    // TermKingSafety is the pawn shield, the open files and the attacks around the king.
+	TermTempo                         // TermTempo is the bonus of the side to move.
+	EvalTermCount                     // EvalTermCount is the number of terms.
+)
+
+// String implements the Stringer interface.
+func (term EvalTerm) String() string {
+	switch term {
+	case TermMaterial:
+		return "Material"
+	case TermPieceSquare:
+		return "Piece square"
+	case TermMobility:
+		return "Mobility"
+	case TermTrappedPieces:
+		return "Trapped pieces"
+	case TermLostPieces:
+		return "Lost pieces"
+	case TermRooks:
+		return "Rooks"
+	case TermOutposts:
+		return "Outposts"
+	case TermBishopPair:
+		return "Bishop pair"
+	case TermThreats:
+		return "Threats"
+	case TermDevelopment:
+		return "Development"
+	case TermPawnStructure:
+		return "Pawn structure"
+	case TermPassedPawns:
+		return "Passed pawns"
+	case TermKingSafety:
+		return "King safety"
+	case TermTempo:
+		return "Tempo"
+	default:
+		return "?"
+	}
+}
+
+// EvalScore holds the middle game and end game parts of a score.
+type EvalScore struct {
+	MG int32 // Middle game score.
+	EG int32 // End game score.
+}
+
+// Tapered returns the score tapered by the game phase.
+func (s EvalScore) Tapered(phase int32) int32 {
+	return taperedEval(s.MG, s.EG, phase)
+}
+
+// EvalTrace holds the breakdown of the evaluation of a position.
+type EvalTrace struct {
+	Terms       [EvalTermCount][2]EvalScore // Contribution of each side to the terms. Indexed by term and color.
+	Phase       int32                       // Game phase, from 24 in the opening to 0 in pawn endgames, zero when specialized.
+	Scale       int32                       // Scale factor of drawish endgames, out of 64.
+	Specialized bool                        // Whether a specialized endgame function scored the position, without terms.
+	NNUE        bool                        // Whether the score is the evaluation of the NNUE network.
+	Classic     int32                       // Score of the classic evaluation.
+	Score       int32                       // Score of the evaluation used by the search.
+	Turn        chess.Color                 // Side to move, the scores being from its point of view.
+}
+
+// Total returns the score of the term, from White's point of view.
+func (t EvalTrace) Total(term EvalTerm) EvalScore {
+	white, black := t.Terms[term][chess.White], t.Terms[term][chess.Black]
+	return EvalScore{MG: white.MG - black.MG, EG: white.EG - black.EG}
+}
+
+// add adds the contribution of the side to the term.
+//
+// A nil trace records nothing.
+func (t *EvalTrace) add(term EvalTerm, c chess.Color, mg, eg int32) {
+	if t != nil {
+		t.Terms[term][c].MG += mg
+		t.Terms[term][c].EG += eg
+	}
+}
+
+// setPhase records the game phase and the scale factor.
+func (t *EvalTrace) setPhase(phase, scale int32) {
+	if t != nil {
+		t.Phase, t.Scale = phase, scale
+	}
+}
+
+// setSpecialized records the use of a specialized endgame function.
+func (t *EvalTrace) setSpecialized() {
+	if t != nil {
+		t.Specialized = true
+	}
+}
+
+// Trace returns the breakdown of the evaluation of the position.
+//
+// The terms are those of the classic evaluation. When the NNUE network
+// is used, the score is the evaluation of the network, except for the endgames
+// scored by specialized functions.
+func (e *Engine) Trace(pos *chess.Position) EvalTrace {
+	_ = e.Init()
+
+	si := newSearchInfo(noTable{}, noPawnTable{})
+	si.weights = e.loadedEvalWeights()
+
+	t := EvalTrace{Scale: scaleNormal, Turn: pos.Turn()}
+	t.Classic = si.evaluateTrace(pos, &t)
+	t.Score = t.Classic
+
+	if network := e.loadedNetwork(); network != nil && !t.Specialized {
+		si.nnue = nnue.NewEvaluator(network)
+		si.nnue.Reset(pos)
+		t.NNUE = true
+		t.Score = si.evaluate(pos)
+	}
+
+	return t
+}
+
+// String returns the breakdown as a table of the contributions of each side
+// to the terms, followed by the scores from White's point of view.
+func (t EvalTrace) String() string {
+	var b strings.Builder
+	line := "----------------+-------------------------+-------------------------+-------------------------\n"
+
+	if !t.Specialized {
+		fmt.Fprintf(&b, "%15s | %23s | %23s | %23s\n", "Term", "White", "Black", "Total")
+		fmt.Fprintf(&b, "%15s | %7s %7s %7s | %7s %7s %7s | %7s %7s %7s\n", "", "MG", "EG", "Tapered", "MG", "EG", "Tapered", "MG", "EG", "Tapered")
+		b.WriteString(line)
+
+		var total [2]EvalScore
+		for term := range EvalTermCount {
+			white, black := t.Terms[term][chess.White], t.Terms[term][chess.Black]
+			fmt.Fprintf(&b, "%15s | %s | %s | %s\n", term, t.columns(white), t.columns(black), t.columns(t.Total(term)))
+
+			total[chess.White] = EvalScore{total[chess.White].MG + white.MG, total[chess.White].EG + white.EG}
+			total[chess.Black] = EvalScore{total[chess.Black].MG + black.MG, total[chess.Black].EG + black.EG}
+		}
+
+		b.WriteString(line)
+		sum := EvalScore{total[chess.White].MG - total[chess.Black].MG, total[chess.White].EG - total[chess.Black].EG}
+		fmt.Fprintf(&b, "%15s | %s | %s | %s\n\n", "Total", t.columns(total[chess.White]), t.columns(total[chess.Black]), t.columns(sum))
+	}
+
+	if t.Specialized {
+		b.WriteString("Specialized endgame evaluation\n")
+	} else {
+		fmt.Fprintf(&b, "Phase: %d/24\n", t.Phase)
+	}
+	if t.Scale != scaleNormal {
+		fmt.Fprintf(&b, "Scale: %d/%d\n", t.Scale, scaleNormal)
+	}
+	fmt.Fprintf(&b, "Classic evaluation: %+d (White side)\n", t.whiteSide(t.Classic))
+	if t.NNUE {
+		fmt.Fprintf(&b, "NNUE evaluation: %+d (White side)\n", t.whiteSide(t.Score))
+	}
+	fmt.Fprintf(&b, "Final evaluation: %+d (White side)", t.whiteSide(t.Score))
+
+	return b.String()
+}
+
+// columns formats the middle game, end game and tapered parts of a score.
+func (t EvalTrace) columns(s EvalScore) string {
+	return fmt.Sprintf("%7d %7d %7d", s.MG, s.EG, s.Tapered(t.Phase))
+}
+
+// whiteSide returns the score from White's point of view.
+func (t EvalTrace) whiteSide(score int32) int32 {
+	if t.Turn == chess.Black {
+		return -score
+	}
+	return score
+}
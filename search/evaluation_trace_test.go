@@ -0,0 +1,98 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+func TestEngine_Trace(t *testing.T) {
+	t.Parallel()
+	e := NewEngine()
+	require.NoError(t, e.Init())
+
+	for _, tt := range testPositions {
+		t.Run(tt.fen, func(t *testing.T) {
+			t.Parallel()
+			pos := unsafeFEN(tt.fen)
+			trace := e.Trace(pos)
+
+			assert.Equal(t, tt.score, trace.Classic)
+			assert.Equal(t, tt.score, trace.Score)
+			assert.False(t, trace.NNUE)
+			assert.False(t, trace.Specialized)
+
+			// the terms add up to the score
+			var sum EvalScore
+			for term := range EvalTermCount {
+				total := trace.Total(term)
+				sum.MG += total.MG
+				sum.EG += total.EG
+			}
+			if pos.Turn() == chess.Black {
+				sum = EvalScore{-sum.MG, -sum.EG}
+			}
+			assert.Equal(t, trace.Score, sum.Tapered(trace.Phase)*trace.Scale/scaleNormal)
+
+			assert.Equal(t, EvalScore{defaultEvalWeights.tempo, 0}, trace.Terms[TermTempo][pos.Turn()])
+			assert.Contains(t, trace.String(), "Pawn structure")
+		})
+	}
+}
+
+func TestEngine_Trace_EarlyQueen(t *testing.T) {
+	t.Parallel()
+	e := NewEngine()
+	require.NoError(t, e.Init())
+
+	tests := []struct {
+		name string
+		fen  string
+		want int32
+	}{
+		{"queen developed early", "rnbqkbnr/pppp1ppp/8/4p3/4P3/5Q2/PPPP1PPP/RNB1KBNR b HAha - 1 2", 4},
+		{"queen on its starting square", "rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b HAha - 1 2", 0},
+		{"promoted queen", "rnbqkbnQ/pppp1p1p/8/4p3/8/8/PPPP1PPP/RNBQKBNR b HAa - 0 5", 0},
+		{"chess960", "qbb1rkrn/1ppppppp/p7/7n/8/P2P4/1PPQPPPP/1BBRNKRN b GDgd - 0 9", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pos, err := chess.ShredderFEN{}.Decode(tt.fen)
+			require.NoError(t, err)
+
+			trace := e.Trace(pos)
+			want := tt.want * defaultEvalWeights.earlyQueenMG
+			assert.Equal(t, EvalScore{want, 0}, trace.Terms[TermDevelopment][chess.White])
+		})
+	}
+}
+
+func TestEngine_Trace_Specialized(t *testing.T) {
+	t.Parallel()
+	e := NewEngine(withTestNetwork(t))
+	require.NoError(t, e.Init())
+
+	trace := e.Trace(unsafeFEN("8/8/8/4k3/8/8/4P3/4K3 w - - 0 1"))
+	assert.True(t, trace.Specialized)
+	assert.False(t, trace.NNUE)
+	assert.Equal(t, [EvalTermCount][2]EvalScore{}, trace.Terms)
+	assert.Contains(t, trace.String(), "Specialized endgame evaluation")
+}
+
+func TestEngine_Trace_NNUE(t *testing.T) {
+	t.Parallel()
+	e := NewEngine(withTestNetwork(t))
+	require.NoError(t, e.Init())
+
+	pos := unsafeFEN("rnb1kbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1")
+	trace := e.Trace(pos)
+	assert.True(t, trace.NNUE)
+	assert.Less(t, trace.Classic, int32(-500))
+	assert.Less(t, trace.Score, int32(-500))
+	assert.Contains(t, trace.String(), "NNUE evaluation")
+}
@@ -0,0 +1,74 @@
+package search
+
+import "github.com/leonhfr/orca/chess"
+
+// historyTable contains the history heuristic scores of quiet moves,
+// indexed by moving piece and destination square.
+//
+// Quiet moves that caused a beta cutoff are rewarded proportionally to the
+// square of the remaining depth, so that they are tried earlier in other nodes.
+type historyTable struct {
+	entries [12][64]uint32
+}
+
+// newHistoryTable returns a new historyTable.
+func newHistoryTable() *historyTable {
+	return &historyTable{}
+}
+
+// get returns the history score of the move.
+func (ht *historyTable) get(move chess.Move) uint32 {
+	return ht.entries[move.P1()][move.S2()]
+}
+
+// inc rewards the move that caused a beta cutoff at the given depth.
+//
+// When a score reaches maxHistory, all scores are halved so that
+// recent cutoffs weigh more than older ones.
+func (ht *historyTable) inc(move chess.Move, depth uint8) {
+	entry := &ht.entries[move.P1()][move.S2()]
+	*entry += uint32(depth) * uint32(depth)
+
+	if *entry < maxHistory {
+		return
+	}
+
+	for p := range ht.entries {
+		for sq := range ht.entries[p] {
+			ht.entries[p][sq] /= 2
+		}
+	}
+}
+
+// maxHistory is the history score above which all scores are aged.
+const maxHistory = 1 << 20
+
+// counterList contains the countermoves, indexed by the piece and
+// destination square of the previous move.
+//
+// A countermove is a quiet move that caused a beta cutoff in response
+// to the previous move, independently of the depth.
+type counterList struct {
+	entries [12][64]chess.Move
+}
+
+// newCounterList returns a new counterList.
+func newCounterList() *counterList {
+	return &counterList{}
+}
+
+// get returns the countermove of the previous move.
+func (cl *counterList) get(previous chess.Move) chess.Move {
+	if previous == chess.NoMove {
+		return chess.NoMove
+	}
+	return cl.entries[previous.P1()][previous.S2()]
+}
+
+// set sets the countermove of the previous move.
+func (cl *counterList) set(previous, move chess.Move) {
+	if previous == chess.NoMove {
+		return
+	}
+	cl.entries[previous.P1()][previous.S2()] = move
+}
@@ -0,0 +1,45 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+func TestHistoryTable(t *testing.T) {
+	t.Parallel()
+	a := newMove(chess.G1, chess.F3, chess.WhiteKnight, chess.NoPiece)
+	b := newMove(chess.B1, chess.F3, chess.WhiteKnight, chess.NoPiece)
+	c := newMove(chess.G8, chess.F6, chess.BlackKnight, chess.NoPiece)
+
+	ht := newHistoryTable()
+	ht.inc(a, 3)
+	ht.inc(c, 2)
+
+	assert.Equal(t, uint32(9), ht.get(a))
+	assert.Equal(t, uint32(9), ht.get(b), "indexed by piece and destination square")
+	assert.Equal(t, uint32(4), ht.get(c))
+
+	ht.entries[chess.WhiteKnight][chess.F3] = maxHistory - 1
+	ht.inc(a, 1)
+
+	assert.Equal(t, uint32(maxHistory/2), ht.get(a))
+	assert.Equal(t, uint32(2), ht.get(c), "all scores are aged")
+}
+
+func TestCounterList(t *testing.T) {
+	t.Parallel()
+	previous := newMove(chess.E2, chess.E4, chess.WhitePawn, chess.NoPiece)
+	counter := newMove(chess.E7, chess.E5, chess.BlackPawn, chess.NoPiece)
+
+	cl := newCounterList()
+	assert.Equal(t, chess.NoMove, cl.get(previous))
+
+	cl.set(previous, counter)
+	assert.Equal(t, counter, cl.get(previous))
+
+	cl.set(chess.NoMove, counter)
+	assert.Equal(t, chess.NoMove, cl.get(chess.NoMove))
+}
@@ -0,0 +1,187 @@
+package search
+
+import "github.com/leonhfr/orca/chess"
+
+// stage represents a move picker stage.
+type stage uint8
+
+const (
+	stageHash             stage = iota // hash move from the transposition table
+	stageGenerateCaptures              // generate and score captures
+	stageGoodCaptures                  // captures that do not lose material
+	stageRefutations                   // killers and countermove
+	stageGenerateQuiets                // generate and score quiet moves
+	stageQuiets                        // quiet moves ordered by history
+	stageBadCaptures                   // captures that lose material
+	stageGenerateEvasions              // generate and score check evasions
+	stageEvasions                      // check evasions
+	stageDone                          // no more moves
+)
+
+// movePicker lazily generates and orders the pseudo moves of a position.
+//
+// Moves are generated in stages, so that nodes that cut off early
+// never generate the moves of the later stages:
+//
+//	stage              moves
+//	hash               best move from the transposition table
+//	good captures      captures and queen promotions ordered by SEE
+//	refutations        killer moves and countermove
+//	quiets             quiet moves ordered by history
+//	bad captures       captures that lose material
+//
+// When the king is in check, all evasions are generated and ordered at once.
+//
+//nolint:govet
+type movePicker struct {
+	pos         *chess.Position
+	list        *chess.MoveList
+	history     *historyTable
+	checkData   chess.CheckData
+	best        chess.Move
+	killers     [2]chess.Move
+	refutations [3]chess.Move
+	moves       []chess.Move
+	bad         []chess.Move
+	index       int
+	stage       stage
+}
+
+// newMovePicker returns a new movePicker.
+//
+// The moves are generated into the passed list, which must not be shared
+// with another move picker in use.
+func newMovePicker(pos *chess.Position, list *chess.MoveList, checkData chess.CheckData, best chess.Move, killers [2]chess.Move, counter chess.Move, history *historyTable) movePicker {
+	mp := movePicker{
+		pos:         pos,
+		list:        list,
+		history:     history,
+		checkData:   checkData,
+		best:        best,
+		killers:     killers,
+		refutations: [3]chess.Move{killers[0], killers[1], counter},
+	}
+
+	if checkData > 0 {
+		mp.stage = stageGenerateEvasions
+	}
+
+	return mp
+}
+
+// next returns the next pseudo move.
+//
+// Returns chess.NoMove when all moves have been returned.
+func (mp *movePicker) next() chess.Move {
+	for {
+		switch mp.stage {
+		case stageHash:
+			mp.stage++
+			if mp.best != chess.NoMove && mp.pos.IsPseudoLegal(mp.best) {
+				return mp.best.WithScore(0)
+			}
+		case stageGenerateCaptures:
+			mp.moves = mp.pos.AppendCaptureMoves(mp.list[:0])
+			scoreCaptures(mp.pos, mp.moves)
+			mp.index = 0
+			mp.stage++
+		case stageGoodCaptures:
+			for mp.index < len(mp.moves) {
+				nextOracle(mp.moves, mp.index)
+				move := mp.moves[mp.index]
+				if move.Score() < rankCapture {
+					mp.bad = mp.moves[mp.index:]
+					break
+				}
+				mp.index++
+				if sameMove(move, mp.best) {
+					continue
+				}
+				return move.WithScore(0)
+			}
+			mp.index = 0
+			mp.stage++
+		case stageRefutations:
+			for mp.index < len(mp.refutations) {
+				move := mp.refutations[mp.index]
+				mp.index++
+				if mp.isRefutation(move) {
+					return move.WithScore(0)
+				}
+			}
+			mp.stage++
+		case stageGenerateQuiets:
+			// quiets are appended after the captures so that bad captures stay valid
+			captures := len(mp.moves)
+			mp.moves = mp.pos.AppendQuietMoves(mp.moves)[captures:]
+			scoreQuiets(mp.moves, mp.history)
+			mp.index = 0
+			mp.stage++
+		case stageQuiets:
+			for mp.index < len(mp.moves) {
+				nextOracle(mp.moves, mp.index)
+				move := mp.moves[mp.index]
+				mp.index++
+				if sameMove(move, mp.best) ||
+					sameMove(move, mp.refutations[0]) ||
+					sameMove(move, mp.refutations[1]) ||
+					sameMove(move, mp.refutations[2]) {
+					continue
+				}
+				return move.WithScore(0)
+			}
+			mp.index = 0
+			mp.stage++
+		case stageBadCaptures:
+			for mp.index < len(mp.bad) {
+				nextOracle(mp.bad, mp.index)
+				move := mp.bad[mp.index]
+				mp.index++
+				if sameMove(move, mp.best) {
+					continue
+				}
+				return move.WithScore(0)
+			}
+			mp.stage = stageDone
+		case stageGenerateEvasions:
+			mp.moves = mp.pos.AppendEvasionMoves(mp.list[:0], mp.checkData)
+			scoreMoves(mp.pos, mp.moves, mp.best, mp.killers)
+			mp.index = 0
+			mp.stage++
+		case stageEvasions:
+			if mp.index < len(mp.moves) {
+				nextOracle(mp.moves, mp.index)
+				move := mp.moves[mp.index]
+				mp.index++
+				return move.WithScore(0)
+			}
+			mp.stage = stageDone
+		default:
+			return chess.NoMove
+		}
+	}
+}
+
+// isRefutation checks whether the move is a valid refutation that has not
+// been returned yet, either as the hash move or as a previous refutation.
+func (mp *movePicker) isRefutation(move chess.Move) bool {
+	if move == chess.NoMove || !move.HasTag(chess.Quiet) || sameMove(move, mp.best) {
+		return false
+	}
+
+	for _, previous := range mp.refutations[:mp.index-1] {
+		if sameMove(move, previous) {
+			return false
+		}
+	}
+
+	return mp.pos.IsPseudoLegal(move)
+}
+
+// sameMove checks whether both moves have the same origin and destination
+// squares and the same promotion.
+//
+// Informative tags and scores are ignored.
+func sameMove(a, b chess.Move) bool {
+	return a.S1() == b.S1() && a.S2() == b.S2() && a.Promo() == b.Promo()
+}
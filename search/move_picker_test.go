@@ -0,0 +1,103 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+func TestMovePicker(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		fen     string
+		best    string
+		killers [2]string
+		counter string
+		want    []string // expected first moves
+	}{
+		{
+			name: "captures before quiets",
+			fen:  "r1bqkbnr/ppp1pppp/2n5/3p4/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3",
+			want: []string{"e4d5"},
+		},
+		{
+			name:    "hash move first",
+			fen:     "r1bqkbnr/ppp1pppp/2n5/3p4/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3",
+			best:    "b1c3",
+			killers: [2]string{"h2h3", "a2a3"},
+			counter: "f1b5",
+			want:    []string{"b1c3", "e4d5", "h2h3", "a2a3", "f1b5"},
+		},
+		{
+			name:    "illegal refutations are skipped",
+			fen:     "r1bqkbnr/ppp1pppp/2n5/3p4/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3",
+			killers: [2]string{"e4e5", "f1d3"},
+			counter: "g1f3",
+			want:    []string{"e4d5", "e4e5", "f1d3"},
+		},
+		{
+			name: "bad captures last",
+			fen:  "r1b1kb1r/pppp1ppp/2n1pq2/8/3Pn2N/2P3P1/PP1NPP1P/R1BQKB1R b KQkq - 3 6",
+			want: []string{"e4d2", "f6f2"},
+		},
+		{
+			name: "evasions",
+			fen:  "4k3/8/8/8/1b6/8/2P5/4K3 w - - 0 1",
+			best: "c2c3",
+			want: []string{"c2c3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pos := unsafeFEN(tt.fen)
+			checkData, _ := pos.InCheck()
+			best := unsafeMove(pos, tt.best)
+			killers := [2]chess.Move{unsafeMove(pos, tt.killers[0]), unsafeMove(pos, tt.killers[1])}
+			counter := unsafeMove(pos, tt.counter)
+
+			mp := newMovePicker(pos, &chess.MoveList{}, checkData, best, killers, counter, newHistoryTable())
+			var got []string
+			for move := mp.next(); move != chess.NoMove; move = mp.next() {
+				got = append(got, move.String())
+			}
+
+			want := movesString(pos.PseudoMoves(checkData))
+			assert.ElementsMatch(t, want, got, "generates every pseudo move once")
+
+			if tt.name == "bad captures last" {
+				assert.Equal(t, tt.want[1], got[len(got)-1])
+				return
+			}
+			assert.Equal(t, tt.want, got[:len(tt.want)])
+		})
+	}
+}
+
+func BenchmarkMovePicker(b *testing.B) {
+	pos := unsafeFEN("r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10")
+	history := newHistoryTable()
+	list := &chess.MoveList{}
+	for n := 0; n < b.N; n++ {
+		mp := newMovePicker(pos, list, 0, chess.NoMove, [2]chess.Move{}, chess.NoMove, history)
+		for move := mp.next(); move != chess.NoMove; move = mp.next() {
+			_ = move
+		}
+	}
+}
+
+// unsafeMove returns a move without error checking, only meant for tests.
+func unsafeMove(pos *chess.Position, move string) chess.Move {
+	if move == "" {
+		return chess.NoMove
+	}
+	m, err := chess.NewMove(pos, move)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
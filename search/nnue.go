@@ -0,0 +1,90 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/leonhfr/orca/chess"
+	"github.com/leonhfr/orca/nnue"
+)
+
+// WithNNUE determines the use of the NNUE evaluation instead of the classic one.
+//
+// The network set by WithNNUEFile is loaded on the next call to Init.
+// The classic evaluation is used when no network file is set
+// or when the network could not be loaded.
+func WithNNUE(on bool) Option {
+	return func(e *Engine) {
+		e.networkMu.Lock()
+		defer e.networkMu.Unlock()
+		e.useNNUE = on
+		e.network, e.networkReady = nil, false
+	}
+}
+
+// WithNNUEFile sets the path of the NNUE network file.
+//
+// The network is loaded on the next call to Init when the NNUE evaluation is used.
+func WithNNUEFile(path string) Option {
+	return func(e *Engine) {
+		e.networkMu.Lock()
+		defer e.networkMu.Unlock()
+		e.nnueFile = path
+		e.network, e.networkReady = nil, false
+	}
+}
+
+var errMissingNetwork = errors.New("no network file, keeping the classic evaluation")
+
+// initNetwork loads the NNUE network if the options changed since the last call.
+//
+// Reports whether the evaluation changed.
+func (e *Engine) initNetwork() (bool, error) {
+	e.networkMu.Lock()
+	defer e.networkMu.Unlock()
+
+	if e.networkReady {
+		return false, nil
+	}
+
+	// the classic evaluation is kept when the network could not be loaded
+	e.networkReady = true
+
+	if !e.useNNUE {
+		return true, nil
+	}
+
+	if e.nnueFile == "" {
+		return true, fmt.Errorf("nnue: %w", errMissingNetwork)
+	}
+
+	network, err := nnue.Load(e.nnueFile)
+	if err != nil {
+		return true, fmt.Errorf("nnue: %w", err)
+	}
+
+	e.network = network
+	return true, nil
+}
+
+// loadedNetwork returns the loaded NNUE network, nil for the classic evaluation.
+func (e *Engine) loadedNetwork() *nnue.Network {
+	e.networkMu.Lock()
+	defer e.networkMu.Unlock()
+	return e.network
+}
+
+// useNetwork evaluates the positions searched from the position with the network,
+// the accumulators following the moves made on the position.
+//
+// The caller removes the move hook of the position at the end of the search.
+// A nil network keeps the classic evaluation.
+func (si *searchInfo) useNetwork(network *nnue.Network, pos *chess.Position) {
+	if network == nil {
+		return
+	}
+
+	si.nnue = nnue.NewEvaluator(network)
+	si.nnue.Reset(pos)
+	pos.SetMoveHook(si.nnue)
+}
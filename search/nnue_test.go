@@ -0,0 +1,126 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonhfr/orca/chess"
+	"github.com/leonhfr/orca/nnue"
+)
+
+// withTestNetwork evaluates the positions with a network whose piece-square term
+// is distilled from the default classic evaluation, tapered by the number of pieces.
+func withTestNetwork(t *testing.T) Option {
+	t.Helper()
+	network := nnue.NewNetwork()
+	params := DefaultEvalParams()
+	for bucket := range nnue.PSQTBuckets {
+		phase := min(max(int32(4*bucket+2)-8, 0), 24)
+		for pt := chess.Pawn; pt <= chess.King; pt++ {
+			for sq := chess.A1; sq <= chess.H8; sq++ {
+				rank, file := 7-int(sq.Rank()), int(sq.File())
+				mg := params.PieceValuesMG[pt] + params.PieceSquareMG[pt][rank][file]
+				eg := params.PieceValuesEG[pt] + params.PieceSquareEG[pt][rank][file]
+				network.SetPieceSquare(pt, sq, bucket, (mg*phase+eg*(24-phase))/24)
+			}
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "test.nnue")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, network.Write(f))
+	require.NoError(t, f.Close())
+
+	return func(e *Engine) {
+		WithNNUE(true)(e)
+		WithNNUEFile(path)(e)
+	}
+}
+
+func TestInitNetwork(t *testing.T) {
+	t.Parallel()
+	missing := filepath.Join(t.TempDir(), "missing.nnue")
+
+	tests := []struct {
+		name    string
+		options []Option
+		loaded  bool
+		err     bool
+	}{
+		{"classic", nil, false, false},
+		{"network file", []Option{withTestNetwork(t)}, true, false},
+		{"missing network file", []Option{WithNNUE(true)}, false, true},
+		{"missing file", []Option{WithNNUE(true), WithNNUEFile(missing)}, false, true},
+		{"file without nnue", []Option{WithNNUEFile(missing)}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			e := NewEngine(tt.options...)
+			err := e.Init()
+			defer e.Close()
+
+			if tt.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.loaded, e.loadedNetwork() != nil)
+
+			// errors are reported once
+			assert.NoError(t, e.Init())
+		})
+	}
+}
+
+func TestWithNNUE(t *testing.T) {
+	t.Parallel()
+	e := NewEngine(withTestNetwork(t))
+	require.NoError(t, e.Init())
+	assert.NotNil(t, e.loadedNetwork())
+
+	WithNNUE(false)(e)
+	require.NoError(t, e.Init())
+	assert.Nil(t, e.loadedNetwork())
+}
+
+func TestSearch_NNUE(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		fen  string
+		move string
+	}{
+		{"free queen", "rnb1kbnr/pppp1ppp/8/4p1q1/3P4/2N5/PPP1PPPP/R1BQKBNR w KQkq - 0 1", "c1g5"},
+		{"mate in 1", "8/8/8/5K1k/8/8/8/5R2 w - - 0 1", "f1h1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			engine := NewEngine(withTestNetwork(t))
+			require.NoError(t, engine.Init())
+			defer engine.Close()
+
+			pos := unsafeFEN(tt.fen)
+			fen := pos.String()
+
+			var last Output
+			for o := range engine.Search(context.Background(), pos, 4, 0) {
+				last = o
+			}
+
+			require.NotEmpty(t, last.PV)
+			assert.Equal(t, tt.move, last.PV[0].String())
+			// the search unmakes its moves
+			assert.Equal(t, fen, pos.String())
+		})
+	}
+}
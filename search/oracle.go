@@ -9,10 +9,26 @@ func scoreMoves(pos *chess.Position, moves []chess.Move, best chess.Move, killer
 	}
 }
 
-// quickScoreMoves quickly scores the moves.
-func quickScoreMoves(pos *chess.Position, moves []chess.Move) {
+// scoreCaptures scores the captures and queen promotions by SEE.
+//
+// Captures that lose material are scored below rankCapture.
+func scoreCaptures(pos *chess.Position, moves []chess.Move) {
 	for i, move := range moves {
-		moves[i] = move.WithScore(quickScore(pos, move))
+		var gain int
+		if move.HasTag(chess.Capture) {
+			gain = see(pos, move)
+		}
+		if promo := move.Promo(); promo != chess.NoPiece {
+			gain += values[promo.Type()] - values[chess.Pawn]
+		}
+		moves[i] = move.WithScore(uint32(rankCapture + gain))
+	}
+}
+
+// scoreQuiets scores the quiet moves by history.
+func scoreQuiets(moves []chess.Move, history *historyTable) {
+	for i, move := range moves {
+		moves[i] = move.WithScore(history.get(move))
 	}
 }
 
@@ -65,22 +81,6 @@ func score(pos *chess.Position, m, best chess.Move, killers [2]chess.Move) uint3
 	}
 }
 
-// quickScore scores the move without the best and killer moves.
-func quickScore(pos *chess.Position, m chess.Move) uint32 {
-	switch {
-	case m.HasTag(chess.HSideCastle):
-		return rankKingSideCastle
-	case m.HasTag(chess.ASideCastle):
-		return rankQueenSideCastle
-	case m.HasTag(chess.Promotion):
-		return promoRank[m.Promo()]
-	case m.HasTag(chess.Capture):
-		return uint32(rankCapture + see(pos, m))
-	default:
-		return rankQuiet
-	}
-}
-
 // rankSEE ranks the move by SEE.
 func rankSEE(pos *chess.Position, m chess.Move) uint32 {
 	return uint32(rankCapture + see(pos, m))
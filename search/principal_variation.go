@@ -15,13 +15,18 @@ func (si *searchInfo) principalVariation(ctx context.Context, pos *chess.Positio
 		si.nodes++
 	}
 
+	if si.isDraw(pos, index) {
+		return si.drawScore(pos), nil
+	}
+
 	meta := pos.Metadata()
 	hash := pos.Hash()
 	pawnHash := pos.PawnHash()
 
+	// root cutoffs could return a move excluded by the tablebases or the skill level
 	entry, inCache := si.table.get(hash)
-	if inCache && entry.depth() >= depth {
-		switch nt, score := entry.nodeType(), entry.score(); {
+	if inCache && entry.depth() >= depth && (index > 0 || si.rootMoves == nil && si.excluded == nil) {
+		switch nt, score := entry.nodeType(), si.tableScore(pos, entry); {
 		case nt == exact:
 			return score, nil
 		case nt == lowerBound && score > alpha:
@@ -37,7 +42,17 @@ func (si *searchInfo) principalVariation(ctx context.Context, pos *chess.Positio
 
 	if pos.HasInsufficientMaterial() {
 		si.table.set(hash, chess.NoMove, draw, exact, depth)
-		return draw, nil
+		return si.drawScore(pos), nil
+	}
+
+	if index > 0 && si.inTablebase(pos) {
+		if score, ok := si.probeTablebase(pos); ok {
+			si.table.set(hash, chess.NoMove, score, exact, maxSearchDepth)
+			if score == draw {
+				return si.drawScore(pos), nil
+			}
+			return score, nil
+		}
 	}
 
 	checkData, inCheck := pos.InCheck()
@@ -46,13 +61,16 @@ func (si *searchInfo) principalVariation(ctx context.Context, pos *chess.Positio
 	}
 
 	if depth == 0 {
-		return si.quiesce(ctx, pos, -beta, -alpha)
+		return si.quiesce(ctx, pos, alpha, beta, index)
 	}
 
 	if shouldNullMovePrune(pos, inCheck, depth) {
 		pos.MakeNullMove()
-		score, err := si.zeroWindow(ctx, pos, beta, depth-rNullMovePruning-1)
+		nullPly := si.nullPly
+		si.nullPly = index + 1
+		score, err := si.zeroWindow(ctx, pos, beta, depth-rNullMovePruning-1, index+1)
 		score = -score
+		si.nullPly = nullPly
 		pos.UnmakeNullMove(meta, hash)
 
 		if err != nil {
@@ -68,17 +86,18 @@ func (si *searchInfo) principalVariation(ctx context.Context, pos *chess.Positio
 	var best chess.Move
 	nt := upperBound
 
-	moves := pos.PseudoMoves(checkData)
-	scoreMoves(pos, moves, entry.best, si.killers.get(index))
-
-	for i, searchPv := 0, true; i < len(moves); i++ {
-		nextOracle(moves, i)
-		move := moves[i]
+	previous := si.previous(index)
+	mp := newMovePicker(pos, si.moveList(index), checkData, entry.best, si.killers.get(index), si.counters.get(previous), si.history)
 
+	for move, searchPv := mp.next(), true; move != chess.NoMove; move = mp.next() {
+		if si.skipRootMove(move, index) {
+			continue
+		}
 		if ok := pos.MakeMove(move); !ok {
 			continue
 		}
 		validMoves++
+		si.push(move, index)
 
 		var score int32
 		var err error
@@ -88,7 +107,7 @@ func (si *searchInfo) principalVariation(ctx context.Context, pos *chess.Positio
 			score = -score
 		} else {
 			lmr := lateMoveReduction(validMoves, inCheck, depth, move)
-			score, err = si.zeroWindow(ctx, pos, -alpha, depth-lmr-1)
+			score, err = si.zeroWindow(ctx, pos, -alpha, depth-lmr-1, index+1)
 			score = -score
 
 			if score > alpha && err == nil {
@@ -103,9 +122,15 @@ func (si *searchInfo) principalVariation(ctx context.Context, pos *chess.Positio
 			return 0, err
 		}
 
+		if index == 0 && score > alpha {
+			si.rootBest = move.WithScore(0)
+		}
+
 		if score >= beta {
 			if move.HasTag(chess.Quiet) {
 				si.killers.set(move, index)
+				si.history.inc(move, depth)
+				si.counters.set(previous, move)
 			}
 
 			beta = incMateDistance(beta)
@@ -127,7 +152,7 @@ func (si *searchInfo) principalVariation(ctx context.Context, pos *chess.Positio
 		return -mate, nil
 	case validMoves == 0:
 		si.table.set(hash, best, draw, exact, depth)
-		return draw, nil
+		return si.drawScore(pos), nil
 	default:
 		alpha = incMateDistance(alpha)
 		si.table.set(hash, best, alpha, nt, depth)
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPrincipalVariation(t *testing.T) {
@@ -27,6 +28,49 @@ func TestPrincipalVariation(t *testing.T) {
 	}
 }
 
+func TestPrincipalVariationAllocs(t *testing.T) {
+	for _, tt := range searchTestPositions {
+		t.Run(tt.name, func(t *testing.T) {
+			si := newSearchInfo(noTable{}, noPawnTable{})
+			pos := unsafeFEN(tt.fen)
+			ctx := context.Background()
+
+			allocs := testing.AllocsPerRun(1, func() {
+				_, _ = si.principalVariation(ctx, pos, -mate, mate, tt.depth, 0)
+			})
+
+			assert.Zero(t, allocs)
+		})
+	}
+}
+
+func TestPrincipalVariation_Horizon(t *testing.T) {
+	t.Parallel()
+	fen := "5r1k/4Qpq1/4p3/1p1p2P1/2p2P2/1p2P3/3P4/BK6 b - - 0 1"
+	tests := []struct {
+		name        string
+		alpha, beta int32
+	}{
+		{"full window", -mate, mate},
+		{"low window", -300, -200},
+		{"asymmetric window", 0, 100},
+		{"high window", 200, 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			si := newSearchInfo(noTable{}, noPawnTable{})
+			want, err := si.quiesce(context.Background(), unsafeFEN(fen), tt.alpha, tt.beta, 0)
+			require.NoError(t, err)
+
+			score, err := si.principalVariation(context.Background(), unsafeFEN(fen), tt.alpha, tt.beta, 0, 0)
+			require.NoError(t, err)
+			assert.Equal(t, want, score)
+		})
+	}
+}
+
 func BenchmarkPrincipalVariation(b *testing.B) {
 	for _, bb := range searchTestPositions {
 		b.Run(bb.name, func(b *testing.B) {
@@ -6,7 +6,7 @@ import (
 	"github.com/leonhfr/orca/chess"
 )
 
-func (si *searchInfo) quiesce(ctx context.Context, pos *chess.Position, alpha, beta int32) (int32, error) {
+func (si *searchInfo) quiesce(ctx context.Context, pos *chess.Position, alpha, beta int32, index uint8) (int32, error) {
 	select {
 	case <-ctx.Done():
 		return 0, context.Canceled
@@ -23,7 +23,7 @@ func (si *searchInfo) quiesce(ctx context.Context, pos *chess.Position, alpha, b
 		alpha = standPat
 	}
 
-	moves := pos.LoudMoves()
+	moves := pos.AppendLoudMoves(si.moveList(index)[:0])
 	scoreLoudMoves(pos, moves)
 
 	for i := range len(moves) {
@@ -34,7 +34,7 @@ func (si *searchInfo) quiesce(ctx context.Context, pos *chess.Position, alpha, b
 			continue
 		}
 
-		score, err := si.quiesce(ctx, pos, -beta, -alpha)
+		score, err := si.quiesce(ctx, pos, -beta, -alpha, index+1)
 
 		score = -score
 		pos.UnmakeMove(move, meta, hash, pawnHash)
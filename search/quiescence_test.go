@@ -25,22 +25,22 @@ func TestQuiescence(t *testing.T) {
 			name:   "horizon effect depth 4",
 			fen:    "5r1k/4Qpq1/4p3/1p1p2P1/2p2P2/1p2P3/3P4/BK6 b - - 0 1",
 			depth:  4,
-			result: quiescenceSearchTestResult{nodes: 4064, score: 4},
-			moves:  []string{"b3b2", "a1b2", "c4c3", "d2c3"},
+			result: quiescenceSearchTestResult{nodes: 3956, score: 0},
+			moves:  []string{"b3b2", "a1b2", "c4c3", "b2c3"},
 		},
 		{
 			name:   "horizon effect depth 5",
 			fen:    "5r1k/4Qpq1/4p3/1p1p2P1/2p2P2/1p2P3/3P4/BK6 b - - 0 1",
 			depth:  5,
-			result: quiescenceSearchTestResult{nodes: 17115, score: 3},
-			moves:  []string{"c4c3", "d2c3", "b3b2", "b1b2", "b5b4"},
+			result: quiescenceSearchTestResult{nodes: 18223, score: 3},
+			moves:  []string{"d5d4", "e3d4", "b3b2", "b1b2"},
 		},
 		{
 			name:   "horizon effect depth 6",
 			fen:    "5r1k/4Qpq1/4p3/1p1p2P1/2p2P2/1p2P3/3P4/BK6 b - - 0 1",
 			depth:  6,
-			result: quiescenceSearchTestResult{nodes: 16838, score: 0},
-			moves:  []string{"g7a1", "b1a1", "h8g8", "d2d3", "c4d3", "g5g6", "f8a8", "a1b1"},
+			result: quiescenceSearchTestResult{nodes: 56921, score: 3},
+			moves:  []string{"f7f6", "g5f6", "g7e7", "f6e7", "h8g8", "e7e8q"},
 		},
 	}
 
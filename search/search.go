@@ -5,14 +5,16 @@
 package search
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"math"
 	"math/rand"
+	"slices"
 	"sync"
 
 	"github.com/leonhfr/orca/chess"
-	"github.com/leonhfr/orca/data/books"
+	"github.com/leonhfr/orca/nnue"
+	"github.com/leonhfr/orca/syzygy"
 )
 
 const (
@@ -28,23 +30,54 @@ const (
 //
 //nolint:govet
 type Engine struct {
-	book      *chess.Book
-	killers   *killerList
-	once      sync.Once
-	ownBook   bool
-	tableSize int
-	table     transpositionTable
-	pawnTable transpositionPawnTable
+	books     []*chess.Book // opening books in priority order, nil until loaded
+	bookFiles []string
+	bookMu    sync.Mutex
+	// book moves played since the last learning, protected by bookMu
+	bookPlayed    []bookPlay
+	bookLearning  bool
+	book          bookOptions
+	skill         skillOptions
+	contempt      int
+	analyseMode   bool
+	history       []chess.Hash // hashes of the game positions before the searched position
+	killers       *killerList
+	once          sync.Once
+	ownBook       bool
+	tableSize     int
+	table         transpositionTable
+	pawnTableSize int
+	pawnTable     transpositionPawnTable
+	evalTableSize int
+	evalTable     transpositionEvalTable
+	syzygyPath    string
+	tablebase     *syzygy.Tablebase         // nil until loaded
+	tablebaseRefs map[*syzygy.Tablebase]int // number of searches probing each tablebase
+	tablebaseMu   sync.Mutex
+	evalFile      string
+	evalParams    *EvalParams  // weights set by WithEvalParams, nil for none
+	evalWeights   *evalWeights // nil until loaded
+	evalMu        sync.Mutex
+	useNNUE       bool
+	nnueFile      string
+	network       *nnue.Network // nil until loaded or for the classic evaluation
+	networkReady  bool          // whether the network options have been applied
+	networkMu     sync.Mutex
+	searchMu      sync.RWMutex // held for reading by the running searches
 }
 
 // NewEngine creates a new search engine.
 func NewEngine(options ...Option) *Engine {
 	e := &Engine{
-		book:      chess.NewBook(),
-		killers:   newKillerList(),
-		table:     noTable{},
-		pawnTable: noPawnTable{},
-		tableSize: 64,
+		killers:       newKillerList(),
+		table:         noTable{},
+		pawnTable:     noPawnTable{},
+		evalTable:     noEvalTable{},
+		tableSize:     64,
+		pawnTableSize: 1,
+		evalTableSize: 4,
+		book:          defaultBookOptions(),
+		skill:         defaultSkillOptions(),
 	}
 	for _, fn := range options {
 		fn(e)
@@ -56,12 +89,32 @@ func NewEngine(options ...Option) *Engine {
 type Option func(*Engine)
 
 // WithTableSize sets the size of the transposition table in MB.
+//
+// The table is reallocated on the next call to Init.
 func WithTableSize(size int) Option {
 	return func(e *Engine) {
 		e.tableSize = size
 	}
 }
 
+// WithPawnTableSize sets the size of the pawn transposition table in MB.
+//
+// The table is reallocated on the next call to Init.
+func WithPawnTableSize(size int) Option {
+	return func(e *Engine) {
+		e.pawnTableSize = size
+	}
+}
+
+// WithEvalTableSize sets the size of the evaluation transposition table in MB.
+//
+// The table is reallocated on the next call to Init.
+func WithEvalTableSize(size int) Option {
+	return func(e *Engine) {
+		e.evalTableSize = size
+	}
+}
+
 // WithOwnBook determines the use of the search engine's own opening book.
 func WithOwnBook(on bool) Option {
 	return func(e *Engine) {
@@ -69,38 +122,244 @@ func WithOwnBook(on bool) Option {
 	}
 }
 
+// WithBookFiles sets the paths of the Polyglot opening books, consulted in priority order.
+//
+// The books are loaded on the next call to Init. The embedded book
+// is used when no path is given or when no file could be loaded.
+func WithBookFiles(paths ...string) Option {
+	return func(e *Engine) {
+		e.bookMu.Lock()
+		defer e.bookMu.Unlock()
+		e.bookFiles = paths
+		e.closeBooks()
+	}
+}
+
+// WithBookLearning determines whether the opening book files learn from the games played.
+//
+// The book files are opened for writing on the next call to Init.
+// The results are learned with LearnBook, FinishGame or FinishGameWithResult.
+func WithBookLearning(on bool) Option {
+	return func(e *Engine) {
+		e.bookMu.Lock()
+		defer e.bookMu.Unlock()
+		e.bookLearning = on
+		e.closeBooks()
+	}
+}
+
+// WithBookPolicy sets the selection policy of the book moves.
+func WithBookPolicy(policy BookPolicy) Option {
+	return func(e *Engine) {
+		e.book.policy = policy
+	}
+}
+
+// WithBookTemperature sets the temperature in percent of the weighted book policy.
+//
+// At 100, moves are selected with probabilities proportional to their weights.
+// Lower temperatures favor the best weighted moves, higher ones flatten the distribution.
+func WithBookTemperature(temperature int) Option {
+	return func(e *Engine) {
+		e.book.temperature = temperature
+	}
+}
+
+// WithBookThreshold sets the weight threshold of the uniform book policy,
+// in percent of the best weight.
+func WithBookThreshold(threshold int) Option {
+	return func(e *Engine) {
+		e.book.threshold = threshold
+	}
+}
+
+// WithBookDepth sets the last full move at which the opening book is used.
+func WithBookDepth(depth int) Option {
+	return func(e *Engine) {
+		e.book.depth = depth
+	}
+}
+
+// WithBookVariety sets the number of best weighted book moves considered.
+//
+// Zero considers all the book moves.
+func WithBookVariety(variety int) Option {
+	return func(e *Engine) {
+		e.book.variety = variety
+	}
+}
+
+// WithBookVerification sets the depth of the search verifying the book moves.
+//
+// Book moves scoring well below the best move are skipped. Zero disables verification.
+func WithBookVerification(depth int) Option {
+	return func(e *Engine) {
+		e.book.verify = depth
+	}
+}
+
+// WithSkillLevel sets the skill level, from 0 for the weakest to 20 for the full strength.
+func WithSkillLevel(level int) Option {
+	return func(e *Engine) {
+		e.skill.level = level
+	}
+}
+
+// WithLimitStrength determines whether the strength is limited to the Elo rating
+// set by WithElo, in place of the skill level.
+func WithLimitStrength(on bool) Option {
+	return func(e *Engine) {
+		e.skill.limit = on
+	}
+}
+
+// WithElo sets the Elo rating of the limited strength, from MinElo to MaxElo.
+func WithElo(elo int) Option {
+	return func(e *Engine) {
+		e.skill.elo = elo
+	}
+}
+
+// WithContempt sets the contempt in centipawns.
+//
+// A positive contempt scores draws below equality for the engine, which avoids them,
+// a negative one scores them above equality.
+func WithContempt(contempt int) Option {
+	return func(e *Engine) {
+		e.contempt = contempt
+	}
+}
+
+// WithAnalyseMode determines whether the search engine analyses positions
+// rather than plays games, which disables the opening book and the contempt.
+func WithAnalyseMode(on bool) Option {
+	return func(e *Engine) {
+		e.analyseMode = on
+	}
+}
+
+// AnalyseMode returns whether the search engine analyses positions rather than plays games.
+func (e *Engine) AnalyseMode() bool {
+	return e.analyseMode
+}
+
+// SetHistory sets the hashes of the game positions played before the position
+// searched next, from the oldest to the latest, to detect repetitions.
+func (e *Engine) SetHistory(hashes []chess.Hash) {
+	e.history = slices.Clone(hashes)
+}
+
 // Init initializes the search engine.
+//
+// The tables are reallocated when their size changed, and the cached
+// evaluations discarded when the evaluation changed, once the running
+// searches are done.
+//
+// Returns the errors of the opening book files, of the tablebases,
+// of the evaluation weights and of the NNUE network that could not be loaded.
 func (e *Engine) Init() error {
-	var err error
 	e.once.Do(func() {
-		performance := bytes.NewReader(books.Performance)
-		err = e.book.Init(performance)
 		e.killers = newKillerList()
 		e.table = newArrayTable(e.tableSize)
-		e.pawnTable = newArrayPawnTable(8)
+		e.pawnTable = newArrayPawnTable(e.pawnTableSize)
+		e.evalTable = newArrayEvalTable(e.evalTableSize)
 	})
-	return err
+
+	booksErr, tablebaseErr := e.initBooks(), e.initTablebase()
+	weightsChanged, weightsErr := e.initEvalWeights()
+	networkChanged, networkErr := e.initNetwork()
+	// the cached evaluations may come from another evaluation
+	e.initTables(weightsChanged, weightsChanged || networkChanged)
+
+	return errors.Join(booksErr, tablebaseErr, weightsErr, networkErr)
+}
+
+// initTables reallocates the tables whose size changed or whose content
+// must be discarded.
+//
+// The running searches are not waited for when nothing changed.
+func (e *Engine) initTables(resetPawn, resetEval bool) {
+	e.searchMu.RLock()
+	changed := resetPawn || resetEval || e.tablesResized()
+	e.searchMu.RUnlock()
+	if !changed {
+		return
+	}
+
+	e.searchMu.Lock()
+	defer e.searchMu.Unlock()
+
+	if table, ok := e.table.(*arrayTable); ok && table.size != e.tableSize {
+		table.close()
+		e.table = newArrayTable(e.tableSize)
+	}
+	if table, ok := e.pawnTable.(*arrayPawnTable); ok && (resetPawn || table.size != e.pawnTableSize) {
+		table.close()
+		e.pawnTable = newArrayPawnTable(e.pawnTableSize)
+	}
+	if table, ok := e.evalTable.(*arrayEvalTable); ok && (resetEval || table.size != e.evalTableSize) {
+		table.close()
+		e.evalTable = newArrayEvalTable(e.evalTableSize)
+	}
+}
+
+// tablesResized reports whether the size of a table changed.
+func (e *Engine) tablesResized() bool {
+	if table, ok := e.table.(*arrayTable); ok && table.size != e.tableSize {
+		return true
+	}
+	if table, ok := e.pawnTable.(*arrayPawnTable); ok && table.size != e.pawnTableSize {
+		return true
+	}
+	table, ok := e.evalTable.(*arrayEvalTable)
+	return ok && table.size != e.evalTableSize
 }
 
 // Close shuts down the resources used by the search engine.
+//
+// Waits for the running searches to finish, which requires their outputs
+// to be read until closed.
 func (e *Engine) Close() {
 	_ = e.Init()
+
+	// the tables are released once the running searches are done
+	e.searchMu.Lock()
+	defer e.searchMu.Unlock()
+
 	e.table.close()
 	e.pawnTable.close()
+	e.evalTable.close()
+
+	e.bookMu.Lock()
+	defer e.bookMu.Unlock()
+	e.closeBooks()
+
+	e.tablebaseMu.Lock()
+	defer e.tablebaseMu.Unlock()
+	e.closeTablebase()
 }
 
 // Search runs a search on the given position until the given depth.
-// Cancelling the context stops the search.
+// Cancelling the context stops the search after its first iteration.
+//
+// The positions repeating the game positions set by SetHistory are draws.
+//
+// In analysis mode, the opening book is not used.
+//
+// When the strength is limited, the depth and the nodes are capped
+// and the last output holds the move picked at the skill level.
 func (e *Engine) Search(ctx context.Context, pos *chess.Position, maxDepth, maxNodes int) <-chan Output {
 	_ = e.Init()
 	output := make(chan Output)
+	history := e.history
 
+	e.searchMu.RLock()
 	go func() {
+		defer e.searchMu.RUnlock()
 		defer close(output)
 
-		if e.ownBook {
-			moves := e.book.Lookup(pos)
-			if move := weightedRandomMove(moves); move != chess.NoMove {
+		if e.ownBook && !e.analyseMode {
+			if move := e.bookMove(ctx, pos); move != chess.NoMove {
 				output <- Output{
 					PV:    []chess.Move{move},
 					Depth: 1,
@@ -112,7 +371,7 @@ func (e *Engine) Search(ctx context.Context, pos *chess.Position, maxDepth, maxN
 		}
 
 		e.table.inc()
-		e.iterativeSearch(ctx, pos, maxDepth, maxNodes, output)
+		e.iterativeSearch(ctx, pos, history, maxDepth, maxNodes, output)
 	}()
 
 	return output
@@ -120,33 +379,96 @@ func (e *Engine) Search(ctx context.Context, pos *chess.Position, maxDepth, maxN
 
 // Output holds a search output.
 type Output struct {
-	PV    []chess.Move // Principal variation, best line found.
-	Depth int          // Search depth in plies.
-	Nodes int          // Number of nodes searched.
-	Score int          // Score from the engine's point of view in centipawns.
-	Mate  int          // Number of moves before mate. Positive for the current player to mate, negative for the current player to be mated.
+	PV     []chess.Move // Principal variation, best line found.
+	Depth  int          // Search depth in plies.
+	Nodes  int          // Number of nodes searched.
+	TBHits int          // Number of tablebase hits.
+	Score  int          // Score from the engine's point of view in centipawns.
+	Mate   int          // Number of moves before mate. Positive for the current player to mate, negative for the current player to be mated.
 }
 
 // searchInfo contains info on the running search.
 type searchInfo struct {
 	killers   *killerList
+	history   *historyTable
+	counters  *counterList
 	table     transpositionTable
 	pawnTable transpositionPawnTable
+	evalTable transpositionEvalTable
+	noise     int32  // amplitude of the evaluation noise, 0 for none
+	seed      uint64 // seed of the evaluation noise
+	weights   *evalWeights
+	nnue      *nnue.Evaluator               // nil for the classic evaluation
+	tablebase *syzygy.Tablebase             // nil when no tablebase is loaded
+	rootMoves []chess.Move                  // root moves allowed by the tablebases, nil for all
+	excluded  []chess.Move                  // root moves excluded by the skill level, nil for none
+	rootBest  chess.Move                    // best root move of the last search, NoMove for none
+	game      []chess.Hash                  // hashes of the game positions before the root
+	rootColor chess.Color                   // color of the side to move at the root
+	contempt  int32                         // draw score in centipawns below equality for the root color
+	nullPly   uint8                         // ply following the last null move of the search, 0 for none
+	keys      [math.MaxUint8 + 1]chess.Hash // hashes of the positions searched at each ply
+	stack     [maxSearchDepth]chess.Move
+	moves     [math.MaxUint8 + 1]chess.MoveList
 	nodes     uint32
+	tbHits    uint32
 }
 
 // newSearchInfo returns a new searchInfo.
 func newSearchInfo(table transpositionTable, pawnTable transpositionPawnTable) *searchInfo {
 	return &searchInfo{
 		killers:   newKillerList(),
+		history:   newHistoryTable(),
+		counters:  newCounterList(),
 		table:     table,
 		pawnTable: pawnTable,
+		evalTable: noEvalTable{},
+		weights:   defaultEvalWeights,
+	}
+}
+
+// push records the move played at the given ply.
+func (si *searchInfo) push(move chess.Move, index uint8) {
+	if index < maxSearchDepth {
+		si.stack[index] = move
+	}
+}
+
+// previous returns the move that led to the node at the given ply.
+func (si *searchInfo) previous(index uint8) chess.Move {
+	if index == 0 || index > maxSearchDepth {
+		return chess.NoMove
 	}
+	return si.stack[index-1]
+}
+
+// moveList returns the move list of the given ply.
+//
+// Each ply owns its list so that move generation does not allocate.
+func (si *searchInfo) moveList(index uint8) *chess.MoveList {
+	return &si.moves[index]
 }
 
 // iterativeSearch performs an iterative search.
-func (e *Engine) iterativeSearch(ctx context.Context, pos *chess.Position, maxDepth, maxNodes int, output chan<- Output) {
+func (e *Engine) iterativeSearch(ctx context.Context, pos *chess.Position, history []chess.Hash, maxDepth, maxNodes int, output chan<- Output) {
 	si := newSearchInfo(e.table, e.pawnTable)
+	si.evalTable = e.evalTable
+	si.game = history
+	si.rootColor = pos.Turn()
+	if !e.analyseMode {
+		si.contempt = int32(e.contempt)
+	}
+	si.weights = e.loadedEvalWeights()
+	si.tablebase = e.acquireTablebase()
+	defer e.releaseTablebase(si.tablebase)
+	si.useNetwork(e.loadedNetwork(), pos)
+	defer pos.SetMoveHook(nil)
+	si.setRootMoves(pos)
+
+	if e.skill.enabled() {
+		maxDepth, maxNodes = e.skill.limits(maxDepth, maxNodes)
+		si.noise, si.seed = e.skill.noise(), rand.Uint64() //nolint:gosec
+	}
 
 	if maxDepth <= 0 || maxDepth > maxSearchDepth {
 		maxDepth = maxSearchDepth
@@ -156,49 +478,62 @@ func (e *Engine) iterativeSearch(ctx context.Context, pos *chess.Position, maxDe
 		maxNodes = math.MaxInt
 	}
 
+	// best root moves of the last completed iteration when the strength is limited
+	var skillMoves []rootMove
+	var skillDepth int
+
 	for depth := 1; depth <= maxDepth; depth++ {
-		score, err := si.principalVariation(ctx, pos, -mate, mate, uint8(depth), 0)
+		// the first iteration is not cancelled so that a move is always returned
+		iterCtx := ctx
+		if depth == 1 {
+			iterCtx = context.WithoutCancel(ctx)
+		}
+
+		score, err := si.principalVariation(iterCtx, pos, -mate, mate, uint8(depth), 0)
 		if err != nil {
-			return
+			break
 		}
 
 		pv := e.table.principalVariation(pos)
 
+		// the output of the iteration is sent even if the skill level search is cancelled
+		if e.skill.enabled() && len(pv) > 0 {
+			var moves []rootMove
+			moves, err = si.bestRootMoves(iterCtx, pos, rootMove{pv[0].WithScore(0), score}, uint8(depth))
+			if err == nil {
+				skillMoves, skillDepth = moves, depth
+			}
+		}
+
 		maxDepth := max(depth, len(pv))
 
 		nodes := int(si.nodes)
 
 		output <- Output{
-			Depth: maxDepth,
-			Score: int(score),
-			Nodes: nodes,
-			Mate:  int(mateIn(score)),
-			PV:    pv,
+			Depth:  maxDepth,
+			Score:  int(score),
+			Nodes:  nodes,
+			TBHits: int(si.tbHits),
+			Mate:   int(mateIn(score)),
+			PV:     pv,
 		}
 
-		if nodes >= maxNodes {
+		if err != nil || nodes >= maxNodes {
 			break
 		}
 	}
-}
 
-// weightedRandomMove randomly selects a move with weighted probabilities.
-func weightedRandomMove(moves []chess.WeightedMove) chess.Move {
-	var sum int
-	for _, move := range moves {
-		sum += move.Weight
-	}
-	if sum <= 0 {
-		return chess.NoMove
-	}
-	index := rand.Intn(sum) //nolint:gosec
-	for _, move := range moves {
-		if index < move.Weight {
-			return move.Move
+	if len(skillMoves) > 0 {
+		rm := skillMoves[pickSkillMove(skillMoves, e.skill.skillLevel(), rand.Intn)] //nolint:gosec
+		output <- Output{
+			Depth:  skillDepth,
+			Score:  int(rm.score),
+			Nodes:  int(si.nodes),
+			TBHits: int(si.tbHits),
+			Mate:   int(mateIn(rm.score)),
+			PV:     []chess.Move{rm.move},
 		}
-		index -= move.Weight
 	}
-	return chess.NoMove
 }
 
 // mateIn returns the number of moves before mate.
@@ -24,6 +24,18 @@ func TestWithTableSize(t *testing.T) {
 	assert.Equal(t, 128, e.tableSize)
 }
 
+func TestWithPawnTableSize(t *testing.T) {
+	t.Parallel()
+	e := NewEngine(WithPawnTableSize(2))
+	assert.Equal(t, 2, e.pawnTableSize)
+}
+
+func TestWithEvalTableSize(t *testing.T) {
+	t.Parallel()
+	e := NewEngine(WithEvalTableSize(8))
+	assert.Equal(t, 8, e.evalTableSize)
+}
+
 func TestWithOwnBook(t *testing.T) {
 	t.Parallel()
 	e := NewEngine(WithOwnBook(true))
@@ -39,6 +51,82 @@ func TestInit(t *testing.T) {
 
 	require.NoError(t, err)
 	assert.IsType(t, &arrayTable{}, engine.table)
+	assert.IsType(t, &arrayPawnTable{}, engine.pawnTable)
+	assert.IsType(t, &arrayEvalTable{}, engine.evalTable)
+}
+
+func TestClose_DuringSearch(t *testing.T) {
+	t.Parallel()
+	engine := NewEngine()
+	require.NoError(t, engine.Init())
+
+	pos := unsafeFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	output := engine.Search(context.Background(), pos, 64, 200_000)
+	<-output
+
+	closed := make(chan struct{})
+	go func() {
+		engine.Close()
+		close(closed)
+	}()
+
+	// the search runs until its node budget is spent before the tables are released
+	var last Output
+	for o := range output {
+		last = o
+	}
+	<-closed
+
+	assert.GreaterOrEqual(t, last.Nodes, 100_000)
+	assert.Nil(t, engine.evalTable.(*arrayEvalTable).table)
+}
+
+func TestInit_TableSize(t *testing.T) {
+	t.Parallel()
+	engine := NewEngine(WithTableSize(1), WithPawnTableSize(1), WithEvalTableSize(1))
+	require.NoError(t, engine.Init())
+	table, pawnTable, evalTable := engine.table, engine.pawnTable, engine.evalTable
+
+	require.NoError(t, engine.Init())
+	assert.Same(t, table, engine.table)
+	assert.Same(t, pawnTable, engine.pawnTable)
+	assert.Same(t, evalTable, engine.evalTable)
+
+	for _, fn := range []Option{WithTableSize(2), WithPawnTableSize(2), WithEvalTableSize(2)} {
+		fn(engine)
+	}
+	require.NoError(t, engine.Init())
+	assert.Equal(t, newArrayTable(2).length, engine.table.(*arrayTable).length)
+	assert.Equal(t, newArrayPawnTable(2).length, engine.pawnTable.(*arrayPawnTable).length)
+	assert.Equal(t, newArrayEvalTable(2).length, engine.evalTable.(*arrayEvalTable).length)
+}
+
+func TestInit_DuringSearch(t *testing.T) {
+	t.Parallel()
+	engine := NewEngine()
+	require.NoError(t, engine.Init())
+	pawnTable, evalTable := engine.pawnTable, engine.evalTable
+
+	pos := unsafeFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	output := engine.Search(context.Background(), pos, 64, 200_000)
+	<-output
+
+	WithEvalParams(DefaultEvalParams())(engine)
+	initialized := make(chan error)
+	go func() {
+		initialized <- engine.Init()
+	}()
+
+	// the cached evaluations are discarded once the search is done
+	var last Output
+	for o := range output {
+		last = o
+	}
+	require.NoError(t, <-initialized)
+
+	assert.GreaterOrEqual(t, last.Nodes, 100_000)
+	assert.NotSame(t, pawnTable, engine.pawnTable)
+	assert.NotSame(t, evalTable, engine.evalTable)
 }
 
 func TestSearch(t *testing.T) {
@@ -49,6 +137,7 @@ func TestSearch(t *testing.T) {
 		depth   int
 		nodes   int
 		book    bool
+		analyse bool
 		outputs []Output
 	}{
 		{
@@ -56,8 +145,8 @@ func TestSearch(t *testing.T) {
 			fen:   "r1b1kb1r/pppp1ppp/2n1pq2/8/3Pn2N/2P3P1/PP1NPP1P/R1BQKB1R b KQkq - 3 6",
 			depth: 2,
 			outputs: []Output{
-				{Depth: 1, Nodes: 91, Score: mate - 1, Mate: 1, PV: []chess.Move{0xd206c1836d}},
-				{Depth: 2, Nodes: 338, Score: mate - 1, Mate: 1, PV: []chess.Move{0xd206c1836d}},
+				{Depth: 1, Nodes: 323, Score: mate - 1, Mate: 1, PV: []chess.Move{0x6c1836d}},
+				{Depth: 2, Nodes: 1466, Score: mate - 1, Mate: 1, PV: []chess.Move{0x6c1836d}},
 			},
 		},
 		{
@@ -65,8 +154,8 @@ func TestSearch(t *testing.T) {
 			fen:   "rnbqkbnr/ppp2ppp/4p3/3p4/2PP4/5N2/PP2PPPP/RNBQKB1R b KQkq - 1 3",
 			depth: 2,
 			outputs: []Output{
-				{Depth: 1, Nodes: 115, Score: 0, Mate: 0, PV: []chess.Move{0x13602c106a3}},
-				{Depth: 2, Nodes: 924, Score: 5, Mate: 0, PV: []chess.Move{0x6401cc2ab9, 0x12c02c018da}},
+				{Depth: 1, Nodes: 89, Score: 61, Mate: 0, PV: []chess.Move{0x1cc2ab9}},
+				{Depth: 2, Nodes: 1406, Score: -12, Mate: 0, PV: []chess.Move{0x1cc2ab9, 0x1cc5982}},
 			},
 		},
 		{
@@ -76,15 +165,27 @@ func TestSearch(t *testing.T) {
 			book:    true,
 			outputs: []Output{{PV: []chess.Move{0x1cc2b7e}, Depth: 1, Nodes: 1, Score: 0, Mate: 0}},
 		},
+		{
+			name:    "lasker trap with opening book in analyse mode",
+			fen:     "rnbqkbnr/ppp2ppp/4p3/3p4/2PP4/5N2/PP2PPPP/RNBQKB1R b KQkq - 1 3",
+			depth:   2,
+			book:    true,
+			analyse: true,
+			outputs: []Output{
+				{Depth: 1, Nodes: 89, Score: 61, Mate: 0, PV: []chess.Move{0x1cc2ab9}},
+				{Depth: 2, Nodes: 1406, Score: -12, Mate: 0, PV: []chess.Move{0x1cc2ab9, 0x1cc5982}},
+			},
+		},
 		{
 			name:  "nodes limit",
 			fen:   "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
 			nodes: 16384,
 			depth: 5,
 			outputs: []Output{
-				{Depth: 1, Nodes: 747, Score: 5, Mate: 0, PV: []chess.Move{0x6401cc38d2}},
-				{Depth: 2, Nodes: 5805, Score: 23, Mate: 0, PV: []chess.Move{0x6401cc38d2, 0x12c02c328ed}},
-				{Depth: 3, Nodes: 19798, Score: 25, Mate: 0, PV: []chess.Move{0x6401cc38d2, 0x12c02c328ed, 0x19002c85d26}},
+				{Depth: 1, Nodes: 266, Score: 88, Mate: 0, PV: []chess.Move{0x1cc38d2}},
+				{Depth: 2, Nodes: 2235, Score: 123, Mate: 0, PV: []chess.Move{0x1cc38d2, 0x1cc8ef4}},
+				{Depth: 3, Nodes: 9399, Score: 162, Mate: 0, PV: []chess.Move{0x1cc38d2, 0x1cc8ef4, 0x1cc15cf}},
+				{Depth: 4, Nodes: 26248, Score: 132, Mate: 0, PV: []chess.Move{0x1cc38d2, 0x1cc8ef4, 0x1cc92cc, 0x1cc0871}},
 			},
 		},
 	}
@@ -97,6 +198,7 @@ func TestSearch(t *testing.T) {
 			if tt.book {
 				engine.ownBook = true
 			}
+			engine.analyseMode = tt.analyse
 			engine.table = newHashMapTable()
 			engine.pawnTable = noPawnTable{}
 			output := engine.Search(context.Background(), unsafeFEN(tt.fen), tt.depth, tt.nodes)
@@ -113,7 +215,7 @@ func TestSearch(t *testing.T) {
 func TestCachedSearch(t *testing.T) {
 	t.Parallel()
 	fen := "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10"
-	depth := 8
+	depth := 7
 
 	tests := []struct {
 		name   string
@@ -124,28 +226,26 @@ func TestCachedSearch(t *testing.T) {
 			"not cached",
 			false,
 			[]Output{
-				{Depth: 1, Nodes: 747, Score: 5, Mate: 0, PV: []chess.Move{0x6401cc38d2}},
-				{Depth: 2, Nodes: 5805, Score: 23, Mate: 0, PV: []chess.Move{0x6401cc38d2, 0x12c02c328ed}},
-				{Depth: 3, Nodes: 19798, Score: 25, Mate: 0, PV: []chess.Move{0x6401cc38d2, 0x12c02c328ed, 0x19002c85d26}},
-				{Depth: 4, Nodes: 59913, Score: 4, Mate: 0, PV: []chess.Move{0x12702c25b66, 0x14f02c50b76, 0x6401cc15cf, 0x6401cc26ea}},
-				{Depth: 5, Nodes: 184981, Score: 3, Mate: 0, PV: []chess.Move{0x12702c25b66, 0x14f02c50b76, 0x6401cc1649, 0x12702c3455e, 0x14f02c4954c}},
-				{Depth: 6, Nodes: 634537, Score: 3, Mate: 0, PV: []chess.Move{0x12702c25b66, 0x14f02c50b76, 0x6401cc1649, 0x12702c3455e, 0x14f02c4954c}},
-				{Depth: 7, Nodes: 3300539, Score: 1, Mate: 0, PV: []chess.Move{0x12702c25b66, 0x14f02c58b74, 0x6401cc38d2, 0x6401cc2e6a, 0x19006c83b63, 0x14a02c30b76, 0x11802c03915}},
-				{Depth: 8, Nodes: 18846865, Score: 1, Mate: 0, PV: []chess.Move{0x6401cc38d2, 0x12c02c328ed, 0x19002c85d26, 0x14f02c52d23, 0x11302c05a1a, 0x14f02c50a31, 0x6401cc92cc}},
+				{Depth: 1, Nodes: 266, Score: 88, Mate: 0, PV: []chess.Move{0x1cc38d2}},
+				{Depth: 2, Nodes: 2235, Score: 123, Mate: 0, PV: []chess.Move{0x1cc38d2, 0x1cc8ef4}},
+				{Depth: 3, Nodes: 9399, Score: 162, Mate: 0, PV: []chess.Move{0x1cc38d2, 0x1cc8ef4, 0x1cc15cf}},
+				{Depth: 4, Nodes: 26248, Score: 132, Mate: 0, PV: []chess.Move{0x1cc38d2, 0x1cc8ef4, 0x1cc92cc, 0x1cc0871}},
+				{Depth: 5, Nodes: 85537, Score: 132, Mate: 0, PV: []chess.Move{0x1cc38d2, 0x1cc8ef4, 0x1cc92cc, 0x1cc0871}},
+				{Depth: 6, Nodes: 537679, Score: 47, Mate: 0, PV: []chess.Move{0x1cc38d2, 0x1cc8cf4, 0x1cc148a, 0x2c328ed, 0x2c258da}},
+				{Depth: 7, Nodes: 3353079, Score: 3, Mate: 0, PV: []chess.Move{0x1cc38d2, 0x1cc8cf4, 0x1cc1649, 0x2c328ed, 0x2c258da, 0x1cc26ea, 0x1cc92cc}},
 			},
 		},
 		{
 			"cached",
 			true,
 			[]Output{
-				{Depth: 1, Nodes: 747, Score: 5, Mate: 0, PV: []chess.Move{0x6401cc38d2}},
-				{Depth: 2, Nodes: 5805, Score: 23, Mate: 0, PV: []chess.Move{0x6401cc38d2, 0x12c02c328ed}},
-				{Depth: 3, Nodes: 19798, Score: 25, Mate: 0, PV: []chess.Move{0x6401cc38d2, 0x12c02c328ed, 0x19002c85d26}},
-				{Depth: 4, Nodes: 42379, Score: 25, Mate: 0, PV: []chess.Move{0x6401cc38d2, 0x12c02c328ed, 0x19002c85d26}},
-				{Depth: 5, Nodes: 167447, Score: 3, Mate: 0, PV: []chess.Move{0x12702c25b66, 0x14f02c50b76, 0x6401cc1649, 0x12702c3455e, 0x14f02c4954c}},
-				{Depth: 6, Nodes: 613541, Score: 3, Mate: 0, PV: []chess.Move{0x12702c25b66, 0x14f02c50b76, 0x6401cc1649, 0x12702c3455e, 0x14f02c4954c}},
-				{Depth: 7, Nodes: 3279541, Score: 1, Mate: 0, PV: []chess.Move{0x12702c25b66, 0x14f02c58b74, 0x6401cc38d2, 0x6401cc2e6a, 0x19006c83b63, 0x14a02c30b76, 0x11802c03915}},
-				{Depth: 8, Nodes: 18821824, Score: 1, Mate: 0, PV: []chess.Move{0x6401cc38d2, 0x12c02c328ed, 0x19002c85d26, 0x14f02c52d23, 0x11302c05a1a, 0x14f02c50a31, 0x6401cc92cc}},
+				{Depth: 1, Nodes: 266, Score: 88, Mate: 0, PV: []chess.Move{0x1cc38d2}},
+				{Depth: 2, Nodes: 1720, Score: 123, Mate: 0, PV: []chess.Move{0x1cc38d2, 0x1cc8ef4}},
+				{Depth: 3, Nodes: 7304, Score: 162, Mate: 0, PV: []chess.Move{0x1cc38d2, 0x1cc8ef4, 0x1cc15cf}},
+				{Depth: 4, Nodes: 25273, Score: 132, Mate: 0, PV: []chess.Move{0x1cc38d2, 0x1cc8ef4, 0x1cc92cc, 0x1cc0871}},
+				{Depth: 5, Nodes: 95701, Score: 188, Mate: 0, PV: []chess.Move{0x1cc38d2, 0x1cc8ef4, 0x2c25b66, 0x2c50b76, 0x1cc15cf}},
+				{Depth: 6, Nodes: 555530, Score: 106, Mate: 0, PV: []chess.Move{0x1cc38d2, 0x1cc8cf4, 0x2c25b66, 0x1cc26ea, 0x1cc92cc}},
+				{Depth: 7, Nodes: 3042100, Score: 106, Mate: 0, PV: []chess.Move{0x1cc38d2, 0x1cc8cf4, 0x2c25b66, 0x1cc26ea, 0x1cc92cc, 0x2c3455e, 0x2c4154e}},
 			},
 		},
 	}
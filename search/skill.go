@@ -0,0 +1,202 @@
+package search
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+// Skill levels weaken the engine for weaker opponents, from 0 for the weakest
+// to maxSkillLevel for the full strength. Below the full strength:
+//   - the search is capped in depth and in nodes,
+//   - the evaluation is blurred by a noise that grows as the level decreases,
+//   - the move played is picked at random among the skillMultiPV best root moves
+//     of the last completed iteration, the weaker levels favoring the best moves less.
+//
+// When the strength is limited, the Elo rating maps to the levels with skillElo.
+// The ratings are calibrated by self-play with cmd/calibrate: each level played
+// 40 games against the next one, the full strength searching 131072 nodes
+// per move and being rated MaxElo. The ratings are relative to the full strength,
+// not measured against rated opponents, and each step between two levels is
+// measured within about ±110 Elo. The levels measured out of order share their
+// mean rating, the higher level being selected:
+//
+//	Elo   level  depth  nodes   noise (cp)
+//	739   0      1      256     ±160
+//	837   1      1      256     ±152
+//	1028  2      2      512     ±144
+//	1098  3      2      512     ±136
+//	1289  4      3      1024    ±128
+//	1360  5      3      1024    ±120
+//	1448  6      4      2048    ±112
+//	1510  7      4      2048    ±104
+//	1627  8      5      4096    ±96
+//	1662  9      5      4096    ±88
+//	1849  10     6      8192    ±80
+//	1849  11     6      8192    ±72
+//	1870  12     7      16384   ±64
+//	1879  13     7      16384   ±56
+//	1940  14     8      32768   ±48
+//	1966  15     8      32768   ±40
+//	2113  16     9      65536   ±32
+//	2184  17     9      65536   ±24
+//	2287  18     10     131072  ±16
+//	2287  19     10     131072  ±8
+//	2400  20     -      -       -
+const (
+	// maxSkillLevel is the skill level of the full strength.
+	maxSkillLevel = 20
+	// MinElo is the lowest Elo rating of the limited strength.
+	MinElo = 739
+	// MaxElo is the Elo rating of the full strength.
+	MaxElo = 2400
+	// skillMultiPV is the number of best root moves the skill move is picked from.
+	skillMultiPV = 4
+	// skillNodes is the node cap of the weakest skill level, doubled every two levels.
+	skillNodes = 256
+	// skillNoise is the evaluation noise in centipawns removed at each skill level.
+	skillNoise = 8
+	// skillDelta is the maximum score spread in centipawns of the random part of the pick.
+	skillDelta = 100
+)
+
+// skillElo holds the lowest Elo rating of each skill level.
+var skillElo = [maxSkillLevel + 1]int{
+	MinElo, 837, 1028, 1098, 1289, 1360, 1448, 1510, 1627, 1662,
+	1849, 1849, 1870, 1879, 1940, 1966, 2113, 2184, 2287, 2287,
+	MaxElo,
+}
+
+// skillOptions holds the strength limiting options.
+type skillOptions struct {
+	level int  // skill level, maxSkillLevel for the full strength
+	limit bool // whether the strength is limited to the Elo rating instead of the skill level
+	elo   int  // Elo rating of the limited strength
+}
+
+// defaultSkillOptions returns the default strength limiting options.
+func defaultSkillOptions() skillOptions {
+	return skillOptions{
+		level: maxSkillLevel,
+		elo:   1500,
+	}
+}
+
+// skillLevel returns the effective skill level.
+func (so skillOptions) skillLevel() int {
+	if so.limit {
+		level := 0
+		for level < maxSkillLevel && so.elo >= skillElo[level+1] {
+			level++
+		}
+		return level
+	}
+	return min(max(so.level, 0), maxSkillLevel)
+}
+
+// enabled returns whether the strength is limited.
+func (so skillOptions) enabled() bool {
+	return so.skillLevel() < maxSkillLevel
+}
+
+// limits returns the search limits capped by the skill level.
+//
+// Zero limits are not set.
+func (so skillOptions) limits(maxDepth, maxNodes int) (int, int) {
+	if !so.enabled() {
+		return maxDepth, maxNodes
+	}
+
+	level := so.skillLevel()
+	depth := level/2 + 1
+	nodes := skillNodes << (level / 2)
+
+	if maxDepth <= 0 || maxDepth > depth {
+		maxDepth = depth
+	}
+	if maxNodes <= 0 || maxNodes > nodes {
+		maxNodes = nodes
+	}
+	return maxDepth, maxNodes
+}
+
+// noise returns the amplitude of the evaluation noise in centipawns.
+func (so skillOptions) noise() int32 {
+	return int32(maxSkillLevel-so.skillLevel()) * skillNoise
+}
+
+// evalNoise returns the evaluation noise of the position.
+//
+// The noise depends on the hash of the position and on the seed of the search,
+// so that the positions keep their evaluation during a search.
+func (si *searchInfo) evalNoise(hash chess.Hash) int32 {
+	h := (uint64(hash) ^ si.seed) * 0x9e3779b97f4a7c15
+	h ^= h >> 32
+	return int32(h%uint64(2*si.noise+1)) - si.noise
+}
+
+// rootMove holds a root move and its score.
+type rootMove struct {
+	move  chess.Move
+	score int32
+}
+
+// bestRootMoves returns the skillMultiPV best root moves searched at the depth,
+// sorted by decreasing score, starting from the best move of the iteration.
+//
+// Each next move is the best move of a search excluding the moves already found.
+// The root entry of the transposition table is restored so that the next iteration
+// starts from the best move.
+func (si *searchInfo) bestRootMoves(ctx context.Context, pos *chess.Position, best rootMove, depth uint8) ([]rootMove, error) {
+	hash := pos.Hash()
+	if entry, ok := si.table.get(hash); ok {
+		defer si.table.set(hash, entry.best, entry.score(), entry.nodeType(), entry.depth())
+	}
+	defer func() { si.excluded = nil }()
+
+	moves := []rootMove{best}
+	si.excluded = []chess.Move{best.move.WithScore(0)}
+	for len(moves) < skillMultiPV {
+		si.rootBest = chess.NoMove
+		score, err := si.principalVariation(ctx, pos, -mate, mate, depth, 0)
+		if err != nil {
+			return nil, err
+		}
+		if si.rootBest == chess.NoMove {
+			break
+		}
+
+		moves = append(moves, rootMove{si.rootBest, score})
+		si.excluded = append(si.excluded, si.rootBest)
+	}
+
+	slices.SortStableFunc(moves, func(a, b rootMove) int {
+		return cmp.Compare(b.score, a.score)
+	})
+	return moves, nil
+}
+
+// pickSkillMove returns the index of the move played at the skill level.
+//
+// Expects the moves to be sorted by decreasing score. The scores are pushed towards
+// the best score by a weakness decreasing with the level, and a random part
+// bounded by the score spread is added.
+func pickSkillMove(moves []rootMove, level int, random func(int) int) int {
+	weakness := int64(120 - 2*level)
+	top := int64(moves[0].score)
+	delta := min(top-int64(moves[len(moves)-1].score), skillDelta)
+
+	var best int
+	var bestScore int64
+	for i, rm := range moves {
+		score := int64(rm.score)
+		push := (weakness*(top-score) + delta*int64(random(int(weakness)))) / 128
+		if i == 0 || score+push >= bestScore {
+			best, bestScore = i, score+push
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,210 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+func TestWithSkillLevel(t *testing.T) {
+	t.Parallel()
+	e := NewEngine(WithSkillLevel(5))
+	assert.Equal(t, 5, e.skill.level)
+}
+
+func TestWithLimitStrength(t *testing.T) {
+	t.Parallel()
+	e := NewEngine(WithLimitStrength(true), WithElo(1800))
+	assert.True(t, e.skill.limit)
+	assert.Equal(t, 1800, e.skill.elo)
+}
+
+func TestSkillOptions_SkillLevel(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		args skillOptions
+		want int
+	}{
+		{"default", defaultSkillOptions(), maxSkillLevel},
+		{"level", skillOptions{level: 7, elo: 1000}, 7},
+		{"level below range", skillOptions{level: -3}, 0},
+		{"elo", skillOptions{level: 20, limit: true, elo: 1560}, 7},
+		{"shared elo", skillOptions{limit: true, elo: 1849}, 11},
+		{"lowest elo", skillOptions{limit: true, elo: MinElo}, 0},
+		{"elo below range", skillOptions{limit: true, elo: 500}, 0},
+		{"highest elo", skillOptions{limit: true, elo: MaxElo}, maxSkillLevel},
+		{"elo above range", skillOptions{limit: true, elo: 3000}, maxSkillLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, tt.args.skillLevel())
+			assert.Equal(t, tt.want < maxSkillLevel, tt.args.enabled())
+		})
+	}
+}
+
+func TestSkillOptions_Limits(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		level     int
+		depth     int
+		nodes     int
+		want      [2]int
+		wantNoise int32
+	}{
+		{"full strength", 20, 0, 0, [2]int{0, 0}, 0},
+		{"weakest", 0, 0, 0, [2]int{1, 256}, 160},
+		{"level 9", 9, 0, 0, [2]int{5, 4096}, 88},
+		{"lower depth", 9, 3, 0, [2]int{3, 4096}, 88},
+		{"lower nodes", 19, 0, 1000, [2]int{10, 1000}, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			so := skillOptions{level: tt.level}
+			depth, nodes := so.limits(tt.depth, tt.nodes)
+			assert.Equal(t, tt.want, [2]int{depth, nodes})
+			assert.Equal(t, tt.wantNoise, so.noise())
+		})
+	}
+}
+
+func TestPickSkillMove(t *testing.T) {
+	t.Parallel()
+	moves := []rootMove{{score: 50}, {score: 40}, {score: -20}, {score: -300}}
+
+	tests := []struct {
+		name   string
+		moves  []rootMove
+		level  int
+		random []int
+		want   int
+	}{
+		{"no randomness", moves, 19, []int{0, 0, 0, 0}, 0},
+		{"random second move", moves, 19, []int{0, 81, 0, 0}, 1},
+		{"strong level avoids blunder", moves, 19, []int{0, 0, 0, 81}, 0},
+		{"weak level blunders", moves, 0, []int{0, 0, 0, 119}, 3},
+		{"single move", moves[:1], 0, []int{119}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var calls int
+			random := func(n int) int {
+				r := tt.random[calls]
+				calls++
+				require.Less(t, r, n)
+				return r
+			}
+			assert.Equal(t, tt.want, pickSkillMove(tt.moves, tt.level, random))
+		})
+	}
+}
+
+func TestEvalNoise(t *testing.T) {
+	t.Parallel()
+	si := newSearchInfo(noTable{}, noPawnTable{})
+	si.noise, si.seed = 40, 0x1234
+
+	pos := unsafeFEN("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1")
+	noise := si.evalNoise(pos.Hash())
+	assert.GreaterOrEqual(t, noise, int32(-40))
+	assert.LessOrEqual(t, noise, int32(40))
+	assert.Equal(t, noise, si.evalNoise(pos.Hash()))
+
+	want := newSearchInfo(noTable{}, noPawnTable{}).evaluate(pos)
+	assert.Equal(t, want+noise, si.evaluate(pos))
+}
+
+func TestSearch_SkillLevel(t *testing.T) {
+	t.Parallel()
+	e := NewEngine(WithSkillLevel(0))
+	pos := unsafeFEN("r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3")
+
+	var outputs []Output
+	for output := range e.Search(context.Background(), pos, 0, 0) {
+		outputs = append(outputs, output)
+	}
+
+	require.Len(t, outputs, 2)
+	assert.Equal(t, 1, outputs[0].Depth)
+	last := outputs[len(outputs)-1]
+	require.Len(t, last.PV, 1)
+	assert.True(t, isLegalMove(pos, last.PV[0]))
+}
+
+func TestSearch_SkillLevel_Stopped(t *testing.T) {
+	t.Parallel()
+	e := NewEngine(WithSkillLevel(19))
+	pos := unsafeFEN("r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	outputs := e.Search(ctx, pos, 0, 0)
+	first := <-outputs
+	cancel()
+
+	last := first
+	for output := range outputs {
+		last = output
+	}
+
+	// the move is picked among the best moves of the last completed iteration
+	require.Len(t, last.PV, 1)
+	assert.GreaterOrEqual(t, last.Depth, 1)
+	assert.True(t, isLegalMove(pos, last.PV[0]))
+}
+
+func TestBestRootMoves(t *testing.T) {
+	t.Parallel()
+	si := newSearchInfo(newArrayTable(1), noPawnTable{})
+	pos := unsafeFEN("rnb1kbnr/pppp1ppp/8/4p1q1/3P4/2N5/PPP1PPPP/R1BQKBNR w KQkq - 0 1")
+
+	score, err := si.principalVariation(context.Background(), pos, -mate, mate, 3, 0)
+	require.NoError(t, err)
+	entry, ok := si.table.get(pos.Hash())
+	require.True(t, ok)
+
+	moves, err := si.bestRootMoves(context.Background(), pos, rootMove{si.rootBest, score}, 3)
+	require.NoError(t, err)
+
+	require.Len(t, moves, skillMultiPV)
+	assert.Equal(t, "c1g5", moves[0].move.String())
+	seen := make(map[chess.Move]bool)
+	for i, rm := range moves {
+		assert.True(t, isLegalMove(pos, rm.move), rm.move.String())
+		assert.False(t, seen[rm.move], rm.move.String())
+		seen[rm.move] = true
+		if i > 0 {
+			assert.LessOrEqual(t, rm.score, moves[i-1].score)
+		}
+	}
+
+	// the root entry is restored and the moves are no longer excluded
+	restored, ok := si.table.get(pos.Hash())
+	require.True(t, ok)
+	assert.Equal(t, entry, restored)
+	assert.Nil(t, si.excluded)
+}
+
+// isLegalMove returns whether the move is legal in the position.
+func isLegalMove(pos *chess.Position, move chess.Move) bool {
+	checkData, _ := pos.InCheck()
+	for _, m := range pos.AppendLegalMoves(nil, checkData) {
+		if m == move {
+			return true
+		}
+	}
+	return false
+}
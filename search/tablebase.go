@@ -0,0 +1,164 @@
+package search
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/leonhfr/orca/chess"
+	"github.com/leonhfr/orca/syzygy"
+)
+
+// tablebaseWin is the score of a tablebase win.
+//
+// Above any evaluation, below the mate scores.
+const tablebaseWin int32 = 20_000
+
+// WithSyzygyPath sets the directories of the Syzygy tablebases,
+// separated by the OS path list separator.
+//
+// The tables are loaded on the next call to Init. An empty path disables the tablebases.
+func WithSyzygyPath(path string) Option {
+	return func(e *Engine) {
+		e.tablebaseMu.Lock()
+		defer e.tablebaseMu.Unlock()
+		e.syzygyPath = path
+		e.closeTablebase()
+	}
+}
+
+// initTablebase loads the Syzygy tablebases if the path changed since the last call.
+func (e *Engine) initTablebase() error {
+	e.tablebaseMu.Lock()
+	defer e.tablebaseMu.Unlock()
+
+	if e.tablebase != nil || e.syzygyPath == "" {
+		return nil
+	}
+
+	var dirs []string
+	for _, dir := range filepath.SplitList(e.syzygyPath) {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	tb, err := syzygy.Open(dirs...)
+	e.tablebase = tb
+	if err != nil {
+		return fmt.Errorf("syzygy: %w", err)
+	}
+
+	return nil
+}
+
+// closeTablebase releases the Syzygy tablebases.
+//
+// Tablebases still probed by running searches are closed
+// when the last search releases them.
+//
+// Expects the tablebase mutex to be held.
+func (e *Engine) closeTablebase() {
+	if e.tablebase != nil && e.tablebaseRefs[e.tablebase] == 0 {
+		_ = e.tablebase.Close()
+	}
+	e.tablebase = nil
+}
+
+// acquireTablebase returns the Syzygy tablebases, nil if none is loaded.
+//
+// The tablebases stay open until released with releaseTablebase.
+func (e *Engine) acquireTablebase() *syzygy.Tablebase {
+	e.tablebaseMu.Lock()
+	defer e.tablebaseMu.Unlock()
+
+	if e.tablebase == nil || e.tablebase.Largest() == 0 {
+		return nil
+	}
+
+	if e.tablebaseRefs == nil {
+		e.tablebaseRefs = make(map[*syzygy.Tablebase]int)
+	}
+	e.tablebaseRefs[e.tablebase]++
+	return e.tablebase
+}
+
+// releaseTablebase releases the Syzygy tablebases returned by acquireTablebase.
+//
+// Closes them if the engine released them in the meantime.
+func (e *Engine) releaseTablebase(tb *syzygy.Tablebase) {
+	if tb == nil {
+		return
+	}
+
+	e.tablebaseMu.Lock()
+	defer e.tablebaseMu.Unlock()
+
+	e.tablebaseRefs[tb]--
+	if e.tablebaseRefs[tb] > 0 {
+		return
+	}
+
+	delete(e.tablebaseRefs, tb)
+	if tb != e.tablebase {
+		_ = tb.Close()
+	}
+}
+
+// inTablebase returns whether the position may be probed in the tablebases.
+func (si *searchInfo) inTablebase(pos *chess.Position) bool {
+	return si.tablebase != nil && pos.PieceCount() <= si.tablebase.Largest()
+}
+
+// probeTablebase returns the score of the position in the tablebases.
+func (si *searchInfo) probeTablebase(pos *chess.Position) (int32, bool) {
+	wdl, ok := si.tablebase.ProbeWDL(pos)
+	if !ok {
+		return 0, false
+	}
+
+	si.tbHits++
+	return tablebaseScore(wdl), true
+}
+
+// tablebaseScore returns the score of a tablebase outcome.
+//
+// Wins and losses spoiled by the fifty-move rule score next to a draw.
+func tablebaseScore(wdl syzygy.WDL) int32 {
+	switch wdl {
+	case syzygy.Win:
+		return tablebaseWin
+	case syzygy.CursedWin:
+		return draw + 1
+	case syzygy.BlessedLoss:
+		return draw - 1
+	case syzygy.Loss:
+		return -tablebaseWin
+	default:
+		return draw
+	}
+}
+
+// setRootMoves restricts the root moves to the ones that keep the best
+// tablebase outcome, so that won endgames are converted.
+func (si *searchInfo) setRootMoves(pos *chess.Position) {
+	if !si.inTablebase(pos) {
+		return
+	}
+
+	if moves, _, ok := si.tablebase.ProbeRoot(pos); ok {
+		si.rootMoves = moves
+		si.tbHits++
+	}
+}
+
+// skipRootMove returns whether the move is excluded from the search,
+// by the tablebases or by the skill level.
+func (si *searchInfo) skipRootMove(move chess.Move, index uint8) bool {
+	if index > 0 {
+		return false
+	}
+	move = move.WithScore(0)
+	return si.rootMoves != nil && !slices.Contains(si.rootMoves, move) || slices.Contains(si.excluded, move)
+}
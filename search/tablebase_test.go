@@ -0,0 +1,168 @@
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonhfr/orca/chess"
+	"github.com/leonhfr/orca/syzygy"
+)
+
+const syzygyDir = "../test/data/syzygy"
+
+func TestInitTablebase(t *testing.T) {
+	t.Parallel()
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	tests := []struct {
+		name    string
+		path    string
+		largest int
+		err     bool
+	}{
+		{"no path", "", 0, false},
+		{"directory", syzygyDir, 3, false},
+		{"several directories", missing + string(filepath.ListSeparator) + syzygyDir, 3, true},
+		{"missing directory", missing, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			e := NewEngine(WithSyzygyPath(tt.path))
+			err := e.Init()
+			defer e.Close()
+
+			if tt.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			var largest int
+			if tb := e.acquireTablebase(); tb != nil {
+				largest = tb.Largest()
+				e.releaseTablebase(tb)
+			}
+			assert.Equal(t, tt.largest, largest)
+		})
+	}
+}
+
+func TestWithSyzygyPath(t *testing.T) {
+	t.Parallel()
+	e := NewEngine(WithSyzygyPath(syzygyDir))
+	require.NoError(t, e.Init())
+	tb := e.acquireTablebase()
+	assert.NotNil(t, tb)
+	e.releaseTablebase(tb)
+
+	WithSyzygyPath("")(e)
+	require.NoError(t, e.Init())
+	assert.Nil(t, e.acquireTablebase())
+}
+
+func TestReleaseTablebase(t *testing.T) {
+	t.Parallel()
+	pos := unsafeFEN("8/8/8/4k3/8/8/4P3/4K3 w - - 0 1")
+	e := NewEngine(WithSyzygyPath(syzygyDir))
+	require.NoError(t, e.Init())
+
+	// a search probes the tables while they are replaced and the engine is closed
+	tb := e.acquireTablebase()
+	require.NotNil(t, tb)
+	WithSyzygyPath(syzygyDir)(e)
+	require.NoError(t, e.Init())
+	e.Close()
+
+	_, ok := tb.ProbeWDL(pos)
+	assert.True(t, ok)
+	assert.Equal(t, 3, tb.Largest())
+
+	e.releaseTablebase(tb)
+	assert.Zero(t, tb.Largest())
+	assert.Empty(t, e.tablebaseRefs)
+}
+
+func TestTablebaseScore(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		wdl  syzygy.WDL
+		want int32
+	}{
+		{syzygy.Loss, -tablebaseWin},
+		{syzygy.BlessedLoss, -1},
+		{syzygy.Draw, draw},
+		{syzygy.CursedWin, 1},
+		{syzygy.Win, tablebaseWin},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tablebaseScore(tt.wdl))
+	}
+}
+
+func TestSearch_Tablebase(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		fen   string
+		moves []string // best moves allowed by the tablebases
+		score int
+	}{
+		{"KRvK win", "8/8/8/8/8/8/4R3/K1k5 w - - 0 1", []string{"e2a2", "e2b2"}, int(tablebaseWin)},
+		{"KRvK loss", "8/8/8/8/8/8/4R3/K1k5 b - - 0 1", nil, -int(tablebaseWin)},
+		{"KPvK promotion", "8/P7/8/8/8/8/8/K1k5 w - - 0 1", []string{"a7a8q", "a7a8r"}, int(tablebaseWin)},
+		{"KPvK draw", "8/8/8/4k3/8/8/4P3/4K3 w - - 0 1", nil, draw},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			engine := NewEngine(WithSyzygyPath(syzygyDir))
+			require.NoError(t, engine.Init())
+			defer engine.Close()
+
+			pos := unsafeFEN(tt.fen)
+			tb := engine.acquireTablebase()
+			moves, _, ok := tb.ProbeRoot(pos)
+			engine.releaseTablebase(tb)
+			require.True(t, ok)
+
+			var last Output
+			for o := range engine.Search(context.Background(), pos, 4, 0) {
+				last = o
+			}
+
+			require.NotEmpty(t, last.PV)
+			assert.Contains(t, moves, last.PV[0])
+			if tt.moves != nil {
+				var allowed []string
+				for _, m := range moves {
+					allowed = append(allowed, m.String())
+				}
+				assert.ElementsMatch(t, tt.moves, allowed)
+			}
+			assert.Equal(t, tt.score, last.Score)
+			assert.Positive(t, last.TBHits)
+		})
+	}
+}
+
+func TestSearch_NoTablebase(t *testing.T) {
+	t.Parallel()
+	engine := NewEngine()
+	require.NoError(t, engine.Init())
+
+	var last Output
+	for o := range engine.Search(context.Background(), unsafeFEN("8/8/8/8/8/8/4R3/K1k5 w - - 0 1"), 2, 0) {
+		last = o
+	}
+
+	assert.Zero(t, last.TBHits)
+	assert.NotEqual(t, chess.NoMove, last.PV[0])
+}
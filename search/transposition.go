@@ -91,6 +91,7 @@ func (noTable) close()                                                       {}
 type arrayTable struct {
 	table  []searchEntry
 	length uint64
+	size   int // in MB
 	epoch  uint8
 }
 
@@ -104,6 +105,7 @@ func newArrayTable(size int) *arrayTable {
 	return &arrayTable{
 		table:  make([]searchEntry, length),
 		length: length,
+		size:   size,
 	}
 }
 
@@ -147,7 +149,8 @@ func (ar *arrayTable) principalVariation(pos *chess.Position) []chess.Move {
 	pv := make([]chess.Move, 0, 10)
 	unmakeMoveStack := make([]unmakeMove, 0, 10)
 
-	for hash := pos.Hash(); ; hash = pos.Hash() {
+	// the entries may form a cycle through repeated positions
+	for hash := pos.Hash(); len(pv) < maxSearchDepth; hash = pos.Hash() {
 		entry, inCache := ar.get(hash)
 		if !inCache || entry.best == chess.NoMove {
 			break
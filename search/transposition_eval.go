@@ -0,0 +1,97 @@
+package search
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+// transpositionEvalTable is the interface that evaluation transposition tables should implement.
+//
+// Allows the storing of the evaluations of positions by mapping chess.Hash to evalEntry structs.
+type transpositionEvalTable interface {
+	// get returns the entry (if any) for the given hash
+	// and a boolean representing whether the value was found or not.
+	get(hash chess.Hash) (evalEntry, bool)
+	// set adds an entry to the table for the given hash.
+	// If an entry already exists, it is replaced.
+	set(hash chess.Hash, score int32)
+	// close initiates a graceful shutdown of the evaluation transposition table.
+	close()
+}
+
+// evalEntry holds the evaluation of a position.
+type evalEntry struct {
+	hash uint64
+	data uint64
+}
+
+// score returns the evaluation of the position.
+func (ee evalEntry) score() int32 {
+	return int32(uint32(ee.data))
+}
+
+// noEvalTable does not store anything at all.
+type noEvalTable struct{}
+
+func (noEvalTable) get(_ chess.Hash) (evalEntry, bool) { return evalEntry{}, false } // implements transpositionEvalTable.
+func (noEvalTable) set(_ chess.Hash, _ int32)          {}                            // implements transpositionEvalTable.
+func (noEvalTable) close()                             {}                            // implements transpositionEvalTable.
+
+// arrayEvalTable uses an array as backend.
+//
+// The table is shared by the search goroutines without locks
+// in the same way as the arrayPawnTable.
+//
+// Implements the transpositionEvalTable interface.
+type arrayEvalTable struct {
+	table  []evalEntry
+	length uint64
+	size   int // in MB
+}
+
+// newArrayEvalTable returns a new arrayEvalTable.
+//
+// Takes the desired table size in Megabytes as argument.
+func newArrayEvalTable(size int) *arrayEvalTable {
+	entrySize := uint64(unsafe.Sizeof(evalEntry{}))
+	length := 1024 * 1024 * uint64(size) / entrySize
+
+	return &arrayEvalTable{
+		table:  make([]evalEntry, length),
+		length: length,
+		size:   size,
+	}
+}
+
+// Implements the transpositionEvalTable interface.
+func (ar *arrayEvalTable) get(hash chess.Hash) (evalEntry, bool) {
+	cached := &ar.table[ar.hash(hash)]
+	entry := evalEntry{
+		hash: atomic.LoadUint64(&cached.hash),
+		data: atomic.LoadUint64(&cached.data),
+	}
+	return entry, chess.Hash(entry.hash^entry.data) == hash
+}
+
+// Implements the transpositionEvalTable interface.
+func (ar *arrayEvalTable) set(hash chess.Hash, score int32) {
+	cached := &ar.table[ar.hash(hash)]
+	data := uint64(uint32(score))
+
+	atomic.StoreUint64(&cached.hash, uint64(hash)^data)
+	atomic.StoreUint64(&cached.data, data)
+}
+
+// Implements the transpositionEvalTable interface.
+func (ar *arrayEvalTable) close() {
+	ar.table = nil
+}
+
+// hash is the hash function used by the array table.
+func (ar *arrayEvalTable) hash(hash chess.Hash) uint64 {
+	index, _ := bits.Mul64(uint64(hash), ar.length)
+	return index
+}
@@ -0,0 +1,77 @@
+package search
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+// compile time check that noEvalTable implements transpositionEvalTable.
+var _ transpositionEvalTable = noEvalTable{}
+
+// compile time check that arrayEvalTable implements transpositionEvalTable.
+var _ transpositionEvalTable = (*arrayEvalTable)(nil)
+
+func TestNewEvalTable(t *testing.T) {
+	t.Parallel()
+	table := newArrayEvalTable(1)
+	defer table.close()
+
+	require.Equal(t, uint64(65536), table.length)
+}
+
+func TestEvalTableGet(t *testing.T) {
+	t.Parallel()
+	//nolint:gosec
+	hash := chess.Hash(rand.Uint64())
+	table := newArrayEvalTable(1)
+	defer table.close()
+
+	_, ok := table.get(hash)
+	require.False(t, ok)
+}
+
+func TestEvalTableSet(t *testing.T) {
+	t.Parallel()
+	//nolint:gosec
+	hash := chess.Hash(rand.Uint64())
+	//nolint:gosec
+	score := -rand.Int31()
+
+	table := newArrayEvalTable(1)
+	defer table.close()
+
+	table.set(hash, score)
+	entry, ok := table.get(hash)
+
+	require.True(t, ok)
+	require.Equal(t, score, entry.score())
+}
+
+func TestEvalTableConcurrent(t *testing.T) {
+	t.Parallel()
+	table := newArrayEvalTable(1)
+	defer table.close()
+
+	var wg sync.WaitGroup
+	for worker := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 10_000 {
+				hash := chess.Hash(i % 64)
+				score := int32(worker)
+				table.set(hash, score)
+				if entry, ok := table.get(hash); ok {
+					assert.Less(t, entry.score(), int32(4))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,195 @@
+package search
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+// Transposition table files start with a header followed by the entries of the table:
+//
+//	magic      "ORCATTAB"
+//	version    uint64
+//	entry size uint64
+//	length     uint64
+//	epoch      uint64
+//	entries    length × (hash uint64, best uint64, data uint64)
+//
+// All values are little-endian.
+
+const (
+	// tableFileVersion is the version of the transposition table file format.
+	tableFileVersion = 1
+	// tableEntrySize is the size in bytes of the entries in transposition table files.
+	tableEntrySize = 24
+	// maxTableFileLength is the maximum number of entries in transposition table files,
+	// that of the largest table allowed by the Hash option.
+	maxTableFileLength = 16 * 1024 * 1024 * 1024 / tableEntrySize
+)
+
+// tableFileMagic starts the transposition table files.
+var tableFileMagic = [8]byte{'O', 'R', 'C', 'A', 'T', 'T', 'A', 'B'}
+
+var (
+	errTableMagic        = errors.New("not a transposition table file")
+	errTableVersion      = errors.New("unsupported transposition table file version")
+	errTableEntrySize    = errors.New("transposition table entry size mismatch")
+	errTableHeader       = errors.New("invalid transposition table header")
+	errTableEntry        = errors.New("invalid transposition table entry")
+	errTableTrailingData = errors.New("trailing data after the transposition table")
+	errTableUnavailable  = errors.New("transposition table not available")
+)
+
+// SaveTable writes the content of the transposition table.
+//
+// The table can be restored with LoadTable, for example to resume
+// an analysis after a restart.
+func (e *Engine) SaveTable(w io.Writer) error {
+	_ = e.Init()
+
+	e.searchMu.RLock()
+	defer e.searchMu.RUnlock()
+
+	table, ok := e.table.(*arrayTable)
+	if !ok {
+		return fmt.Errorf("table: %w", errTableUnavailable)
+	}
+
+	if err := table.write(w); err != nil {
+		return fmt.Errorf("table: %w", err)
+	}
+	return nil
+}
+
+// LoadTable replaces the content of the transposition table
+// with the one written by SaveTable.
+//
+// Entries are rehashed when the table was saved with another size,
+// the best entries being kept when the table is smaller.
+// The table is left unchanged when the content is invalid.
+// The table is replaced once the running searches are done.
+func (e *Engine) LoadTable(r io.Reader) error {
+	_ = e.Init()
+
+	table, err := readArrayTable(r, e.tableSize)
+	if err != nil {
+		return fmt.Errorf("table: %w", err)
+	}
+
+	e.searchMu.Lock()
+	defer e.searchMu.Unlock()
+
+	if _, ok := e.table.(*arrayTable); !ok {
+		return fmt.Errorf("table: %w", errTableUnavailable)
+	}
+
+	e.table.close()
+	e.table = table
+	return nil
+}
+
+// write writes the table header and entries.
+func (ar *arrayTable) write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(tableFileMagic[:]); err != nil {
+		return err
+	}
+
+	header := [4]uint64{tableFileVersion, tableEntrySize, ar.length, uint64(ar.epoch)}
+	if err := binary.Write(bw, binary.LittleEndian, header); err != nil {
+		return err
+	}
+
+	var buf [tableEntrySize]byte
+	for _, entry := range ar.table {
+		binary.LittleEndian.PutUint64(buf[0:], entry.hash)
+		binary.LittleEndian.PutUint64(buf[8:], uint64(entry.best))
+		binary.LittleEndian.PutUint64(buf[16:], entry.data)
+		if _, err := bw.Write(buf[:]); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// readArrayTable reads a table written by write into a new table of the given size in MB.
+func readArrayTable(r io.Reader, size int) (*arrayTable, error) {
+	br := bufio.NewReader(r)
+
+	var magic [8]byte
+	if _, err := io.ReadFull(br, magic[:]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if magic != tableFileMagic {
+		return nil, errTableMagic
+	}
+
+	var header [4]uint64
+	if err := binary.Read(br, binary.LittleEndian, &header); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	switch version, entrySize, length, epoch := header[0], header[1], header[2], header[3]; {
+	case version != tableFileVersion:
+		return nil, errTableVersion
+	case entrySize != tableEntrySize:
+		return nil, errTableEntrySize
+	case length == 0 || length > maxTableFileLength || epoch > math.MaxUint8:
+		return nil, errTableHeader
+	}
+
+	ar := newArrayTable(size)
+	ar.epoch = uint8(header[3])
+
+	var buf [tableEntrySize]byte
+	for range header[2] {
+		if _, err := io.ReadFull(br, buf[:]); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+
+		entry := searchEntry{
+			hash: binary.LittleEndian.Uint64(buf[0:]),
+			best: chess.Move(binary.LittleEndian.Uint64(buf[8:])),
+			data: binary.LittleEndian.Uint64(buf[16:]),
+		}
+
+		switch nt := entry.nodeType(); {
+		case nt == noEntry:
+			continue
+		case nt > exact:
+			return nil, errTableEntry
+		}
+
+		ar.restore(entry)
+	}
+
+	if _, err := br.ReadByte(); err != io.EOF {
+		return nil, errTableTrailingData
+	}
+
+	return ar, nil
+}
+
+// restore adds an entry read from a file to the table,
+// replacing the cached entry if it is of equal or lower quality.
+func (ar *arrayTable) restore(entry searchEntry) {
+	hash := chess.Hash(entry.hash ^ uint64(entry.best) ^ entry.data)
+	index := ar.hash(hash)
+	if entry.quality() >= ar.table[index].quality() {
+		ar.table[index] = entry
+	}
+}
+
+// unexpectedEOF converts a partial read to an unexpected end of file.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
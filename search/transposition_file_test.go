@@ -0,0 +1,140 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+func TestSaveLoadTable(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		size int
+	}{
+		{"same size", 1},
+		{"larger table", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			saved := NewEngine(WithTableSize(1))
+			require.NoError(t, saved.Init())
+			saved.table.inc()
+			saved.table.set(chess.Hash(0x1234567890abcdef), chess.Move(0x6c1836d), 42, exact, 7)
+			saved.table.set(chess.Hash(0xfedcba0987654321), chess.NoMove, -15, lowerBound, 3)
+
+			var buf bytes.Buffer
+			require.NoError(t, saved.SaveTable(&buf))
+
+			loaded := NewEngine(WithTableSize(tt.size))
+			require.NoError(t, loaded.LoadTable(&buf))
+
+			entry, ok := loaded.table.get(chess.Hash(0x1234567890abcdef))
+			require.True(t, ok)
+			assert.Equal(t, chess.Move(0x6c1836d), entry.best)
+			assert.Equal(t, int32(42), entry.score())
+			assert.Equal(t, exact, entry.nodeType())
+			assert.Equal(t, uint8(7), entry.depth())
+			assert.Equal(t, uint8(1), entry.epoch())
+
+			entry, ok = loaded.table.get(chess.Hash(0xfedcba0987654321))
+			require.True(t, ok)
+			assert.Equal(t, int32(-15), entry.score())
+			assert.Equal(t, lowerBound, entry.nodeType())
+
+			assert.Equal(t, uint8(1), loaded.table.(*arrayTable).epoch)
+		})
+	}
+}
+
+func TestLoadTable_Invalid(t *testing.T) {
+	t.Parallel()
+	e := NewEngine(WithTableSize(1))
+	require.NoError(t, e.Init())
+	e.table.set(chess.Hash(0x1234567890abcdef), chess.NoMove, 42, exact, 7)
+
+	var buf bytes.Buffer
+	require.NoError(t, e.SaveTable(&buf))
+	valid := buf.Bytes()
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, io.ErrUnexpectedEOF},
+		{"magic", append([]byte("ORCANNUE"), valid[8:]...), errTableMagic},
+		{"version", patchTableHeader(valid, 0, 2), errTableVersion},
+		{"entry size", patchTableHeader(valid, 1, 16), errTableEntrySize},
+		{"length", patchTableHeader(valid, 2, 0), errTableHeader},
+		{"epoch", patchTableHeader(valid, 3, 256), errTableHeader},
+		{"truncated", valid[:len(valid)-1], io.ErrUnexpectedEOF},
+		{"trailing data", append(bytes.Clone(valid), 0), errTableTrailingData},
+		{"entry", invalidTableEntry(valid), errTableEntry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			loaded := NewEngine(WithTableSize(1))
+			require.NoError(t, loaded.Init())
+			table := loaded.table
+
+			err := loaded.LoadTable(bytes.NewReader(tt.data))
+			assert.ErrorIs(t, err, tt.want)
+			assert.Same(t, table, loaded.table)
+		})
+	}
+}
+
+func TestLoadTable_DuringSearch(t *testing.T) {
+	t.Parallel()
+	e := NewEngine(WithTableSize(1))
+	require.NoError(t, e.Init())
+
+	var buf bytes.Buffer
+	require.NoError(t, e.SaveTable(&buf))
+	table := e.table
+
+	pos := unsafeFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	output := e.Search(context.Background(), pos, 64, 200_000)
+	<-output
+
+	loaded := make(chan error)
+	go func() {
+		loaded <- e.LoadTable(&buf)
+	}()
+
+	// the search runs until its node budget is spent before the table is replaced
+	var last Output
+	for o := range output {
+		last = o
+	}
+	require.NoError(t, <-loaded)
+
+	assert.GreaterOrEqual(t, last.Nodes, 100_000)
+	assert.NotSame(t, table, e.table)
+}
+
+// patchTableHeader returns a copy of the table file with the header value at the given index replaced.
+func patchTableHeader(data []byte, index int, value uint64) []byte {
+	data = bytes.Clone(data)
+	binary.LittleEndian.PutUint64(data[8+8*index:], value)
+	return data
+}
+
+// invalidTableEntry returns a copy of the table file with an invalid node type in the first entry.
+func invalidTableEntry(data []byte) []byte {
+	data = bytes.Clone(data)
+	data[40+16+4] = 0xff
+	return data
+}
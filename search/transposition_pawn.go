@@ -2,6 +2,7 @@ package search
 
 import (
 	"math/bits"
+	"sync/atomic"
 	"unsafe"
 
 	"github.com/leonhfr/orca/chess"
@@ -10,21 +11,24 @@ import (
 // transpositionPawnTable is the interface that pawn transposition tables should implement.
 //
 // Allows the storing of pawn evaluation results by mapping chess.Hash to pawnEntry structs.
+// Along the scores, entries hold the squares of the passed pawns,
+// which are scored against the pieces of the position outside of the table.
 type transpositionPawnTable interface {
 	// get returns the entry (if any) for the given hash
 	// and a boolean representing whether the value was found or not.
 	get(hash chess.Hash) (pawnEntry, bool)
 	// set adds an entry to the table for the given hash.
 	// If an entry already exists, it is replaced.
-	set(hash chess.Hash, mg, eg int32)
+	set(hash chess.Hash, mg, eg int32, passed uint64)
 	// close initiates a graceful shutdown of the pawn transposition table.
 	close()
 }
 
 // pawnEntry hols a pawn evaluation.
 type pawnEntry struct {
-	hash uint64
-	data uint64
+	hash   uint64
+	data   uint64
+	passed uint64 // squares of the passed pawns of both colors
 }
 
 // serializePawnData serializes a pawn entry data.
@@ -45,46 +49,57 @@ func (pe pawnEntry) eg() int32 {
 // noPawnTable does not store anything at all.
 type noPawnTable struct{}
 
-func (noPawnTable) get(_ chess.Hash) (pawnEntry, bool) { return pawnEntry{}, false } // implements transpositionPawnTable.
-func (noPawnTable) set(_ chess.Hash, _, _ int32)       {}                            // implements transpositionPawnTable.
-func (noPawnTable) close()                             {}                            // implements transpositionPawnTable.
+func (noPawnTable) get(_ chess.Hash) (pawnEntry, bool)     { return pawnEntry{}, false } // implements transpositionPawnTable.
+func (noPawnTable) set(_ chess.Hash, _, _ int32, _ uint64) {}                            // implements transpositionPawnTable.
+func (noPawnTable) close()                                 {}                            // implements transpositionPawnTable.
 
 // arrayPawnTable uses an array as backend.
 //
+// The table is shared by the search goroutines without locks: the words
+// of the entries are loaded and stored atomically, and the hash is stored
+// xored with the other words so that entries torn by concurrent writes
+// fail the verification and are treated as misses.
+//
 // Implements the transpositionPawnTable interface.
 type arrayPawnTable struct {
 	table  []pawnEntry
 	length uint64
+	size   int // in MB
 }
 
 // newArrayPawnTable returns a new arrayPawnTable.
 //
-// Takes the desired table size in Kilobytes as argument.
+// Takes the desired table size in Megabytes as argument.
 func newArrayPawnTable(size int) *arrayPawnTable {
 	entrySize := uint64(unsafe.Sizeof(pawnEntry{}))
-	length := 1024 * uint64(size) / entrySize
+	length := 1024 * 1024 * uint64(size) / entrySize
 
 	return &arrayPawnTable{
 		table:  make([]pawnEntry, length),
 		length: length,
+		size:   size,
 	}
 }
 
 // Implements the transpositionPawnTable interface.
 func (ar *arrayPawnTable) get(hash chess.Hash) (pawnEntry, bool) {
-	entry := ar.table[ar.hash(hash)]
-	return entry, chess.Hash(entry.hash^entry.data) == hash
+	cached := &ar.table[ar.hash(hash)]
+	entry := pawnEntry{
+		hash:   atomic.LoadUint64(&cached.hash),
+		data:   atomic.LoadUint64(&cached.data),
+		passed: atomic.LoadUint64(&cached.passed),
+	}
+	return entry, chess.Hash(entry.hash^entry.data^entry.passed) == hash
 }
 
 // Implements the transpositionPawnTable interface.
-func (ar *arrayPawnTable) set(hash chess.Hash, mg, eg int32) {
-	index := ar.hash(hash)
+func (ar *arrayPawnTable) set(hash chess.Hash, mg, eg int32, passed uint64) {
+	cached := &ar.table[ar.hash(hash)]
 	data := serializePawnData(mg, eg)
 
-	ar.table[index] = pawnEntry{
-		uint64(hash) ^ data,
-		data,
-	}
+	atomic.StoreUint64(&cached.hash, uint64(hash)^data^passed)
+	atomic.StoreUint64(&cached.data, data)
+	atomic.StoreUint64(&cached.passed, passed)
 }
 
 // Implements the transpositionPawnTable interface.
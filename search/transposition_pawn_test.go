@@ -20,7 +20,7 @@ func TestNewPawnTable(t *testing.T) {
 	table := newArrayPawnTable(1)
 	defer table.close()
 
-	require.Equal(t, uint64(64), table.length)
+	require.Equal(t, uint64(43690), table.length)
 }
 
 func TestPawnTableGet(t *testing.T) {
@@ -40,15 +40,18 @@ func TestPawnTableSet(t *testing.T) {
 	hash := chess.Hash(rand.Uint64())
 	//nolint:gosec
 	mg, eg := rand.Int31(), rand.Int31()
+	//nolint:gosec
+	passed := rand.Uint64()
 
 	table := newArrayPawnTable(1)
 	defer table.close()
 
-	table.set(hash, mg, eg)
+	table.set(hash, mg, eg, passed)
 
 	entry, ok := table.get(hash)
 
 	require.True(t, ok)
 	require.Equal(t, mg, entry.mg())
 	require.Equal(t, eg, entry.eg())
+	require.Equal(t, passed, entry.passed)
 }
@@ -58,6 +58,25 @@ func TestTableSet(t *testing.T) {
 	require.Equal(t, depth, entry.depth())
 }
 
+func TestTablePrincipalVariation_Cycle(t *testing.T) {
+	t.Parallel()
+	table := newArrayTable(1)
+	defer table.close()
+
+	pos := chess.StartingPosition()
+	for _, m := range []string{"g1f3", "g8f6", "f3g1", "f6g8"} {
+		move := unsafeMove(pos, m)
+		table.set(pos.Hash(), move, 0, exact, 1)
+		require.True(t, pos.MakeMove(move))
+	}
+	fen := pos.String()
+
+	pv := table.principalVariation(pos)
+
+	require.Len(t, pv, maxSearchDepth)
+	require.Equal(t, fen, pos.String())
+}
+
 // hashMapTable uses a map as backend. Intended to be used for tests.
 //
 // Implements the transpositionTable interface.
@@ -6,7 +6,7 @@ import (
 	"github.com/leonhfr/orca/chess"
 )
 
-func (si *searchInfo) zeroWindow(ctx context.Context, pos *chess.Position, beta int32, depth uint8) (int32, error) {
+func (si *searchInfo) zeroWindow(ctx context.Context, pos *chess.Position, beta int32, depth, index uint8) (int32, error) {
 	select {
 	case <-ctx.Done():
 		return 0, context.Canceled
@@ -14,8 +14,8 @@ func (si *searchInfo) zeroWindow(ctx context.Context, pos *chess.Position, beta
 		si.nodes++
 	}
 
-	if pos.HasInsufficientMaterial() {
-		return draw, nil
+	if si.isDraw(pos, index) || pos.HasInsufficientMaterial() {
+		return si.drawScore(pos), nil
 	}
 
 	checkData, inCheck := pos.InCheck()
@@ -24,24 +24,20 @@ func (si *searchInfo) zeroWindow(ctx context.Context, pos *chess.Position, beta
 	}
 
 	if depth == 0 {
-		return si.quiesce(ctx, pos, beta-1, beta)
+		return si.quiesce(ctx, pos, beta-1, beta, index)
 	}
 
 	meta := pos.Metadata()
 	hash := pos.Hash()
 	pawnHash := pos.PawnHash()
-	moves := pos.PseudoMoves(checkData)
-	quickScoreMoves(pos, moves)
-
-	for i := range len(moves) {
-		nextOracle(moves, i)
-		move := moves[i]
+	mp := newMovePicker(pos, si.moveList(index), checkData, chess.NoMove, [2]chess.Move{}, chess.NoMove, si.history)
 
+	for move := mp.next(); move != chess.NoMove; move = mp.next() {
 		if ok := pos.MakeMove(move); !ok {
 			continue
 		}
 
-		score, err := si.zeroWindow(ctx, pos, 1-beta, depth-1)
+		score, err := si.zeroWindow(ctx, pos, 1-beta, depth-1, index+1)
 
 		pos.UnmakeMove(move, meta, hash, pawnHash)
 
@@ -51,6 +47,9 @@ func (si *searchInfo) zeroWindow(ctx context.Context, pos *chess.Position, beta
 		score = -score
 
 		if score >= beta {
+			if move.HasTag(chess.Quiet) {
+				si.history.inc(move, depth)
+			}
 			return beta, nil
 		}
 	}
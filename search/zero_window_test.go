@@ -16,7 +16,7 @@ func TestZeroWindow(t *testing.T) {
 
 			res := tt.zeroWindow
 			pos := unsafeFEN(tt.fen)
-			score, err := si.zeroWindow(context.Background(), pos, mate, tt.depth)
+			score, err := si.zeroWindow(context.Background(), pos, mate, tt.depth, 0)
 
 			assert.Equal(t, res.nodes, si.nodes, "want %d, got %d", res.nodes, si.nodes)
 			assert.Equal(t, res.score, score, "want %d, got %d", res.score, score)
@@ -32,7 +32,7 @@ func BenchmarkZeroWindow(b *testing.B) {
 
 			pos := unsafeFEN(bb.fen)
 			for n := 0; n < b.N; n++ {
-				_, _ = si.zeroWindow(context.Background(), pos, mate, bb.depth)
+				_, _ = si.zeroWindow(context.Background(), pos, mate, bb.depth, 0)
 			}
 		})
 	}
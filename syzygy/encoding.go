@@ -0,0 +1,201 @@
+package syzygy
+
+// maxPieces is the maximum number of pieces of a table.
+const maxPieces = 7
+
+var (
+	// mapPawns maps the squares a2-h7 to 0..47, the number of squares
+	// available to the other pawns when the leading pawn is on the square.
+	mapPawns [64]int
+	// mapB1H1H7 maps the squares below the a1-h8 diagonal to 0..27.
+	mapB1H1H7 [64]int
+	// mapA1D1D4 maps the squares of the a1-d1-d4 triangle to 0..9.
+	mapA1D1D4 [64]int
+	// mapKK maps the 462 legal positions of two kings where the first
+	// one is in the a1-d1-d4 triangle, indexed by mapA1D1D4.
+	mapKK [10][64]int
+	// binomial holds the number of ways to choose k elements from a set of n elements.
+	binomial [maxPieces - 1][64]int
+	// leadPawnIdx holds the index of the leading pawn per number of leading pawns.
+	leadPawnIdx [maxPieces - 1][64]int
+	// leadPawnsSize holds the number of leading pawn placements per number of leading pawns and file.
+	leadPawnsSize [maxPieces - 1][4]int
+)
+
+func init() {
+	initMapB1H1H7()
+	initMapA1D1D4()
+	initMapKK()
+	initBinomial()
+	initLeadPawns()
+}
+
+// initMapB1H1H7 initializes mapB1H1H7.
+func initMapB1H1H7() {
+	var code int
+	for sq := 0; sq < 64; sq++ {
+		if offA1H8(sq) < 0 {
+			mapB1H1H7[sq] = code
+			code++
+		}
+	}
+}
+
+// initMapA1D1D4 initializes mapA1D1D4.
+//
+// The squares of the a1-d4 diagonal are mapped last.
+func initMapA1D1D4() {
+	var code int
+	var diagonal []int
+	for sq := 0; sq <= d4; sq++ {
+		switch {
+		case offA1H8(sq) < 0 && file(sq) <= fileD:
+			mapA1D1D4[sq] = code
+			code++
+		case offA1H8(sq) == 0 && file(sq) <= fileD:
+			diagonal = append(diagonal, sq)
+		}
+	}
+
+	for _, sq := range diagonal {
+		mapA1D1D4[sq] = code
+		code++
+	}
+}
+
+// initMapKK initializes mapKK.
+//
+// When the first king is on the a1-d4 diagonal, the second one is not
+// above the a1-h8 diagonal. Positions with both kings on the diagonal
+// are mapped last.
+func initMapKK() {
+	type kk struct{ idx, sq int }
+	var bothOnDiagonal []kk
+	var code int
+	for idx := 0; idx < 10; idx++ {
+		for s1 := 0; s1 <= d4; s1++ {
+			if mapA1D1D4[s1] != idx || (idx == 0 && s1 != b1) {
+				continue
+			}
+
+			for s2 := 0; s2 < 64; s2++ {
+				switch {
+				case distance(s1, s2) <= 1:
+					// illegal position
+				case offA1H8(s1) == 0 && offA1H8(s2) > 0:
+					// first on the diagonal, second above
+				case offA1H8(s1) == 0 && offA1H8(s2) == 0:
+					bothOnDiagonal = append(bothOnDiagonal, kk{idx, s2})
+				default:
+					mapKK[idx][s2] = code
+					code++
+				}
+			}
+		}
+	}
+
+	for _, p := range bothOnDiagonal {
+		mapKK[p.idx][p.sq] = code
+		code++
+	}
+}
+
+// initBinomial initializes binomial with the Pascal rule.
+func initBinomial() {
+	binomial[0][0] = 1
+	for n := 1; n < 64; n++ {
+		for k := 0; k < len(binomial) && k <= n; k++ {
+			if k > 0 {
+				binomial[k][n] += binomial[k-1][n-1]
+			}
+			if k < n {
+				binomial[k][n] += binomial[k][n-1]
+			}
+		}
+	}
+}
+
+// initLeadPawns initializes mapPawns, leadPawnIdx and leadPawnsSize.
+//
+// The leading pawn is the one with the highest mapPawns value, the one
+// nearest the edge and, among pawns of the same file, with the lowest rank.
+func initLeadPawns() {
+	available := 47
+	for count := 1; count < len(leadPawnIdx); count++ {
+		for f := fileA; f <= fileD; f++ {
+			var idx int
+			for r := 1; r <= 6; r++ {
+				sq := square(f, r)
+				if count == 1 {
+					mapPawns[sq] = available
+					mapPawns[flipFile(sq)] = available - 1
+					available -= 2
+				}
+				leadPawnIdx[count][sq] = idx
+				idx += binomial[count-1][mapPawns[sq]]
+			}
+			leadPawnsSize[count][f] = idx
+		}
+	}
+}
+
+const (
+	fileA = 0
+	fileD = 3
+	b1    = 1
+	d4    = 27
+)
+
+// square returns the square of the file and rank.
+func square(f, r int) int {
+	return r<<3 | f
+}
+
+// file returns the file of the square.
+func file(sq int) int {
+	return sq & 7
+}
+
+// rank returns the rank of the square.
+func rank(sq int) int {
+	return sq >> 3
+}
+
+// flipFile mirrors the square horizontally.
+func flipFile(sq int) int {
+	return sq ^ 7
+}
+
+// flipRank mirrors the square vertically.
+func flipRank(sq int) int {
+	return sq ^ 56
+}
+
+// flipDiagonal mirrors the square along the a1-h8 diagonal.
+func flipDiagonal(sq int) int {
+	return ((sq >> 3) | (sq << 3)) & 63
+}
+
+// offA1H8 returns the signed distance of the square to the a1-h8 diagonal,
+// positive above the diagonal.
+func offA1H8(sq int) int {
+	return rank(sq) - file(sq)
+}
+
+// edgeDistance returns the distance of the file to the nearest edge.
+func edgeDistance(f int) int {
+	return min(f, 7-f)
+}
+
+// distance returns the king distance between two squares.
+func distance(s1, s2 int) int {
+	return max(abs(file(s1)-file(s2)), abs(rank(s1)-rank(s2)))
+}
+
+// abs returns the absolute value of the integer.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
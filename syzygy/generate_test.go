@@ -0,0 +1,389 @@
+package syzygy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+// The tests use tables of three pieces built by retrograde analysis
+// and written with the encoding of the Syzygy tables. The probes are checked
+// against the generated outcomes, the layout of the official table files
+// being followed but not covered by the tests.
+
+// generatedNames holds the generated tables in dependency order,
+// as the promotions of KPvK are looked up in the other tables.
+var generatedNames = []string{"KNvK", "KBvK", "KQvK", "KRvK", "KPvK"}
+
+var (
+	generatedOnce   sync.Once
+	generatedTables map[string]*generated
+	generatedErr    error
+)
+
+// generateTables generates the test tables once.
+func generateTables() (map[string]*generated, error) {
+	generatedOnce.Do(func() {
+		generatedTables = make(map[string]*generated)
+		for _, name := range generatedNames {
+			g, err := generate(name, generatedTables)
+			if err != nil {
+				generatedErr = fmt.Errorf("%s: %w", name, err)
+				return
+			}
+			generatedTables[name] = g
+		}
+	})
+	return generatedTables, generatedErr
+}
+
+// writeTables writes the generated tables to the directory.
+func writeTables(t *testing.T, dir string) map[string]*generated {
+	t.Helper()
+	tables, err := generateTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range generatedNames {
+		g := tables[name]
+		for typ, ext := range tableExtension {
+			data, err := g.encode(tableType(typ))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, name+ext), data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	return tables
+}
+
+// generated holds the outcome of every position of a material.
+//
+// Positions are indexed by the side to move, then by the squares of
+// the pieces in the order of the table name, white pieces first.
+type generated struct {
+	name   string
+	pieces []chess.Piece
+	fens   []string // empty for invalid positions
+	files  []int    // file of the leading pawn in the table
+	idx    []uint64 // index in the table
+	mated  []bool
+	wdl    []WDL // from the point of view of the side to move
+	dtz    []int // in plies, positive when winning and negative when losing
+	edges  [][]edge
+}
+
+// edge is a move of a generated position.
+//
+// Moves that leave the material, captures and promotions,
+// hold the outcome of the resulting position.
+type edge struct {
+	child   int32 // -1 when the move leaves the material
+	wdl     WDL
+	zeroing bool
+}
+
+// generate generates the outcomes of a material of three pieces.
+//
+// Promotions are looked up in the previously generated materials,
+// promotions to minor pieces and captures lead to a draw.
+func generate(name string, children map[string]*generated) (*generated, error) {
+	pieces, err := namePieces(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(pieces) != 3 {
+		return nil, errTableName
+	}
+
+	if strings.ContainsAny(name, "NB") {
+		// a minor piece cannot mate, all positions are draws
+		return &generated{name: name, pieces: pieces}, nil
+	}
+
+	size := 2 * 64 * 64 * 64
+	g := &generated{
+		name:   name,
+		pieces: pieces,
+		fens:   make([]string, size),
+		files:  make([]int, size),
+		idx:    make([]uint64, size),
+		mated:  make([]bool, size),
+		wdl:    make([]WDL, size),
+		dtz:    make([]int, size),
+		edges:  make([][]edge, size),
+	}
+
+	t, err := g.header(wdlTable)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range size {
+		if err := g.expand(t, i, children); err != nil {
+			return nil, err
+		}
+	}
+
+	g.solveWDL()
+	g.solveDTZ()
+	g.edges = nil
+	return g, nil
+}
+
+// namePieces returns the pieces of the table name, white pieces first.
+func namePieces(name string) ([]chess.Piece, error) {
+	white, black, err := parseTableName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var pieces []chess.Piece
+	for i, side := range []string{white, black} {
+		for _, c := range side {
+			pt := chess.PieceType(strings.IndexRune("PNBRQK", c))
+			pieces = append(pieces, chess.Piece(pt<<1)|chess.Piece(1-i))
+		}
+	}
+
+	return pieces, nil
+}
+
+// squares returns the side to move and the squares of the position index.
+func (g *generated) squares(i int) (chess.Color, []int) {
+	squares := make([]int, len(g.pieces))
+	for k := len(squares) - 1; k >= 0; k-- {
+		squares[k] = i & 63
+		i >>= 6
+	}
+	return chess.White - chess.Color(i), squares
+}
+
+// index returns the position index of the side to move and squares.
+func (g *generated) index(turn chess.Color, squares []int) int {
+	i := int(chess.White - turn)
+	for _, sq := range squares {
+		i = i<<6 | sq
+	}
+	return i
+}
+
+// expand decodes the position, locates it in the table and lists its moves.
+func (g *generated) expand(t *table, i int, children map[string]*generated) error {
+	turn, squares := g.squares(i)
+	board := make([]chess.Piece, 64)
+	for sq := range board {
+		board[sq] = chess.NoPiece
+	}
+	for k, sq := range squares {
+		if board[sq] != chess.NoPiece {
+			return nil
+		}
+		if r := rank(sq); g.pieces[k].Type() == chess.Pawn && (r == 0 || r == 7) {
+			return nil
+		}
+		board[sq] = g.pieces[k]
+	}
+
+	if distance(squares[0], squares[len(squares)-1]) <= 1 {
+		// adjacent kings
+		return nil
+	}
+
+	fen := boardFEN(board, turn)
+	pos, err := chess.FEN{}.Decode(fen)
+	if err != nil {
+		return err
+	}
+
+	// the side that just moved must not be in check
+	meta, hash := pos.Metadata(), pos.Hash()
+	pos.MakeNullMove()
+	_, illegal := pos.InCheck()
+	pos.UnmakeNullMove(meta, hash)
+	if illegal {
+		return nil
+	}
+
+	g.fens[i] = fen
+	_, g.idx[i], g.files[i], _ = t.locate(pos, false)
+	moves := legalMoves(pos, new(chess.MoveList))
+	if _, inCheck := pos.InCheck(); inCheck && len(moves) == 0 {
+		g.mated[i] = true
+	}
+
+	edges := make([]edge, len(moves))
+	for j, m := range moves {
+		if m.HasTag(chess.Capture) {
+			edges[j] = edge{child: -1, wdl: Draw, zeroing: true}
+			continue
+		}
+
+		k := slices.Index(squares, int(m.S1()))
+		squares[k] = int(m.S2())
+		if m.HasTag(chess.Promotion) {
+			edges[j] = g.promotion(turn, squares, m, children)
+		} else {
+			edges[j] = edge{
+				child:   int32(g.index(turn.Other(), squares)),
+				zeroing: isZeroing(m),
+			}
+		}
+		squares[k] = int(m.S1())
+	}
+	g.edges[i] = edges
+
+	return nil
+}
+
+// promotion returns the edge of a promotion, whose piece takes the slot of the pawn.
+//
+// Expects the squares after the move.
+func (g *generated) promotion(turn chess.Color, squares []int, m chess.Move, children map[string]*generated) edge {
+	e := edge{child: -1, wdl: Draw, zeroing: true}
+	name := strings.Replace(g.name, "P", strings.ToUpper(m.Promo().Type().String()), 1)
+	if child, ok := children[name]; ok && child.wdl != nil {
+		e.wdl = child.wdl[child.index(turn.Other(), squares)]
+	}
+	return e
+}
+
+// boardFEN returns the FEN of the board.
+func boardFEN(board []chess.Piece, turn chess.Color) string {
+	var sb strings.Builder
+	for r := 7; r >= 0; r-- {
+		empty := 0
+		for f := range 8 {
+			p := board[square(f, r)]
+			if p == chess.NoPiece {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			sb.WriteString(p.String())
+		}
+		if empty > 0 {
+			sb.WriteByte(byte('0' + empty))
+		}
+		if r > 0 {
+			sb.WriteByte('/')
+		}
+	}
+	sb.WriteString(" " + turn.String() + " - - 0 1")
+	return sb.String()
+}
+
+// childWDL returns the outcome of the edge from the point of view of the child,
+// and whether it is known.
+//
+// Outcomes are known when known is nil.
+func (g *generated) childWDL(e edge, known []bool) (WDL, bool) {
+	switch {
+	case e.child < 0:
+		return e.wdl, true
+	case known == nil:
+		return g.wdl[e.child], true
+	default:
+		return g.wdl[e.child], known[e.child]
+	}
+}
+
+// solveWDL solves the outcomes by retrograde analysis.
+//
+// Positions left unsolved are draws.
+func (g *generated) solveWDL() {
+	known := make([]bool, len(g.fens))
+	for i, fen := range g.fens {
+		if fen != "" && len(g.edges[i]) == 0 {
+			known[i] = true
+			if g.mated[i] {
+				g.wdl[i] = Loss
+			}
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for i, fen := range g.fens {
+			if fen == "" || known[i] {
+				continue
+			}
+
+			win, loss := false, true
+			for _, e := range g.edges[i] {
+				w, ok := g.childWDL(e, known)
+				win = win || (ok && w == Loss)
+				loss = loss && ok && w == Win
+			}
+
+			switch {
+			case win:
+				g.wdl[i], known[i], changed = Win, true, true
+			case loss:
+				g.wdl[i], known[i], changed = Loss, true, true
+			}
+		}
+	}
+}
+
+// solveDTZ solves the distances to zeroing of the decisive positions.
+//
+// Mated positions and mating moves count as zeroing.
+func (g *generated) solveDTZ() {
+	for i := range g.fens {
+		if g.mated[i] {
+			g.dtz[i] = -1
+		}
+	}
+
+	for d, changed := 1, true; changed; d++ {
+		changed = false
+		for i, fen := range g.fens {
+			if fen == "" || g.wdl[i] == Draw || g.dtz[i] != 0 {
+				continue
+			}
+
+			if g.wdl[i] == Win {
+				for _, e := range g.edges[i] {
+					if w, _ := g.childWDL(e, nil); w != Loss {
+						continue
+					}
+					if e.zeroing || g.mated[e.child] || (g.dtz[e.child] < 0 && -g.dtz[e.child] < d) {
+						g.dtz[i], changed = d, true
+						break
+					}
+				}
+				continue
+			}
+
+			dist, ready := 1, true
+			for _, e := range g.edges[i] {
+				if e.zeroing {
+					continue
+				}
+				if c := g.dtz[e.child]; c <= 0 || c >= d {
+					ready = false
+					break
+				} else {
+					dist = max(dist, c+1)
+				}
+			}
+			if ready {
+				g.dtz[i], changed = -dist, true
+			}
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package syzygy
+
+import (
+	"slices"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+// probeState represents the state of a probe.
+type probeState uint8
+
+const (
+	probeOK        probeState = iota // probeOK is a successful probe.
+	probeFail                        // probeFail is a failed probe, for example when a table is missing.
+	probeZeroing                     // probeZeroing is a successful probe whose best move is a zeroing move.
+	probeChangeSTM                   // probeChangeSTM indicates that the DTZ table stores the other side to move.
+)
+
+// wdlMap maps the WDL scores shifted by 2 to the indexes of the DTZ maps.
+var wdlMap = [5]int{1, 3, 0, 2, 0}
+
+// probeTable probes the table of the position.
+//
+// Returns the WDL score of WDL tables and the DTZ in plies of DTZ tables,
+// for which the WDL score of the position is expected.
+func (tb *Tablebase) probeTable(pos *chess.Position, typ tableType, wdl WDL) (int, probeState) {
+	if pos.PieceCount() == 2 {
+		// king versus king
+		return 0, probeOK
+	}
+
+	white, black := materialSides(pos)
+	tables := tb.tables[typ]
+	if t, ok := tables[white+"v"+black]; ok {
+		return t.probe(pos, false, wdl)
+	}
+	if t, ok := tables[black+"v"+white]; ok {
+		return t.probe(pos, true, wdl)
+	}
+
+	return 0, probeFail
+}
+
+// materialSides returns the pieces of both sides in the table name convention.
+func materialSides(pos *chess.Position) (string, string) {
+	var counts [2][chess.NoPieceType]int
+	pos.BoardMap(func(p chess.Piece, _ chess.Square) {
+		counts[p.Color()][p.Type()]++
+	})
+
+	var sides [2]string
+	for c, count := range counts {
+		side := []byte{'K'}
+		for _, pt := range []chess.PieceType{chess.Queen, chess.Rook, chess.Bishop, chess.Knight, chess.Pawn} {
+			for range count[pt] {
+				side = append(side, tbPieceTypes[pt+1])
+			}
+		}
+		sides[c] = string(side)
+	}
+
+	return sides[chess.White], sides[chess.Black]
+}
+
+// tbPiece returns the table code of the piece.
+func tbPiece(p chess.Piece) int {
+	code := int(p.Type()) + 1
+	if p.Color() == chess.Black {
+		code |= tbBlack
+	}
+	return code
+}
+
+// probe probes the table.
+//
+// Tables are stored with the first side of their name as white. When the
+// position has the colors swapped, it is flipped before the lookup. Symmetric
+// tables only store white to move, so black to move positions are flipped too.
+func (t *table) probe(pos *chess.Position, flipped bool, wdl WDL) (int, probeState) {
+	d, idx, tbFile, state := t.locate(pos, flipped)
+	if state != probeOK {
+		return 0, state
+	}
+
+	return t.mapScore(tbFile, d.decompress(t.data, idx), wdl), probeOK
+}
+
+// locate returns the pairs data, the index and the file of the leading pawn of the position.
+func (t *table) locate(pos *chess.Position, flipped bool) (*pairsData, uint64, int, probeState) {
+	flip := flipped || (t.symmetric && pos.Turn() == chess.Black)
+	var flipColor, flipSquares, stm int
+	if flip {
+		flipColor, flipSquares, stm = tbBlack, 56, 1
+	}
+	if pos.Turn() == chess.Black {
+		stm ^= 1
+	}
+
+	var squares, pieces [maxPieces]int
+	var size, leadPawnsCnt, tbFile int
+
+	// tables with pawns are split according to the file of the leading pawn,
+	// the pawn of the leading color nearest the edge and with the lowest rank
+	var leadColor chess.Color
+	if t.hasPawns {
+		leadColor = chess.White
+		if (t.get(0, 0).pieces[0]^flipColor)&tbBlack != 0 {
+			leadColor = chess.Black
+		}
+
+		pos.BoardMap(func(p chess.Piece, sq chess.Square) {
+			if p.Type() == chess.Pawn && p.Color() == leadColor {
+				squares[size] = int(sq) ^ flipSquares
+				size++
+			}
+		})
+		leadPawnsCnt = size
+
+		lead := 0
+		for i := 1; i < leadPawnsCnt; i++ {
+			if mapPawns[squares[i]] > mapPawns[squares[lead]] {
+				lead = i
+			}
+		}
+		squares[0], squares[lead] = squares[lead], squares[0]
+		tbFile = edgeDistance(file(squares[0]))
+	}
+
+	if t.typ == dtzTable && !t.storesSTM(stm, tbFile) {
+		return nil, 0, tbFile, probeChangeSTM
+	}
+
+	pos.BoardMap(func(p chess.Piece, sq chess.Square) {
+		if t.hasPawns && p.Type() == chess.Pawn && p.Color() == leadColor {
+			return
+		}
+		squares[size] = int(sq) ^ flipSquares
+		pieces[size] = tbPiece(p) ^ flipColor
+		size++
+	})
+
+	d := t.get(stm, tbFile)
+
+	// reorders the pieces in the sequence of the table
+	for i := leadPawnsCnt; i < size-1; i++ {
+		for j := i + 1; j < size; j++ {
+			if d.pieces[i] == pieces[j] {
+				pieces[i], pieces[j] = pieces[j], pieces[i]
+				squares[i], squares[j] = squares[j], squares[i]
+				break
+			}
+		}
+	}
+
+	return d, t.index(d, squares[:size], leadPawnsCnt), tbFile, probeOK
+}
+
+// storesSTM returns whether the one-sided DTZ table stores the side to move.
+func (t *table) storesSTM(stm, f int) bool {
+	flags := t.get(stm, f).flags
+	return int(flags&stmFlag) == stm || (t.symmetric && !t.hasPawns)
+}
+
+// index returns the index of the position in the table.
+//
+// Expects the leading pawns first, then the pieces in the sequence of the table.
+func (t *table) index(d *pairsData, squares []int, leadPawnsCnt int) uint64 {
+	// maps the leading piece to the a1-d1-d4 triangle
+	if file(squares[0]) > fileD {
+		for i := range squares {
+			squares[i] = flipFile(squares[i])
+		}
+	}
+
+	var idx uint64
+	if t.hasPawns {
+		idx = uint64(leadPawnIdx[leadPawnsCnt][squares[0]])
+		slices.SortStableFunc(squares[1:leadPawnsCnt], func(a, b int) int {
+			return mapPawns[a] - mapPawns[b]
+		})
+		for i := 1; i < leadPawnsCnt; i++ {
+			idx += uint64(binomial[i][mapPawns[squares[i]]])
+		}
+	} else {
+		idx = t.leadingIndex(d, squares)
+	}
+
+	idx *= d.groupIdx[0]
+
+	// encodes the remaining pawns, then the pieces, by increasing squares
+	remainingPawns := t.hasPawns && t.pawnCount[1] > 0
+	start := d.groupLen[0]
+	for next := 1; d.groupLen[next] != 0; next++ {
+		group := squares[start : start+d.groupLen[next]]
+		slices.Sort(group)
+
+		var n uint64
+		for i, sq := range group {
+			// squares of the previous groups are not available
+			adjust := 0
+			for _, s := range squares[:start] {
+				if sq > s {
+					adjust++
+				}
+			}
+			if remainingPawns {
+				adjust += 8
+			}
+			n += uint64(binomial[i+1][sq-adjust])
+		}
+
+		remainingPawns = false
+		idx += n * d.groupIdx[next]
+		start += d.groupLen[next]
+	}
+
+	return idx
+}
+
+// leadingIndex returns the index of the leading group of a table without pawns.
+//
+// The leading piece is mapped below the fifth rank and the first piece
+// of the group that is not on the a1-h8 diagonal is mapped below it.
+func (t *table) leadingIndex(d *pairsData, squares []int) uint64 {
+	if rank(squares[0]) > 3 {
+		for i := range squares {
+			squares[i] = flipRank(squares[i])
+		}
+	}
+
+	for i := range d.groupLen[0] {
+		if offA1H8(squares[i]) == 0 {
+			continue
+		}
+		if offA1H8(squares[i]) > 0 {
+			for j := i; j < len(squares); j++ {
+				squares[j] = flipDiagonal(squares[j])
+			}
+		}
+		break
+	}
+
+	if !t.unique {
+		// only the kings are encoded together
+		return uint64(mapKK[mapA1D1D4[squares[0]]][squares[1]])
+	}
+
+	// three unique pieces are encoded together
+	s0, s1, s2 := squares[0], squares[1], squares[2]
+	adjust1 := boolInt(s1 > s0)
+	adjust2 := boolInt(s2 > s0) + boolInt(s2 > s1)
+
+	var idx int
+	switch {
+	case offA1H8(s0) != 0:
+		idx = (mapA1D1D4[s0]*63+(s1-adjust1))*62 + s2 - adjust2
+	case offA1H8(s1) != 0:
+		idx = (6*63+rank(s0)*28+mapB1H1H7[s1])*62 + s2 - adjust2
+	case offA1H8(s2) != 0:
+		idx = 6*63*62 + 4*28*62 + rank(s0)*7*28 + (rank(s1)-adjust1)*28 + mapB1H1H7[s2]
+	default:
+		idx = 6*63*62 + 4*28*62 + 4*7*28 + rank(s0)*7*6 + (rank(s1)-adjust1)*6 + (rank(s2) - adjust2)
+	}
+
+	return uint64(idx)
+}
+
+// mapScore maps the value of the table to a WDL score or a DTZ in plies.
+func (t *table) mapScore(f, value int, wdl WDL) int {
+	if t.typ == wdlTable {
+		return value - 2
+	}
+
+	d := t.get(0, f)
+	if d.flags&mappedFlag != 0 {
+		i := d.mapIdx[wdlMap[wdl+2]] + value
+		if d.flags&wideFlag != 0 {
+			value = int(t.data[t.dtzMap+2*i]) | int(t.data[t.dtzMap+2*i+1])<<8
+		} else {
+			value = int(t.data[t.dtzMap+i])
+		}
+	}
+
+	if (wdl == Win && d.flags&winPliesFlag == 0) ||
+		(wdl == Loss && d.flags&lossPliesFlag == 0) ||
+		wdl == CursedWin || wdl == BlessedLoss {
+		value *= 2
+	}
+
+	return value + 1
+}
+
+// boolInt returns 1 if the boolean is true, 0 otherwise.
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
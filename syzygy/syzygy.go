@@ -0,0 +1,444 @@
+// Package syzygy provides probing of Syzygy endgame tablebases.
+//
+// Win/draw/loss tables (.rtbw) give the outcome of a position with perfect
+// play, taking the fifty-move rule into account. Distance to zeroing tables
+// (.rtbz) give the number of plies until the next capture or pawn move
+// on the shortest path that keeps the outcome, which lets the engine
+// convert won endgames.
+//
+// Tables do not hold positions with castling rights. En passant captures
+// are handled by a search of the captures before the lookup.
+package syzygy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/leonhfr/orca/chess"
+	"github.com/leonhfr/orca/internal/mmap"
+)
+
+// WDL represents the outcome of a position from the point of view of the side to move.
+type WDL int8
+
+const (
+	Loss        WDL = iota - 2 // Loss is a loss.
+	BlessedLoss                // BlessedLoss is a loss saved by the fifty-move rule.
+	Draw                       // Draw is a draw.
+	CursedWin                  // CursedWin is a win spoiled by the fifty-move rule.
+	Win                        // Win is a win.
+)
+
+// maxDTZ bounds the ranks of the root moves.
+const maxDTZ = 1 << 18
+
+// Tablebase holds the tables found in the tablebase directories.
+//
+// Tables are memory-mapped and safe for concurrent probes.
+type Tablebase struct {
+	tables  [2]map[string]*table // indexed by table type, then by name
+	largest int
+}
+
+// Open opens the tables found in the directories.
+//
+// Files that are not tables are ignored. Tables that could not be opened
+// are skipped and their errors joined. When a table is found in several
+// directories, the first one is used.
+func Open(dirs ...string) (*Tablebase, error) {
+	tb := &Tablebase{
+		tables: [2]map[string]*table{
+			make(map[string]*table),
+			make(map[string]*table),
+		},
+	}
+
+	var errs []error
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			name := strings.TrimSuffix(entry.Name(), ext)
+			for typ, tableExt := range tableExtension {
+				if ext != tableExt || tb.tables[typ][name] != nil {
+					continue
+				}
+				if _, _, err := parseTableName(name); err != nil {
+					continue
+				}
+
+				t, err := openTable(tableType(typ), name, filepath.Join(dir, entry.Name()))
+				if err != nil {
+					errs = append(errs, fmt.Errorf("table %s: %w", entry.Name(), err))
+					continue
+				}
+
+				tb.tables[typ][name] = t
+				if tableType(typ) == wdlTable {
+					tb.largest = max(tb.largest, t.pieceCount)
+				}
+			}
+		}
+	}
+
+	return tb, errors.Join(errs...)
+}
+
+// openTable memory-maps and parses a table file.
+func openTable(typ tableType, name, path string) (*table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, release, err := mmap.Map(f)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := newTable(typ, name, data)
+	if err != nil {
+		_ = release()
+		return nil, err
+	}
+
+	t.release = release
+	return t, nil
+}
+
+// Close releases the tables.
+//
+// The tablebase must not be used afterwards.
+func (tb *Tablebase) Close() error {
+	var errs []error
+	for _, tables := range tb.tables {
+		for name, t := range tables {
+			if t.release != nil {
+				errs = append(errs, t.release())
+			}
+			delete(tables, name)
+		}
+	}
+	tb.largest = 0
+	return errors.Join(errs...)
+}
+
+// Largest returns the number of pieces of the largest win/draw/loss table.
+func (tb *Tablebase) Largest() int {
+	return tb.largest
+}
+
+// Len returns the number of win/draw/loss and distance to zeroing tables.
+func (tb *Tablebase) Len() (int, int) {
+	return len(tb.tables[wdlTable]), len(tb.tables[dtzTable])
+}
+
+// ProbeWDL returns the outcome of the position.
+//
+// Returns false if the position has too many pieces, castling rights,
+// or if a table is missing.
+func (tb *Tablebase) ProbeWDL(pos *chess.Position) (WDL, bool) {
+	if pos.PieceCount() > tb.largest || pos.CanCastle() {
+		return Draw, false
+	}
+
+	wdl, state := tb.search(pos, false)
+	return wdl, state != probeFail
+}
+
+// ProbeDTZ returns the distance to zeroing of the position in plies,
+// positive when winning and negative when losing.
+//
+// The distance is 0 for draws, and beyond 100 plies for cursed wins
+// and blessed losses. The distance of a mated position is -1.
+//
+// Returns false if the position has too many pieces, castling rights,
+// or if a table is missing.
+func (tb *Tablebase) ProbeDTZ(pos *chess.Position) (int, bool) {
+	if pos.PieceCount() > tb.largest || pos.CanCastle() {
+		return 0, false
+	}
+
+	dtz, state := tb.probeDTZ(pos)
+	return dtz, state != probeFail
+}
+
+// ProbeRoot returns the legal moves that keep the best outcome of the position,
+// along with this outcome.
+//
+// When winning, only the moves with the smallest distance to zeroing are
+// returned so that the win is converted within the fifty-move rule. When
+// losing, only the moves with the largest distance are returned.
+//
+// Returns false if the position has too many pieces, castling rights,
+// or if a table is missing.
+func (tb *Tablebase) ProbeRoot(pos *chess.Position) ([]chess.Move, WDL, bool) {
+	if pos.PieceCount() > tb.largest || pos.CanCastle() {
+		return nil, Draw, false
+	}
+
+	meta, hash, pawnHash := pos.Metadata(), pos.Hash(), pos.PawnHash()
+	rule50 := int(pos.HalfMoveClock())
+
+	var moves []chess.Move
+	best := -maxDTZ - 1
+	for _, m := range legalMoves(pos, new(chess.MoveList)) {
+		if ok := pos.MakeMove(m); !ok {
+			continue
+		}
+		dtz, state := tb.rootDTZ(pos, m)
+		pos.UnmakeMove(m, meta, hash, pawnHash)
+
+		if state == probeFail {
+			return nil, Draw, false
+		}
+
+		switch r := rootRank(dtz, rule50); {
+		case r > best:
+			best, moves = r, append(moves[:0], m)
+		case r == best:
+			moves = append(moves, m)
+		}
+	}
+
+	switch {
+	case len(moves) == 0:
+		return nil, Draw, false
+	case best > maxDTZ/2:
+		return moves, Win, true
+	case best > 0:
+		return moves, CursedWin, true
+	case best == 0:
+		return moves, Draw, true
+	case best < -maxDTZ/2:
+		return moves, Loss, true
+	default:
+		return moves, BlessedLoss, true
+	}
+}
+
+// rootDTZ returns the distance to zeroing of the root position after the move,
+// from the point of view of the side that played it.
+func (tb *Tablebase) rootDTZ(pos *chess.Position, m chess.Move) (int, probeState) {
+	if isZeroing(m) {
+		wdl, state := tb.search(pos, false)
+		return dtzBeforeZeroing(-wdl), state
+	}
+
+	if isMate(pos) {
+		return 1, probeOK
+	}
+
+	if pos.HalfMoveClock() >= 100 {
+		return 0, probeOK
+	}
+
+	dtz, state := tb.probeDTZ(pos)
+	switch dtz = -dtz; {
+	case dtz > 0:
+		return dtz + 1, state
+	case dtz < 0:
+		return dtz - 1, state
+	default:
+		return 0, state
+	}
+}
+
+// rootRank returns the rank of a root move from its distance to zeroing.
+//
+// Wins within the fifty-move rule rank highest, by increasing distance.
+// Losses rank lowest, by decreasing distance.
+func rootRank(dtz, rule50 int) int {
+	switch {
+	case dtz > 0 && dtz+rule50 <= 100:
+		return maxDTZ - dtz
+	case dtz > 0:
+		return maxDTZ/2 - (dtz + rule50)
+	case dtz < 0 && -dtz+rule50 <= 100:
+		return -maxDTZ - dtz
+	case dtz < 0:
+		return -maxDTZ/2 + (-dtz + rule50)
+	default:
+		return 0
+	}
+}
+
+// search returns the outcome of the position, searching the captures first
+// and the pawn moves too when zeroing is set.
+//
+// Tables do not store en passant rights and may store any value
+// for positions where a capture wins, hence the search.
+func (tb *Tablebase) search(pos *chess.Position, zeroing bool) (WDL, probeState) {
+	meta, hash, pawnHash := pos.Metadata(), pos.Hash(), pos.PawnHash()
+
+	var ml chess.MoveList
+	moves := legalMoves(pos, &ml)
+
+	best, count := Loss, 0
+	for _, m := range moves {
+		if !m.HasTag(chess.Capture) && (!zeroing || m.P1().Type() != chess.Pawn) {
+			continue
+		}
+		count++
+
+		_ = pos.MakeMove(m)
+		wdl, state := tb.search(pos, false)
+		pos.UnmakeMove(m, meta, hash, pawnHash)
+
+		if state == probeFail {
+			return Draw, probeFail
+		}
+
+		if wdl = -wdl; wdl > best {
+			best = wdl
+			if wdl >= Win {
+				return wdl, probeZeroing
+			}
+		}
+	}
+
+	// when all the legal moves have been searched,
+	// the table is not probed as its value may be wrong
+	noMoreMoves := count > 0 && count == len(moves)
+	value := best
+	if !noMoreMoves {
+		v, state := tb.probeTable(pos, wdlTable, Draw)
+		if state == probeFail {
+			return Draw, probeFail
+		}
+		value = WDL(v)
+	}
+
+	if best >= value {
+		if best > Draw || noMoreMoves {
+			return best, probeZeroing
+		}
+		return best, probeOK
+	}
+
+	return value, probeOK
+}
+
+// probeDTZ returns the distance to zeroing of the position in plies.
+func (tb *Tablebase) probeDTZ(pos *chess.Position) (int, probeState) {
+	wdl, state := tb.search(pos, true)
+	switch {
+	case state == probeFail || wdl == Draw:
+		return 0, state
+	case state == probeZeroing:
+		return dtzBeforeZeroing(wdl), probeOK
+	}
+
+	dtz, state := tb.probeTable(pos, dtzTable, wdl)
+	switch state {
+	case probeFail:
+		return 0, probeFail
+	case probeOK:
+		if wdl == CursedWin || wdl == BlessedLoss {
+			dtz += 100
+		}
+		if wdl < Draw {
+			dtz = -dtz
+		}
+		return dtz, probeOK
+	}
+
+	// the table stores the other side to move, so a 1-ply search
+	// finds the move with the smallest distance that keeps the outcome
+	meta, hash, pawnHash := pos.Metadata(), pos.Hash(), pos.PawnHash()
+	best := 0xFFFF
+	for _, m := range legalMoves(pos, new(chess.MoveList)) {
+		zeroing := isZeroing(m)
+		_ = pos.MakeMove(m)
+
+		// the distance of zeroing moves is taken before the move
+		if zeroing {
+			w, s := tb.search(pos, false)
+			dtz, state = -dtzBeforeZeroing(w), s
+		} else {
+			dtz, state = tb.probeDTZ(pos)
+			dtz = -dtz
+		}
+
+		if dtz == 1 && isMate(pos) {
+			best = 1
+		}
+
+		pos.UnmakeMove(m, meta, hash, pawnHash)
+
+		if state == probeFail {
+			return 0, probeFail
+		}
+
+		if !zeroing && dtz != 0 {
+			dtz += sign(dtz)
+		}
+
+		if dtz < best && sign(dtz) == sign(int(wdl)) {
+			best = dtz
+		}
+	}
+
+	if best == 0xFFFF {
+		// no legal moves: mate
+		return -1, probeOK
+	}
+
+	return best, probeOK
+}
+
+// dtzBeforeZeroing returns the distance to zeroing of a position whose best move is zeroing.
+func dtzBeforeZeroing(wdl WDL) int {
+	switch wdl {
+	case Win:
+		return 1
+	case CursedWin:
+		return 101
+	case BlessedLoss:
+		return -101
+	case Loss:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// legalMoves returns the legal moves of the position.
+func legalMoves(pos *chess.Position, ml *chess.MoveList) []chess.Move {
+	checkData, _ := pos.InCheck()
+	return pos.AppendLegalMoves(ml[:0], checkData)
+}
+
+// isZeroing returns whether the move resets the fifty-move counter.
+func isZeroing(m chess.Move) bool {
+	return m.HasTag(chess.Capture) || m.P1().Type() == chess.Pawn
+}
+
+// isMate returns whether the side to move is checkmated.
+func isMate(pos *chess.Position) bool {
+	if _, inCheck := pos.InCheck(); !inCheck {
+		return false
+	}
+	return len(legalMoves(pos, new(chess.MoveList))) == 0
+}
+
+// sign returns the sign of the integer, 0 for 0.
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,310 @@
+package syzygy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+func TestGenerated(t *testing.T) {
+	tables, err := generateTables()
+	require.NoError(t, err)
+
+	tests := []struct {
+		fen string
+		wdl WDL
+		dtz int
+	}{
+		{"6Qk/8/6K1/8/8/8/8/8 b - - 0 1", Draw, 0},
+		{"7k/6Q1/6K1/8/8/8/8/8 b - - 0 1", Loss, -1},
+		{"7k/8/6K1/8/8/8/8/5Q2 w - - 0 1", Win, 1},
+		{"7k/8/6K1/8/8/8/8/5Q2 b - - 0 1", Loss, -4},
+		{"k7/8/1K6/8/8/8/8/7R w - - 0 1", Win, 1},
+		{"8/8/8/8/8/8/4R3/K1k5 w - - 0 1", Win, 15},
+		{"8/8/8/4k3/8/8/4P3/4K3 w - - 0 1", Draw, 0},
+		{"4k3/8/4K3/4P3/8/8/8/8 w - - 0 1", Win, 3},
+		{"4k3/8/4K3/4P3/8/8/8/8 b - - 0 1", Loss, -4},
+		{"8/1k6/8/8/8/8/P7/K7 w - - 0 1", Draw, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fen, func(t *testing.T) {
+			pos, err := chess.FEN{}.Decode(tt.fen)
+			require.NoError(t, err)
+
+			white, black := materialSides(pos)
+			g := tables[white+"v"+black]
+			var squares []int
+			for _, p := range g.pieces {
+				pos.BoardMap(func(q chess.Piece, sq chess.Square) {
+					if p == q {
+						squares = append(squares, int(sq))
+					}
+				})
+			}
+
+			i := g.index(pos.Turn(), squares)
+			assert.Equal(t, tt.wdl, g.wdl[i])
+			assert.Equal(t, tt.dtz, g.dtz[i])
+		})
+	}
+}
+
+func TestTablebase(t *testing.T) {
+	dir := t.TempDir()
+	tables := writeTables(t, dir)
+
+	tb, err := Open(dir)
+	require.NoError(t, err)
+	defer tb.Close()
+
+	assert.Equal(t, 3, tb.Largest())
+	wdlCount, dtzCount := tb.Len()
+	assert.Equal(t, 5, wdlCount)
+	assert.Equal(t, 5, dtzCount)
+
+	for _, name := range generatedNames {
+		t.Run(name, func(t *testing.T) {
+			g := tables[name]
+			// a sample of the positions keeps the test fast
+			for i, fen := range g.fens {
+				if fen == "" || i%7 != 0 {
+					continue
+				}
+
+				pos, err := chess.FEN{}.Decode(fen)
+				require.NoError(t, err)
+
+				wdl, ok := tb.ProbeWDL(pos)
+				require.True(t, ok, fen)
+				require.Equal(t, g.wdl[i], wdl, fen)
+
+				dtz, ok := tb.ProbeDTZ(pos)
+				require.True(t, ok, fen)
+				require.Equal(t, g.dtz[i], dtz, fen)
+			}
+		})
+	}
+}
+
+func TestTablebase_Flipped(t *testing.T) {
+	dir := t.TempDir()
+	writeTables(t, dir)
+
+	tb, err := Open(dir)
+	require.NoError(t, err)
+	defer tb.Close()
+
+	tests := []struct {
+		fen string
+		wdl WDL
+		dtz int
+	}{
+		{"8/8/8/8/8/8/4r3/k1K5 b - - 0 1", Win, 15},
+		{"8/8/8/8/3p4/8/4K3/4k3 b - - 0 1", Draw, 0},
+		{"8/8/8/8/4p3/4k3/8/4K3 w - - 0 1", Loss, -4},
+		{"8/8/8/8/4p3/4k3/8/4K3 b - - 0 1", Win, 3},
+		{"8/8/8/8/8/8/8/K1k5 w - - 0 1", Draw, 0},
+		{"8/8/8/8/8/8/8/K1kb4 w - - 0 1", Draw, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fen, func(t *testing.T) {
+			pos, err := chess.FEN{}.Decode(tt.fen)
+			require.NoError(t, err)
+
+			wdl, ok := tb.ProbeWDL(pos)
+			assert.True(t, ok)
+			assert.Equal(t, tt.wdl, wdl)
+
+			dtz, ok := tb.ProbeDTZ(pos)
+			assert.True(t, ok)
+			assert.Equal(t, tt.dtz, dtz)
+		})
+	}
+}
+
+func TestTablebase_ProbeRoot(t *testing.T) {
+	dir := t.TempDir()
+	writeTables(t, dir)
+
+	tb, err := Open(dir)
+	require.NoError(t, err)
+	defer tb.Close()
+
+	tests := []struct {
+		name  string
+		fen   string
+		moves []string
+		wdl   WDL
+		ok    bool
+	}{
+		{"mate in one", "7k/8/6K1/8/8/8/8/5Q2 w - - 0 1", []string{"f1f8"}, Win, true},
+		{"promotion", "8/P7/8/8/8/8/8/K1k5 w - - 0 1", []string{"a7a8q", "a7a8r"}, Win, true},
+		{"mate on the edge", "k7/2K5/8/8/8/8/8/7R w - - 0 1", []string{"h1a1"}, Win, true},
+		{"castling rights", "4k3/8/8/8/8/8/8/R3K3 w Q - 0 1", nil, Draw, false},
+		{"too many pieces", "4k3/8/8/8/8/8/P7/R3K3 w - - 0 1", nil, Draw, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos, err := chess.FEN{}.Decode(tt.fen)
+			require.NoError(t, err)
+
+			moves, wdl, ok := tb.ProbeRoot(pos)
+			var got []string
+			for _, m := range moves {
+				got = append(got, m.String())
+			}
+
+			assert.ElementsMatch(t, tt.moves, got)
+			assert.Equal(t, tt.wdl, wdl)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	writeTables(t, dir)
+
+	malformed := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(malformed, "KRvK.rtbw"), []byte("not a table"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(malformed, "KQvK.rtbw"), []byte{0x71, 0xE8, 0x23, 0x5D, 0x01}, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(malformed, "README.txt"), []byte("not a table"), 0o600))
+
+	tests := []struct {
+		name    string
+		dirs    []string
+		wdl     int
+		dtz     int
+		largest int
+		err     []error
+	}{
+		{"no directory", nil, 0, 0, 0, nil},
+		{"tables", []string{dir}, 5, 5, 3, nil},
+		{"missing directory", []string{filepath.Join(dir, "missing")}, 0, 0, 0, []error{os.ErrNotExist}},
+		{"malformed tables", []string{malformed}, 0, 0, 0, []error{errTableMagic, errTableData}},
+		{"first directory wins", []string{dir, malformed}, 5, 5, 3, nil},
+		{"malformed tables skipped", []string{malformed, dir}, 5, 5, 3, []error{errTableMagic, errTableData}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb, err := Open(tt.dirs...)
+			defer tb.Close()
+
+			if tt.err == nil {
+				assert.NoError(t, err)
+			}
+			for _, e := range tt.err {
+				assert.ErrorIs(t, err, e)
+			}
+
+			wdl, dtz := tb.Len()
+			assert.Equal(t, tt.wdl, wdl)
+			assert.Equal(t, tt.dtz, dtz)
+			assert.Equal(t, tt.largest, tb.Largest())
+		})
+	}
+}
+
+func TestTablebase_MissingTable(t *testing.T) {
+	dir := t.TempDir()
+	writeTables(t, dir)
+	require.NoError(t, os.Remove(filepath.Join(dir, "KRvK.rtbz")))
+
+	tb, err := Open(dir)
+	require.NoError(t, err)
+	defer tb.Close()
+
+	pos, err := chess.FEN{}.Decode("8/8/8/8/8/8/4R3/K1k5 w - - 0 1")
+	require.NoError(t, err)
+
+	wdl, ok := tb.ProbeWDL(pos)
+	assert.True(t, ok)
+	assert.Equal(t, Win, wdl)
+
+	_, ok = tb.ProbeDTZ(pos)
+	assert.False(t, ok)
+
+	_, _, ok = tb.ProbeRoot(pos)
+	assert.False(t, ok)
+}
+
+func TestParseTableName(t *testing.T) {
+	tests := []struct {
+		name  string
+		white string
+		black string
+		err   error
+	}{
+		{"KRvK", "KR", "K", nil},
+		{"KQRBPvKP", "KQRBP", "KP", nil},
+		{"KRvKR", "KR", "KR", nil},
+		{"KRK", "", "", errTableName},
+		{"RKvK", "", "", errTableName},
+		{"KPRvK", "", "", errTableName},
+		{"KXvK", "", "", errTableName},
+		{"KQQQvKQQQ", "", "", errTableName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			white, black, err := parseTableName(tt.name)
+			assert.Equal(t, tt.white, white)
+			assert.Equal(t, tt.black, black)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestTableSize(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  tableType
+		size []uint64 // per file
+	}{
+		{"KRvK", wdlTable, []uint64{31332}},
+		{"KRvKR", wdlTable, []uint64{31332 * 61}},
+		{"KPvK", dtzTable, []uint64{6 * 63 * 62, 6 * 63 * 62, 6 * 63 * 62, 6 * 63 * 62}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pieces, err := namePieces(tt.name)
+			require.NoError(t, err)
+
+			g := &generated{name: tt.name, pieces: pieces}
+			tb, err := g.header(tt.typ)
+			require.NoError(t, err)
+
+			for f, size := range tt.size {
+				assert.Equal(t, size, tb.get(0, f).size())
+			}
+		})
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	// the tables of test/data/syzygy are used by the tests of other packages
+	dir := t.TempDir()
+	writeTables(t, dir)
+
+	for _, name := range generatedNames {
+		for _, ext := range tableExtension {
+			want, err := os.ReadFile(filepath.Join(dir, name+ext))
+			require.NoError(t, err)
+			got, err := os.ReadFile(filepath.Join("..", "test", "data", "syzygy", name+ext))
+			require.NoError(t, err)
+			assert.Equal(t, want, got, name+ext)
+		}
+	}
+}
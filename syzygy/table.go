@@ -0,0 +1,564 @@
+package syzygy
+
+import (
+	"encoding/binary"
+	"errors"
+	"slices"
+	"strings"
+)
+
+var (
+	errTableName  = errors.New("invalid table name")
+	errTableMagic = errors.New("invalid table magic number")
+	errTableData  = errors.New("invalid table data")
+)
+
+// tableType represents the type of a table.
+type tableType uint8
+
+const (
+	wdlTable tableType = iota // wdlTable holds win/draw/loss tables (.rtbw).
+	dtzTable                  // dtzTable holds distance to zeroing tables (.rtbz).
+)
+
+// magic numbers of the table files, indexed by table type.
+var tableMagic = [2][4]byte{
+	{0x71, 0xE8, 0x23, 0x5D},
+	{0xD7, 0x66, 0x0C, 0xA5},
+}
+
+// extensions of the table files, indexed by table type.
+var tableExtension = [2]string{".rtbw", ".rtbz"}
+
+// table header flags.
+const (
+	splitFlag    = 1 // splitFlag indicates that both sides to move are stored.
+	hasPawnsFlag = 2 // hasPawnsFlag indicates that the table has pawns.
+)
+
+// pairs data flags.
+const (
+	stmFlag         = 1   // stmFlag is the side to move of a one-sided DTZ table.
+	mappedFlag      = 2   // mappedFlag indicates that the DTZ values are mapped.
+	winPliesFlag    = 4   // winPliesFlag indicates that winning DTZ values are stored in plies.
+	lossPliesFlag   = 8   // lossPliesFlag indicates that losing DTZ values are stored in plies.
+	wideFlag        = 16  // wideFlag indicates that the DTZ map holds 16-bit values.
+	singleValueFlag = 128 // singleValueFlag indicates that the table holds a single value.
+)
+
+// Pieces are encoded in the tables as in the following nibble:
+//
+//	bits 0-2    piece type, 1 for pawn to 6 for king
+//	bit 3       color, set for the second side
+const (
+	tbPawn  = 1
+	tbKing  = 6
+	tbBlack = 8
+)
+
+// tbPieceTypes holds the piece letters of the table names
+// indexed by the piece codes.
+const tbPieceTypes = " PNBRQK"
+
+// table holds a memory-mapped table file.
+type table struct {
+	typ        tableType
+	name       string
+	data       []byte
+	release    func() error
+	symmetric  bool // both sides have the same material
+	hasPawns   bool
+	unique     bool // has a piece type with a single piece, kings excluded
+	pieceCount int
+	pawnCount  [2]int          // pawns of the leading color, then of the other color
+	items      [2][4]pairsData // indexed by side, then by file of the leading pawn
+	dtzMap     int             // offset of the DTZ map
+}
+
+// pairsData holds the data of a compressed table of a side and file.
+//
+// Values are compressed with a canonical Huffman code over symbols
+// that each expand to a sequence of values through a binary tree.
+type pairsData struct {
+	flags       uint8
+	blockSize   int // block size in bytes
+	span        int // number of values per sparse index entry
+	blocks      int
+	minSymLen   int
+	lowestSym   int // offset of the lowest symbols per code length
+	btree       int // offset of the symbol tree
+	blockLength int // offset of the block lengths
+	blockLenNum int
+	sparseIndex int // offset of the sparse index
+	sparseNum   int
+	blockData   int // offset of the blocks
+	base64      []uint64
+	symlen      []int // number of values minus one of each symbol
+	pieces      [maxPieces]int
+	groupIdx    [maxPieces + 1]uint64
+	groupLen    [maxPieces + 1]int
+	mapIdx      [4]int
+}
+
+// parseTableName parses a table name such as KRvK.
+//
+// Returns the pieces of both sides.
+func parseTableName(name string) (string, string, error) {
+	white, black, ok := strings.Cut(name, "v")
+	if !ok || !validSide(white) || !validSide(black) || len(white)+len(black) > maxPieces {
+		return "", "", errTableName
+	}
+
+	return white, black, nil
+}
+
+// validSide returns whether the pieces of a side are a king followed
+// by pieces in the decreasing order of value.
+func validSide(side string) bool {
+	if len(side) == 0 || side[0] != 'K' {
+		return false
+	}
+
+	last := 0
+	for _, c := range side[1:] {
+		i := strings.IndexRune("QRBNP", c)
+		if i < last {
+			return false
+		}
+		last = i
+	}
+
+	return true
+}
+
+// newTable parses the table data.
+func newTable(typ tableType, name string, data []byte) (*table, error) {
+	t, err := newTableMaterial(typ, name)
+	if err != nil {
+		return nil, err
+	}
+
+	t.data = data
+	if err := t.init(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// newTableMaterial returns a new table without data from the material of its name.
+func newTableMaterial(typ tableType, name string) (*table, error) {
+	white, black, err := parseTableName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &table{
+		typ:        typ,
+		name:       name,
+		symmetric:  white == black,
+		hasPawns:   strings.Contains(name, "P"),
+		pieceCount: len(white) + len(black),
+	}
+
+	for _, side := range []string{white, black} {
+		for _, c := range "QRBNP" {
+			if strings.Count(side, string(c)) == 1 {
+				t.unique = true
+			}
+		}
+	}
+
+	// the leading color is the side with fewer pawns, if it has any
+	whitePawns, blackPawns := strings.Count(white, "P"), strings.Count(black, "P")
+	if blackPawns == 0 || (whitePawns > 0 && blackPawns >= whitePawns) {
+		t.pawnCount = [2]int{whitePawns, blackPawns}
+	} else {
+		t.pawnCount = [2]int{blackPawns, whitePawns}
+	}
+
+	return t, nil
+}
+
+// sides returns the number of sides to move stored in the table.
+func (t *table) sides() int {
+	if t.typ == wdlTable && !t.symmetric {
+		return 2
+	}
+	return 1
+}
+
+// files returns the number of files of the leading pawn.
+func (t *table) files() int {
+	if t.hasPawns {
+		return 4
+	}
+	return 1
+}
+
+// get returns the pairs data of the side to move and file of the leading pawn.
+func (t *table) get(stm, f int) *pairsData {
+	if !t.hasPawns {
+		f = 0
+	}
+	return &t.items[stm%t.sides()][f]
+}
+
+// init parses the table header and the layout of the pairs data.
+func (t *table) init() (err error) {
+	defer func() {
+		// malformed files are detected by out of range reads
+		if r := recover(); r != nil {
+			err = errTableData
+		}
+	}()
+
+	if len(t.data) < 5 || [4]byte(t.data[:4]) != tableMagic[t.typ] {
+		return errTableMagic
+	}
+
+	flags := t.data[4]
+	if (flags&hasPawnsFlag != 0) != t.hasPawns || (flags&splitFlag != 0) != !t.symmetric {
+		return errTableData
+	}
+
+	sides, files := t.sides(), t.files()
+	pp := t.hasPawns && t.pawnCount[1] > 0 // pawns on both sides
+	off := 5
+	for f := range files {
+		order := [2][2]int{
+			{int(t.data[off] & 0xF), 0xF},
+			{int(t.data[off] >> 4), 0xF},
+		}
+		if pp {
+			order[0][1] = int(t.data[off+1] & 0xF)
+			order[1][1] = int(t.data[off+1] >> 4)
+			off++
+		}
+		off++
+
+		for k := range t.pieceCount {
+			for i := range sides {
+				piece := t.data[off] & 0xF
+				if i > 0 {
+					piece = t.data[off] >> 4
+				}
+				t.items[i][f].pieces[k] = int(piece)
+			}
+			off++
+		}
+
+		for i := range sides {
+			t.items[i][f].setGroups(t, order[i], f)
+		}
+	}
+
+	off += off & 1
+	for f := range files {
+		for i := range sides {
+			off = t.items[i][f].setSizes(t.data, off)
+		}
+	}
+
+	if t.typ == dtzTable {
+		off = t.setDTZMap(off, files)
+	}
+
+	for f := range files {
+		for i := range sides {
+			d := &t.items[i][f]
+			d.sparseIndex = off
+			off += 6 * d.sparseNum
+		}
+	}
+
+	for f := range files {
+		for i := range sides {
+			d := &t.items[i][f]
+			d.blockLength = off
+			off += 2 * d.blockLenNum
+		}
+	}
+
+	for f := range files {
+		for i := range sides {
+			d := &t.items[i][f]
+			off = (off + 0x3F) &^ 0x3F
+			d.blockData = off
+			off += d.blocks * d.blockSize
+		}
+	}
+
+	if off > len(t.data) {
+		return errTableData
+	}
+
+	return nil
+}
+
+// setGroups sets the groups of pieces encoded together.
+//
+// The leading group holds the leading pawns, or the first three unique
+// pieces or the two kings. The next groups hold the other pawns and the
+// pieces of the same type. The order defines at which position the
+// leading group and the other pawns are encoded.
+func (d *pairsData) setGroups(t *table, order [2]int, f int) {
+	firstLen := 2
+	switch {
+	case t.hasPawns:
+		firstLen = 0
+	case t.unique:
+		firstLen = 3
+	}
+
+	n := 0
+	d.groupLen[n] = 1
+	for i := 1; i < t.pieceCount; i++ {
+		firstLen--
+		if firstLen > 0 || d.pieces[i] == d.pieces[i-1] {
+			d.groupLen[n]++
+		} else {
+			n++
+			d.groupLen[n] = 1
+		}
+	}
+	n++
+	d.groupLen[n] = 0
+
+	pp := t.hasPawns && t.pawnCount[1] > 0
+	next := 1
+	free := 64 - d.groupLen[0]
+	if pp {
+		next = 2
+		free -= d.groupLen[1]
+	}
+
+	idx := uint64(1)
+	for k := 0; next < n || k == order[0] || k == order[1]; k++ {
+		switch {
+		case k == order[0]:
+			d.groupIdx[0] = idx
+			switch {
+			case t.hasPawns:
+				idx *= uint64(leadPawnsSize[d.groupLen[0]][f])
+			case t.unique:
+				idx *= 31332
+			default:
+				idx *= 462
+			}
+		case k == order[1]:
+			d.groupIdx[1] = idx
+			idx *= uint64(binomial[d.groupLen[1]][48-d.groupLen[0]])
+		default:
+			d.groupIdx[next] = idx
+			idx *= uint64(binomial[d.groupLen[next]][free])
+			free -= d.groupLen[next]
+			next++
+		}
+	}
+
+	d.groupIdx[n] = idx
+}
+
+// size returns the number of values of the table.
+func (d *pairsData) size() uint64 {
+	n := slices.Index(d.groupLen[:], 0)
+	return d.groupIdx[n]
+}
+
+// setSizes parses the sizes of the compressed data starting at the offset.
+//
+// Returns the offset following the symbol tree.
+func (d *pairsData) setSizes(data []byte, off int) int {
+	d.flags = data[off]
+	off++
+	if d.flags&singleValueFlag != 0 {
+		d.minSymLen = int(data[off])
+		return off + 1
+	}
+
+	d.blockSize = 1 << data[off]
+	d.span = 1 << data[off+1]
+	d.sparseNum = int((d.size() + uint64(d.span) - 1) / uint64(d.span))
+	padding := int(data[off+2])
+	d.blocks = int(binary.LittleEndian.Uint32(data[off+3:]))
+	d.blockLenNum = d.blocks + padding
+	maxSymLen := int(data[off+7])
+	d.minSymLen = int(data[off+8])
+	off += 9
+
+	if maxSymLen < d.minSymLen || maxSymLen > 64 {
+		panic(errTableData)
+	}
+
+	d.lowestSym = off
+	d.base64 = make([]uint64, maxSymLen-d.minSymLen+1)
+	for i := len(d.base64) - 2; i >= 0; i-- {
+		d.base64[i] = (d.base64[i+1] + uint64(d.lowest(data, i)) - uint64(d.lowest(data, i+1))) / 2
+	}
+	for i := range d.base64 {
+		d.base64[i] <<= 64 - i - d.minSymLen
+	}
+	off += 2 * len(d.base64)
+
+	symbols := int(binary.LittleEndian.Uint16(data[off:]))
+	off += 2
+	d.btree = off
+	d.symlen = make([]int, symbols)
+	visited := make([]bool, symbols)
+	for sym := range symbols {
+		if !visited[sym] {
+			d.symlen[sym] = d.setSymlen(data, sym, visited)
+		}
+	}
+
+	return off + 3*symbols + symbols&1
+}
+
+// lowest returns the lowest symbol of the i-th code length.
+func (d *pairsData) lowest(data []byte, i int) uint16 {
+	return binary.LittleEndian.Uint16(data[d.lowestSym+2*i:])
+}
+
+// setSymlen returns the number of values minus one of the symbol.
+func (d *pairsData) setSymlen(data []byte, sym int, visited []bool) int {
+	visited[sym] = true
+	left, right := d.children(data, sym)
+	if right == 0xFFF {
+		return 0
+	}
+
+	if !visited[left] {
+		d.symlen[left] = d.setSymlen(data, left, visited)
+	}
+	if !visited[right] {
+		d.symlen[right] = d.setSymlen(data, right, visited)
+	}
+
+	return d.symlen[left] + d.symlen[right] + 1
+}
+
+// children returns the left and right symbols of the symbol.
+//
+// A symbol with a right symbol 0xFFF is a leaf whose left symbol is the value.
+func (d *pairsData) children(data []byte, sym int) (int, int) {
+	lr := data[d.btree+3*sym:]
+	return int(lr[1]&0xF)<<8 | int(lr[0]), int(lr[2])<<4 | int(lr[1]>>4)
+}
+
+// setDTZMap parses the maps of the DTZ values starting at the offset.
+//
+// Returns the offset following the maps.
+func (t *table) setDTZMap(off, files int) int {
+	t.dtzMap = off
+	for f := range files {
+		d := t.get(0, f)
+		if d.flags&mappedFlag == 0 {
+			continue
+		}
+
+		if d.flags&wideFlag != 0 {
+			off += off & 1
+			for i := range d.mapIdx {
+				d.mapIdx[i] = (off-t.dtzMap)/2 + 1
+				off += 2*int(binary.LittleEndian.Uint16(t.data[off:])) + 2
+			}
+		} else {
+			for i := range d.mapIdx {
+				d.mapIdx[i] = off - t.dtzMap + 1
+				off += int(t.data[off]) + 1
+			}
+		}
+	}
+
+	return off + off&1
+}
+
+// decompress returns the value at the index.
+func (d *pairsData) decompress(data []byte, idx uint64) int {
+	if d.flags&singleValueFlag != 0 {
+		return d.minSymLen
+	}
+
+	k := idx / uint64(d.span)
+	entry := data[d.sparseIndex+6*int(k):]
+	block := int(binary.LittleEndian.Uint32(entry))
+	offset := int(binary.LittleEndian.Uint16(entry[4:]))
+	offset += int(idx%uint64(d.span)) - d.span/2
+
+	for offset < 0 {
+		block--
+		offset += d.blockLen(data, block) + 1
+	}
+	for offset > d.blockLen(data, block) {
+		offset -= d.blockLen(data, block) + 1
+		block++
+	}
+
+	ptr := d.blockData + block*d.blockSize
+	buf := readUint64(data, ptr)
+	ptr += 8
+	bufSize := 64
+
+	var sym int
+	for {
+		length := 0
+		for buf < d.base64[length] {
+			length++
+		}
+
+		sym = int((buf-d.base64[length])>>(64-length-d.minSymLen)) + int(d.lowest(data, length))
+		if offset < d.symlen[sym]+1 {
+			break
+		}
+
+		offset -= d.symlen[sym] + 1
+		length += d.minSymLen
+		buf <<= length
+		bufSize -= length
+		if bufSize <= 32 {
+			bufSize += 32
+			buf |= uint64(readUint32(data, ptr)) << (64 - bufSize)
+			ptr += 4
+		}
+	}
+
+	for d.symlen[sym] > 0 {
+		left, right := d.children(data, sym)
+		if offset < d.symlen[left]+1 {
+			sym = left
+		} else {
+			offset -= d.symlen[left] + 1
+			sym = right
+		}
+	}
+
+	left, _ := d.children(data, sym)
+	return left
+}
+
+// blockLen returns the number of values minus one of the block.
+func (d *pairsData) blockLen(data []byte, block int) int {
+	return int(binary.LittleEndian.Uint16(data[d.blockLength+2*block:]))
+}
+
+// readUint64 reads a big endian uint64, padded with zeros past the end of the data.
+func readUint64(data []byte, off int) uint64 {
+	if off+8 <= len(data) {
+		return binary.BigEndian.Uint64(data[off:])
+	}
+	return uint64(readUint32(data, off))<<32 | uint64(readUint32(data, off+4))
+}
+
+// readUint32 reads a big endian uint32, padded with zeros past the end of the data.
+func readUint32(data []byte, off int) uint32 {
+	if off+4 <= len(data) {
+		return binary.BigEndian.Uint32(data[off:])
+	}
+
+	var buf [4]byte
+	if off < len(data) {
+		copy(buf[:], data[off:])
+	}
+	return binary.BigEndian.Uint32(buf[:])
+}
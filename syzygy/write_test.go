@@ -0,0 +1,328 @@
+package syzygy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+
+	"github.com/leonhfr/orca/chess"
+)
+
+const (
+	writeBlockSizeLog = 5 // small blocks to exercise the sparse index
+	writeSpanLog      = 6
+)
+
+// header returns the table of the type without data.
+//
+// The pieces are stored in the order of the table name, leading pawns first.
+func (g *generated) header(typ tableType) (*table, error) {
+	t, err := newTableMaterial(typ, g.name)
+	if err != nil {
+		return nil, err
+	}
+
+	var seq []int
+	for _, p := range g.pieces {
+		if p.Type() == chess.Pawn {
+			seq = append([]int{tbPiece(p)}, seq...)
+		} else {
+			seq = append(seq, tbPiece(p))
+		}
+	}
+
+	for f := range t.files() {
+		for i := range t.sides() {
+			d := t.get(i, f)
+			copy(d.pieces[:], seq)
+			d.setGroups(t, [2]int{0, 0xF}, f)
+		}
+	}
+
+	return t, nil
+}
+
+// encode encodes the generated outcomes as a table file.
+//
+// DTZ tables store white to move with distances in plies.
+func (g *generated) encode(typ tableType) ([]byte, error) {
+	t, err := g.header(typ)
+	if err != nil {
+		return nil, err
+	}
+	seq := t.get(0, 0).pieces[:t.pieceCount]
+
+	values, err := g.tableValues(t, typ)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := byte(0)
+	if !t.symmetric {
+		flags |= splitFlag
+	}
+	if t.hasPawns {
+		flags |= hasPawnsFlag
+	}
+	data := append(slices.Clone(tableMagic[typ][:]), flags)
+
+	for range t.files() {
+		data = append(data, 0)
+		for _, p := range seq {
+			data = append(data, byte(p|p<<4))
+		}
+	}
+	data = align(data, 2)
+
+	var pairs []*compressed
+	for f := range t.files() {
+		for i := range t.sides() {
+			var pairsFlags byte
+			if typ == dtzTable {
+				pairsFlags = winPliesFlag | lossPliesFlag
+			}
+			c := compress(values[i][f], pairsFlags)
+			data = append(data, c.sizes...)
+			pairs = append(pairs, c)
+		}
+	}
+
+	if typ == dtzTable {
+		data = align(data, 2)
+	}
+	for _, c := range pairs {
+		data = append(data, c.sparse...)
+	}
+	for _, c := range pairs {
+		data = append(data, c.lengths...)
+	}
+	for _, c := range pairs {
+		data = align(data, 64)
+		data = append(data, c.blocks...)
+	}
+
+	return data, nil
+}
+
+// tableValues returns the values of the table, indexed by side and file.
+//
+// Values of indexes without positions continue the previous value,
+// starting with a draw.
+func (g *generated) tableValues(t *table, typ tableType) ([2][4][]int, error) {
+	var values [2][4][]int
+	for f := range t.files() {
+		for i := range t.sides() {
+			v := make([]int, t.get(i, f).size())
+			for k := range v {
+				v[k] = -1
+			}
+			values[i][f] = v
+		}
+	}
+
+	for i, fen := range g.fens {
+		turn, _ := g.squares(i)
+		stm := boolInt(turn == chess.Black)
+		if fen == "" || stm >= t.sides() {
+			continue
+		}
+
+		value := int(g.wdl[i]) + 2
+		if typ == dtzTable {
+			value = max(abs(g.dtz[i])-1, 0)
+		}
+
+		v := values[stm][g.files[i]]
+		if idx := g.idx[i]; v[idx] >= 0 && v[idx] != value {
+			return values, fmt.Errorf("%s: conflicting value at index %d", fen, idx)
+		}
+		v[g.idx[i]] = value
+	}
+
+	for f := range t.files() {
+		for i := range t.sides() {
+			last := 2
+			if typ == dtzTable {
+				last = 0
+			}
+			for k, value := range values[i][f] {
+				if value < 0 {
+					values[i][f][k] = last
+				}
+				last = values[i][f][k]
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// compressed holds the sections of the pairs data of a side and file.
+type compressed struct {
+	sizes   []byte // flags through the symbol tree
+	sparse  []byte
+	lengths []byte
+	blocks  []byte
+}
+
+// compress compresses the values with a canonical Huffman code
+// whose symbols are single values.
+func compress(values []int, flags byte) *compressed {
+	freq := make(map[int]int)
+	for _, v := range values {
+		freq[v]++
+	}
+
+	if len(freq) == 1 {
+		return &compressed{sizes: []byte{flags | singleValueFlag, byte(values[0])}}
+	}
+
+	lengths := huffmanLengths(freq)
+	symbols := make([]int, 0, len(lengths))
+	for v := range lengths {
+		symbols = append(symbols, v)
+	}
+	// longest codes first
+	slices.SortFunc(symbols, func(a, b int) int {
+		if lengths[a] != lengths[b] {
+			return lengths[b] - lengths[a]
+		}
+		return a - b
+	})
+
+	minLen, maxLen := lengths[symbols[len(symbols)-1]], lengths[symbols[0]]
+	lowest := make([]int, maxLen-minLen+1)
+	for i := range lowest {
+		for _, s := range symbols {
+			if lengths[s] > minLen+i {
+				lowest[i]++
+			}
+		}
+	}
+
+	base := make([]int, len(lowest))
+	for i := len(base) - 2; i >= 0; i-- {
+		base[i] = (base[i+1] + lowest[i] - lowest[i+1]) / 2
+	}
+
+	codes := make(map[int]int)
+	for id, s := range symbols {
+		i := lengths[s] - minLen
+		codes[s] = base[i] + id - lowest[i]
+	}
+
+	c := &compressed{}
+	blockSize := 1 << writeBlockSizeLog
+	var counts []int
+	block := newBitWriter(blockSize)
+	for _, v := range values {
+		if !block.fits(lengths[v]) {
+			c.blocks = append(c.blocks, block.buf...)
+			counts = append(counts, block.count)
+			block = newBitWriter(blockSize)
+		}
+		block.write(codes[v], lengths[v])
+	}
+	c.blocks = append(c.blocks, block.buf...)
+	counts = append(counts, block.count)
+
+	for _, count := range counts {
+		c.lengths = binary.LittleEndian.AppendUint16(c.lengths, uint16(count-1))
+	}
+
+	span := 1 << writeSpanLog
+	for k := 0; k*span < len(values); k++ {
+		p := k*span + span/2
+		b, start := 0, 0
+		for b < len(counts)-1 && start+counts[b] <= p {
+			start += counts[b]
+			b++
+		}
+		c.sparse = binary.LittleEndian.AppendUint32(c.sparse, uint32(b))
+		c.sparse = binary.LittleEndian.AppendUint16(c.sparse, uint16(p-start))
+	}
+
+	c.sizes = []byte{flags, writeBlockSizeLog, writeSpanLog, 0}
+	c.sizes = binary.LittleEndian.AppendUint32(c.sizes, uint32(len(counts)))
+	c.sizes = append(c.sizes, byte(maxLen), byte(minLen))
+	for _, l := range lowest {
+		c.sizes = binary.LittleEndian.AppendUint16(c.sizes, uint16(l))
+	}
+	c.sizes = binary.LittleEndian.AppendUint16(c.sizes, uint16(len(symbols)))
+	for _, s := range symbols {
+		// leaves have the value on the left and 0xFFF on the right
+		c.sizes = append(c.sizes, byte(s), byte(s>>8)&0xF|0xF0, 0xFF)
+	}
+	if len(symbols)&1 != 0 {
+		c.sizes = append(c.sizes, 0)
+	}
+
+	return c
+}
+
+// huffmanLengths returns the code length of each value.
+func huffmanLengths(freq map[int]int) map[int]int {
+	type node struct {
+		weight int
+		values []int
+	}
+
+	var nodes []node
+	for v, w := range freq {
+		nodes = append(nodes, node{w, []int{v}})
+	}
+
+	lengths := make(map[int]int)
+	for len(nodes) > 1 {
+		slices.SortFunc(nodes, func(a, b node) int {
+			if a.weight != b.weight {
+				return a.weight - b.weight
+			}
+			return slices.Min(a.values) - slices.Min(b.values)
+		})
+
+		merged := node{nodes[0].weight + nodes[1].weight, append(slices.Clone(nodes[0].values), nodes[1].values...)}
+		for _, v := range merged.values {
+			lengths[v]++
+		}
+		nodes = append([]node{merged}, nodes[2:]...)
+	}
+
+	return lengths
+}
+
+// bitWriter writes codes into a block, most significant bit first.
+type bitWriter struct {
+	buf   []byte
+	bits  int
+	count int
+}
+
+// newBitWriter returns a bit writer for a block of the size in bytes.
+func newBitWriter(size int) *bitWriter {
+	return &bitWriter{buf: make([]byte, size)}
+}
+
+// fits returns whether a code of the length fits in the block.
+func (w *bitWriter) fits(length int) bool {
+	return w.bits+length <= 8*len(w.buf)
+}
+
+// write writes the code.
+func (w *bitWriter) write(code, length int) {
+	for i := length - 1; i >= 0; i-- {
+		if code>>i&1 != 0 {
+			w.buf[w.bits>>3] |= 0x80 >> (w.bits & 7)
+		}
+		w.bits++
+	}
+	w.count++
+}
+
+// align pads the data with zeros to a multiple of n bytes.
+func align(data []byte, n int) []byte {
+	for len(data)%n != 0 {
+		data = append(data, 0)
+	}
+	return data
+}
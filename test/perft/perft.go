@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"runtime"
 	"strconv"
 	"strings"
 
@@ -56,5 +57,5 @@ func perft(args []string) (chess.PerftResult, error) {
 		}
 	}
 
-	return pos.Perft(int(depth)), nil
+	return pos.Perft(int(depth), chess.WithPerftHash(64), chess.WithPerftWorkers(runtime.NumCPU())), nil
 }
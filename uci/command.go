@@ -3,6 +3,7 @@ package uci
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/leonhfr/orca/chess"
@@ -99,14 +100,24 @@ func (cmd commandSetOption) run(_ context.Context, e *search.Engine, c *Controll
 type commandUCINewGame struct{}
 
 // run implements the command interface.
-func (commandUCINewGame) run(_ context.Context, e *search.Engine, c *Controller) {
-	go func() {
-		c.position = chess.StartingPosition()
-		err := e.Init()
-		if err != nil {
-			c.logError(err)
-		}
-	}()
+//
+// The running search is stopped. The opening books learn from the previous game
+// once the search completes, if it ended in its last position.
+func (commandUCINewGame) run(ctx context.Context, e *search.Engine, c *Controller) {
+	commandStop{}.run(ctx, e, c)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := e.FinishGame(c.position); err != nil {
+		c.logError(err)
+	}
+
+	c.position = chess.StartingPosition()
+	c.history = nil
+	if err := e.Init(); err != nil {
+		c.logError(err)
+	}
 }
 
 // commandPosition represents a "position" command.
@@ -120,6 +131,7 @@ type commandPosition struct {
 func (cmd commandPosition) run(_ context.Context, _ *search.Engine, c *Controller) {
 	if cmd.startPos {
 		c.position = chess.StartingPosition()
+		c.history = nil
 	} else if len(cmd.fen) > 0 {
 		pos, err := c.notation.Decode(cmd.fen)
 		if err != nil {
@@ -127,6 +139,7 @@ func (cmd commandPosition) run(_ context.Context, _ *search.Engine, c *Controlle
 			return
 		}
 		c.position = pos
+		c.history = nil
 	}
 
 	for _, move := range cmd.moves {
@@ -136,10 +149,12 @@ func (cmd commandPosition) run(_ context.Context, _ *search.Engine, c *Controlle
 			return
 		}
 
+		hash := c.position.Hash()
 		if ok := c.position.MakeMove(m); !ok {
 			c.logError(fmt.Errorf("failed to play move %s", move))
 			return
 		}
+		c.history = append(c.history, hash)
 	}
 
 	c.logDebug("position set to FEN ", c.position.String())
@@ -162,15 +177,27 @@ type commandGo struct {
 }
 
 // run implements the command interface.
+//
+// Infinite searches hold the best move until the stop command, even when
+// the search completes before. Searches without time limit are stopped
+// after an hour, except for infinite searches and in analysis mode.
 func (cmd commandGo) run(ctx context.Context, e *search.Engine, c *Controller) {
 	c.mu.Lock()
 	start := time.Now()
-	ctx, cancel := searchContext(ctx, c.stop, cmd.moveTime)
 
+	limit := cmd.moveTime
+	if limit == 0 && !cmd.infinite && !e.AnalyseMode() {
+		limit = time.Hour
+	}
+	ctx, cancel := searchContext(ctx, limit)
+	c.setCancel(cancel)
+
+	e.SetHistory(c.history)
 	outputs := e.Search(ctx, c.position, cmd.depth, cmd.nodes)
 
 	go func() {
 		defer c.mu.Unlock()
+		defer c.setCancel(nil)
 		defer cancel()
 
 		var output search.Output
@@ -180,30 +207,121 @@ func (cmd commandGo) run(ctx context.Context, e *search.Engine, c *Controller) {
 				time:   time.Since(start),
 			})
 		}
+
+		if cmd.infinite {
+			<-ctx.Done()
+		}
+
 		if len(output.PV) > 0 {
 			c.respond(responseBestMove{output.PV[0]})
 		}
 	}()
 }
 
-// searchContext creates a new context that is cancelled when
-// a struct is emitted on the stop channel.
-func searchContext(ctx context.Context, stop <-chan struct{}, limit time.Duration) (context.Context, context.CancelFunc) {
-	if limit == 0 {
-		limit = time.Hour
+// searchContext creates a new context that is cancelled after the limit.
+//
+// A zero limit does not time out.
+func searchContext(ctx context.Context, limit time.Duration) (context.Context, context.CancelFunc) {
+	if limit > 0 {
+		return context.WithTimeout(ctx, limit)
 	}
-	ctx, cancel := context.WithTimeout(ctx, limit)
+	return context.WithCancel(ctx)
+}
+
+// commandEval represents a non-standard "eval" command.
+type commandEval struct{}
 
+// run implements the command interface.
+//
+// The evaluation waits for the running search to complete.
+func (commandEval) run(_ context.Context, e *search.Engine, c *Controller) {
+	pos := c.position
 	go func() {
-		select {
-		case <-ctx.Done():
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.respond(responseEval{e.Trace(pos)})
+	}()
+}
+
+// commandSaveTable represents a non-standard "savetable" command.
+type commandSaveTable struct {
+	path string
+}
+
+// run implements the command interface.
+//
+// The transposition table is saved once the running search completes.
+func (cmd commandSaveTable) run(_ context.Context, e *search.Engine, c *Controller) {
+	go func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		f, err := os.Create(cmd.path)
+		if err != nil {
+			c.logError(err)
 			return
-		case <-stop:
-			cancel()
 		}
+
+		err = e.SaveTable(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			c.logError(err)
+			return
+		}
+
+		c.respond(responseInfoString{"table saved to " + cmd.path})
 	}()
+}
 
-	return ctx, cancel
+// commandLoadTable represents a non-standard "loadtable" command.
+type commandLoadTable struct {
+	path string
+}
+
+// run implements the command interface.
+//
+// The transposition table is loaded once the running search completes.
+func (cmd commandLoadTable) run(_ context.Context, e *search.Engine, c *Controller) {
+	go func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		f, err := os.Open(cmd.path)
+		if err != nil {
+			c.logError(err)
+			return
+		}
+		defer f.Close()
+
+		if err := e.LoadTable(f); err != nil {
+			c.logError(err)
+			return
+		}
+
+		c.respond(responseInfoString{"table loaded from " + cmd.path})
+	}()
+}
+
+// commandResult represents a non-standard "result" command.
+type commandResult struct {
+	result chess.LearnResult // result of the game from White's point of view
+}
+
+// run implements the command interface.
+//
+// The running search is stopped. The opening books learn the result
+// of the game once the search completes.
+func (cmd commandResult) run(ctx context.Context, e *search.Engine, c *Controller) {
+	commandStop{}.run(ctx, e, c)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := e.FinishGameWithResult(cmd.result); err != nil {
+		c.logError(err)
+	}
 }
 
 // commandStop represents a "stop" command.
@@ -211,10 +329,7 @@ type commandStop struct{}
 
 // run implements the command interface.
 func (commandStop) run(_ context.Context, _ *search.Engine, c *Controller) {
-	select {
-	case c.stop <- struct{}{}:
-	default:
-	}
+	c.stopSearch()
 }
 
 // commandQuit represents a "quit" command.
@@ -224,8 +339,11 @@ type commandQuit struct{}
 func (commandQuit) run(ctx context.Context, e *search.Engine, c *Controller) {
 	commandStop{}.run(ctx, e, c)
 
-	e.Close()
-
 	// prevents future searches and ensures all search routines have been shut down
 	c.mu.Lock()
+
+	if err := e.FinishGame(c.position); err != nil {
+		c.logError(err)
+	}
+	e.Close()
 }
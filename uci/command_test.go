@@ -3,6 +3,8 @@ package uci
 import (
 	"context"
 	"io"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -26,13 +28,14 @@ func TestCommandUCI(t *testing.T) {
 	w := &strings.Builder{}
 	c := NewController(name, author, w)
 
-	expected := concatenateResponses(c, []response{
-		responseID{name, author},
-		availableSearchOptions[0].response(),
-		availableSearchOptions[1].response(),
-		availableUCIOptions[0].response(),
-		responseUCIOK{},
-	})
+	responses := []response{responseID{name, author}}
+	for _, option := range availableSearchOptions {
+		responses = append(responses, option.response())
+	}
+	for _, option := range availableUCIOptions {
+		responses = append(responses, option.response())
+	}
+	expected := concatenateResponses(c, append(responses, responseUCIOK{}))
 
 	commandUCI{}.run(context.Background(), e, c)
 
@@ -63,19 +66,29 @@ var _ command = commandIsReady{}
 func TestCommandIsReady(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
-		rr []response
+		options []search.Option
+		errs    []string
+		rr      []response
 	}{
-		{[]response{responseReadyOK{}}},
+		{nil, nil, []response{responseReadyOK{}}},
+		{
+			[]search.Option{search.WithBookFiles("missing.bin", "missing2.bin")},
+			[]string{
+				"info string book file missing.bin: open missing.bin: no such file or directory",
+				"info string book file missing2.bin: open missing2.bin: no such file or directory",
+			},
+			[]response{responseReadyOK{}},
+		},
 	}
 
 	for i, tt := range tests {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
 			t.Parallel()
-			e := search.NewEngine()
+			e := search.NewEngine(tt.options...)
 
 			c := NewController("", "", io.Discard)
-			want := concatenateResponses(c, tt.rr)
-			w := newMockWaitWriter(len(tt.rr))
+			want := concatenateStrings(tt.errs) + concatenateResponses(c, tt.rr)
+			w := newMockWaitWriter(len(tt.errs) + len(tt.rr))
 			c.writer = w
 
 			commandIsReady{}.run(context.Background(), e, c)
@@ -106,6 +119,11 @@ func TestCommandSetOption(t *testing.T) {
 			commandSetOption{"NAME", "VALUE"},
 			[]string{"info string option name not found"},
 		},
+		{
+			"string option",
+			commandSetOption{"BookFile", "<empty>"},
+			[]string{},
+		},
 	}
 
 	for _, tt := range tests {
@@ -125,6 +143,18 @@ func TestCommandSetOption(t *testing.T) {
 // compile time check that commandUCINewGame implements command.
 var _ command = commandUCINewGame{}
 
+func TestCommandUCINewGame(t *testing.T) {
+	t.Parallel()
+	e := search.NewEngine(search.WithTableSize(1))
+	c := NewController("", "", io.Discard)
+
+	commandPosition{startPos: true, moves: []string{"e2e4"}}.run(context.Background(), e, c)
+	commandUCINewGame{}.run(context.Background(), e, c)
+
+	assert.Equal(t, chess.StartingPosition().String(), c.position.String())
+	assert.Empty(t, c.history)
+}
+
 // compile time check that commandPosition implements command.
 var _ command = commandPosition{}
 
@@ -183,17 +213,30 @@ func TestCommandPosition(t *testing.T) {
 	}
 }
 
+func TestCommandPosition_History(t *testing.T) {
+	t.Parallel()
+	e := search.NewEngine()
+	c := NewController("", "", io.Discard)
+
+	commandPosition{startPos: true, moves: []string{"g1f3", "g8f6", "f3g1", "f6g8"}}.run(context.Background(), e, c)
+	assert.Len(t, c.history, 4)
+	assert.Equal(t, chess.StartingPosition().Hash(), c.history[0])
+	assert.Equal(t, c.history[0], c.position.Hash())
+
+	commandPosition{startPos: true}.run(context.Background(), e, c)
+	assert.Empty(t, c.history)
+}
+
 // compile time check that commandGo implements command.
 var _ command = commandGo{}
 
 func TestCommandGo(t *testing.T) {
 	t.Parallel()
-	m1 := chess.Move(chess.A2) ^ chess.Move(chess.A3)<<6 ^ chess.Move(chess.NoPiece)<<20
-	m2 := chess.Move(chess.E2) ^ chess.Move(chess.E4)<<6 ^ chess.Move(chess.NoPiece)<<20
-	m3 := chess.Move(chess.A7) ^ chess.Move(chess.A6)<<6 ^ chess.Move(chess.NoPiece)<<20
+	m1 := chess.Move(chess.E2) ^ chess.Move(chess.E4)<<6 ^ chess.Move(chess.NoPiece)<<20
+	m2 := chess.Move(chess.E7) ^ chess.Move(chess.E5)<<6 ^ chess.Move(chess.NoPiece)<<20
 
-	output1 := search.Output{Depth: 1, Nodes: 71, Score: 51, PV: []chess.Move{m1}}
-	output2 := search.Output{Depth: 2, Nodes: 391, Score: 6, PV: []chess.Move{m2, m3}}
+	output1 := search.Output{Depth: 1, Nodes: 47, Score: 241, PV: []chess.Move{m1}}
+	output2 := search.Output{Depth: 2, Nodes: 181, Score: 6, PV: []chess.Move{m1, m2}}
 
 	tests := []struct {
 		c  commandGo
@@ -206,7 +249,7 @@ func TestCommandGo(t *testing.T) {
 			[]response{
 				responseOutput{Output: output1, time: 1 * time.Nanosecond},
 				responseOutput{Output: output2, time: 1 * time.Nanosecond},
-				responseBestMove{m2},
+				responseBestMove{m1},
 			},
 		},
 	}
@@ -232,72 +275,186 @@ func TestCommandGo(t *testing.T) {
 	}
 }
 
-// compile time check that commandStop implements command.
-var _ command = commandStop{}
+func TestCommandGo_Infinite(t *testing.T) {
+	t.Parallel()
+	e := search.NewEngine()
+	c := NewController("", "", io.Discard)
+	w := newMockWaitWriter(1)
+	c.writer = w
 
-func TestCommandStop(t *testing.T) {
+	commandGo{depth: 1, infinite: true}.run(context.Background(), e, c)
+	w.Wait()
+
+	time.Sleep(50 * time.Millisecond)
+	assert.NotContains(t, w.String(), "bestmove")
+
+	w.wg.Add(1)
+	commandStop{}.run(context.Background(), e, c)
+	w.Wait()
+
+	assert.Contains(t, w.String(), "bestmove")
+}
+
+func TestCommandGo_InfiniteStop(t *testing.T) {
+	t.Parallel()
+	for _, depth := range []int{1, 0} {
+		t.Run(strconv.Itoa(depth), func(t *testing.T) {
+			t.Parallel()
+			e := search.NewEngine()
+			c := NewController("", "", io.Discard)
+			var b strings.Builder
+			c.writer = &b
+
+			// the stop is sent before the search waits for it
+			commandGo{depth: depth, infinite: true}.run(context.Background(), e, c)
+			commandStop{}.run(context.Background(), e, c)
+
+			done := make(chan struct{})
+			go func() {
+				c.mu.Lock()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(10 * time.Second):
+				t.Fatal("search not stopped")
+			}
+
+			assert.Contains(t, b.String(), "bestmove")
+		})
+	}
+}
+
+// compile time check that commandEval implements command.
+var _ command = commandEval{}
+
+func TestCommandEval(t *testing.T) {
 	t.Parallel()
 	e := search.NewEngine()
 	c := NewController("", "", io.Discard)
+	pos, err := chess.NewPosition("rnb1kbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 1")
+	assert.NoError(t, err)
+	c.position = pos
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	w := newMockWaitWriter(1)
+	c.writer = w
 
-	wg := sync.WaitGroup{}
-	wg.Add(1)
+	commandEval{}.run(context.Background(), e, c)
+	w.Wait()
 
-	var stopCalled bool
+	assert.Equal(t, concatenateResponses(c, []response{responseEval{e.Trace(pos)}}), w.String())
+	assert.Contains(t, w.String(), "Final evaluation: +")
+}
 
-	go func() {
-		defer wg.Done()
+// compile time check that commandSaveTable implements command.
+var _ command = commandSaveTable{}
 
-		select {
-		case <-ctx.Done():
-			return
-		case <-c.stop:
-			stopCalled = true
-		}
-	}()
+// compile time check that commandLoadTable implements command.
+var _ command = commandLoadTable{}
 
-	time.Sleep(10 * time.Millisecond)
-	commandStop{}.run(context.Background(), e, c)
-	wg.Wait()
+func TestCommandSaveLoadTable(t *testing.T) {
+	t.Parallel()
+	e := search.NewEngine(search.WithTableSize(1))
+	c := NewController("", "", io.Discard)
+	path := filepath.Join(t.TempDir(), "orca.tt")
+
+	w := newMockWaitWriter(1)
+	c.writer = w
+	commandSaveTable{path}.run(context.Background(), e, c)
+	w.Wait()
+	assert.Equal(t, concatenateResponses(c, []response{responseInfoString{"table saved to " + path}}), w.String())
+
+	w = newMockWaitWriter(1)
+	c.writer = w
+	commandLoadTable{path}.run(context.Background(), e, c)
+	w.Wait()
+	assert.Equal(t, concatenateResponses(c, []response{responseInfoString{"table loaded from " + path}}), w.String())
+
+	w = newMockWaitWriter(1)
+	c.writer = w
+	commandLoadTable{filepath.Join(t.TempDir(), "missing.tt")}.run(context.Background(), e, c)
+	w.Wait()
+	assert.Contains(t, w.String(), "info string open")
+}
+
+// compile time check that commandResult implements command.
+var _ command = commandResult{}
+
+func TestCommandResult(t *testing.T) {
+	t.Parallel()
+	pos := chess.StartingPosition()
+	bb := chess.NewBookBuilder()
+	bb.AddEntry(chess.BookEntry{Key: pos.Hash(), Move: uint16(chess.E4) | uint16(chess.E2)<<6, Weight: 8})
+	path := filepath.Join(t.TempDir(), "book.bin")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	_, err = bb.WriteTo(f)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	e := search.NewEngine(
+		search.WithTableSize(1),
+		search.WithOwnBook(true),
+		search.WithBookFiles(path),
+		search.WithBookLearning(true),
+	)
+	c := NewController("", "", io.Discard)
+	w := newMockWaitWriter(2)
+	c.writer = w
+
+	commandGo{depth: 1}.run(context.Background(), e, c)
+	w.Wait()
+	assert.Contains(t, w.String(), "bestmove e2e4")
+
+	// the game is lost after the book move
+	commandResult{chess.LearnLoss}.run(context.Background(), e, c)
+	e.Close()
+
+	book, err := chess.OpenBook(path)
+	assert.NoError(t, err)
+	defer book.Close()
 
-	assert.True(t, stopCalled)
+	moves := book.Lookup(pos)
+	if assert.Len(t, moves, 1) {
+		assert.Equal(t, 7, moves[0].Weight)
+		assert.Equal(t, uint32(1<<16), moves[0].Learn)
+	}
 }
 
-// compile time check that commandQuit implements command.
-var _ command = commandQuit{}
+// compile time check that commandStop implements command.
+var _ command = commandStop{}
 
-func TestCommandQuit(t *testing.T) {
+func TestCommandStop(t *testing.T) {
 	t.Parallel()
 	e := search.NewEngine()
 	c := NewController("", "", io.Discard)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// no search running
+	commandStop{}.run(context.Background(), e, c)
+
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	c.setCancel(cancel)
 
-	wg := sync.WaitGroup{}
-	wg.Add(1)
+	commandStop{}.run(context.Background(), e, c)
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+}
 
-	var stopCalled bool
+// compile time check that commandQuit implements command.
+var _ command = commandQuit{}
 
-	go func() {
-		defer wg.Done()
+func TestCommandQuit(t *testing.T) {
+	t.Parallel()
+	e := search.NewEngine()
+	c := NewController("", "", io.Discard)
 
-		select {
-		case <-ctx.Done():
-			return
-		case <-c.stop:
-			stopCalled = true
-		}
-	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.setCancel(cancel)
 
-	time.Sleep(10 * time.Millisecond)
 	commandQuit{}.run(context.Background(), e, c)
-	wg.Wait()
-
-	assert.True(t, stopCalled)
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
 }
 
 // concatenateStrings concatenate strings and adds a newline.
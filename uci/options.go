@@ -3,7 +3,10 @@ package uci
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/leonhfr/orca/search"
 )
@@ -14,6 +17,8 @@ type optionType uint8
 const (
 	integerOptionType optionType = iota // OptionInteger represents an integer option.
 	booleanOptionType                   // OptionBoolean represents a boolean option.
+	stringOptionType                    // OptionString represents a string option.
+	comboOptionType                     // OptionCombo represents a combo option.
 )
 
 var (
@@ -21,7 +26,15 @@ var (
 	availableUCIOptions = []uciOption{chess960Option}
 
 	// availableSearchOptions holds all the search available options.
-	availableSearchOptions = []searchOption{tableSizeOption, ownBookOption}
+	availableSearchOptions = []searchOption{
+		tableSizeOption, pawnTableSizeOption, evalTableSizeOption, ownBookOption, bookFileOption,
+		bookPolicyOption, bookTemperatureOption, bookThresholdOption,
+		bookDepthOption, bookVarietyOption, bookVerifyDepthOption,
+		bookLearningOption, syzygyPathOption, evalFileOption,
+		useNNUEOption, nnueFileOption, skillLevelOption,
+		limitStrengthOption, eloOption, contemptOption,
+		analyseModeOption,
+	}
 
 	// chess960Option represents the chess mode, classic or Chess960.
 	chess960Option = booleanUCIOption{
@@ -39,6 +52,24 @@ var (
 		fn:   search.WithTableSize,
 	}
 
+	// pawnTableSizeOption represents the size of the pawn hash table.
+	pawnTableSizeOption = integerSearchOption{
+		name: "PawnHash",
+		def:  1,
+		min:  1,
+		max:  1024,
+		fn:   search.WithPawnTableSize,
+	}
+
+	// evalTableSizeOption represents the size of the evaluation hash table.
+	evalTableSizeOption = integerSearchOption{
+		name: "EvalHash",
+		def:  4,
+		min:  1,
+		max:  1024,
+		fn:   search.WithEvalTableSize,
+	}
+
 	// ownBook represents whether the search engine should use its own opening book.
 	ownBookOption = booleanSearchOption{
 		name: "OwnBook",
@@ -46,8 +77,166 @@ var (
 		fn:   search.WithOwnBook,
 	}
 
+	// bookFileOption represents the paths of the Polyglot opening books,
+	// separated by the OS path list separator and consulted in priority order.
+	bookFileOption = stringSearchOption{
+		name: "BookFile",
+		def:  "",
+		fn: func(value string) search.Option {
+			var paths []string
+			for _, path := range filepath.SplitList(value) {
+				if path = strings.TrimSpace(path); path != "" {
+					paths = append(paths, path)
+				}
+			}
+			return search.WithBookFiles(paths...)
+		},
+	}
+
+	// bookPolicyOption represents the selection policy of the book moves.
+	bookPolicyOption = comboSearchOption{
+		name: "BookPolicy",
+		def:  "weighted",
+		vars: []string{"best", "weighted", "uniform"},
+		fn: func(value string) search.Option {
+			return search.WithBookPolicy(bookPolicies[value])
+		},
+	}
+
+	// bookTemperatureOption represents the temperature in percent of the weighted book policy.
+	bookTemperatureOption = integerSearchOption{
+		name: "BookTemperature",
+		def:  100,
+		min:  1,
+		max:  1000,
+		fn:   search.WithBookTemperature,
+	}
+
+	// bookThresholdOption represents the weight threshold in percent of the best weight
+	// of the uniform book policy.
+	bookThresholdOption = integerSearchOption{
+		name: "BookThreshold",
+		def:  50,
+		min:  0,
+		max:  100,
+		fn:   search.WithBookThreshold,
+	}
+
+	// bookDepthOption represents the last full move at which the book is used.
+	bookDepthOption = integerSearchOption{
+		name: "BookDepth",
+		def:  255,
+		min:  0,
+		max:  255,
+		fn:   search.WithBookDepth,
+	}
+
+	// bookVarietyOption represents the number of best weighted book moves considered.
+	bookVarietyOption = integerSearchOption{
+		name: "BookVariety",
+		def:  0,
+		min:  0,
+		max:  100,
+		fn:   search.WithBookVariety,
+	}
+
+	// bookVerifyDepthOption represents the depth of the search verifying the book moves.
+	bookVerifyDepthOption = integerSearchOption{
+		name: "BookVerifyDepth",
+		def:  0,
+		min:  0,
+		max:  16,
+		fn:   search.WithBookVerification,
+	}
+
+	// bookLearningOption represents whether the opening book files learn from the games played.
+	bookLearningOption = booleanSearchOption{
+		name: "BookLearning",
+		def:  false,
+		fn:   search.WithBookLearning,
+	}
+
+	// syzygyPathOption represents the directories of the Syzygy tablebases,
+	// separated by the OS path list separator.
+	syzygyPathOption = stringSearchOption{
+		name: "SyzygyPath",
+		def:  "",
+		fn:   search.WithSyzygyPath,
+	}
+
+	// evalFileOption represents the path of a file holding the evaluation weights.
+	evalFileOption = stringSearchOption{
+		name: "EvalFile",
+		def:  "",
+		fn:   search.WithEvalFile,
+	}
+
+	// useNNUEOption represents whether the search engine evaluates positions with the NNUE network.
+	// The network is read from the file of nnueFileOption, which is required.
+	useNNUEOption = booleanSearchOption{
+		name: "UseNNUE",
+		def:  false,
+		fn:   search.WithNNUE,
+	}
+
+	// nnueFileOption represents the path of the NNUE network file.
+	nnueFileOption = stringSearchOption{
+		name: "NNUEFile",
+		def:  "",
+		fn:   search.WithNNUEFile,
+	}
+
+	// skillLevelOption represents the skill level of the search engine.
+	skillLevelOption = integerSearchOption{
+		name: "Skill Level",
+		def:  20,
+		min:  0,
+		max:  20,
+		fn:   search.WithSkillLevel,
+	}
+
+	// limitStrengthOption represents whether the strength is limited to the Elo rating.
+	limitStrengthOption = booleanSearchOption{
+		name: "UCI_LimitStrength",
+		def:  false,
+		fn:   search.WithLimitStrength,
+	}
+
+	// eloOption represents the Elo rating of the limited strength.
+	eloOption = integerSearchOption{
+		name: "UCI_Elo",
+		def:  1500,
+		min:  search.MinElo,
+		max:  search.MaxElo,
+		fn:   search.WithElo,
+	}
+
+	// contemptOption represents the contempt of the search engine in centipawns.
+	contemptOption = integerSearchOption{
+		name: "Contempt",
+		def:  0,
+		min:  -100,
+		max:  100,
+		fn:   search.WithContempt,
+	}
+
+	// analyseModeOption represents whether the search engine analyses positions.
+	analyseModeOption = booleanSearchOption{
+		name: "UCI_AnalyseMode",
+		def:  false,
+		fn:   search.WithAnalyseMode,
+	}
+
+	// bookPolicies maps the values of the book policy option to book policies.
+	bookPolicies = map[string]search.BookPolicy{
+		"best":     search.BookBest,
+		"weighted": search.BookWeighted,
+		"uniform":  search.BookUniform,
+	}
+
 	errOptionName   = errors.New("option name not found")
 	errOutsideBound = errors.New("option value outside bounds")
+	errComboValue   = errors.New("option value not found")
 )
 
 // option is the interface implemented by all options.
@@ -189,3 +378,87 @@ func (o booleanSearchOption) optionFunc(value string) (func(*search.Engine), err
 
 	return o.fn(v), nil
 }
+
+// stringSearchOption represents a string option.
+//
+// The empty string is represented by "<empty>".
+type stringSearchOption struct {
+	name string
+	def  string
+	fn   func(string) search.Option
+}
+
+// emptyString is the UCI representation of an empty string option.
+const emptyString = "<empty>"
+
+// String implements the searchOption interface.
+func (o stringSearchOption) String() string {
+	return o.name
+}
+
+// response implements the searchOption interface.
+func (o stringSearchOption) response() responseOption {
+	def := o.def
+	if def == "" {
+		def = emptyString
+	}
+
+	return responseOption{
+		Type:    stringOptionType,
+		Name:    o.name,
+		Default: def,
+	}
+}
+
+// defaultFunc implements the searchOption interface.
+func (o stringSearchOption) defaultFunc() func(*search.Engine) {
+	return o.fn(o.def)
+}
+
+// optionFunc implements the searchOption interface.
+func (o stringSearchOption) optionFunc(value string) (func(*search.Engine), error) {
+	if value == emptyString {
+		value = ""
+	}
+
+	return o.fn(value), nil
+}
+
+// comboSearchOption represents a combo option.
+//
+//nolint:govet
+type comboSearchOption struct {
+	name string
+	def  string
+	vars []string
+	fn   func(string) search.Option
+}
+
+// String implements the searchOption interface.
+func (o comboSearchOption) String() string {
+	return o.name
+}
+
+// response implements the searchOption interface.
+func (o comboSearchOption) response() responseOption {
+	return responseOption{
+		Type:    comboOptionType,
+		Name:    o.name,
+		Default: o.def,
+		Vars:    o.vars,
+	}
+}
+
+// defaultFunc implements the searchOption interface.
+func (o comboSearchOption) defaultFunc() func(*search.Engine) {
+	return o.fn(o.def)
+}
+
+// optionFunc implements the searchOption interface.
+func (o comboSearchOption) optionFunc(value string) (func(*search.Engine), error) {
+	if !slices.Contains(o.vars, value) {
+		return func(_ *search.Engine) {}, errComboValue
+	}
+
+	return o.fn(value), nil
+}
@@ -19,6 +19,12 @@ var _ searchOption = integerSearchOption{}
 // compile time check that booleanSearchOption implements searchOption.
 var _ searchOption = booleanSearchOption{}
 
+// compile time check that stringSearchOption implements searchOption.
+var _ searchOption = stringSearchOption{}
+
+// compile time check that comboSearchOption implements searchOption.
+var _ searchOption = comboSearchOption{}
+
 func TestOptionBooleanString(t *testing.T) {
 	t.Parallel()
 	assert.Equal(t, chess960Option.name, chess960Option.String())
@@ -80,3 +86,33 @@ func TestOptionBooleanOptionFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestOptionStringUCI(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, responseOption{
+		Type:    stringOptionType,
+		Name:    bookFileOption.name,
+		Default: "<empty>",
+	}, bookFileOption.response())
+}
+
+func TestOptionComboOptionFunc(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		args string
+		want error
+	}{
+		{"best", nil},
+		{"weighted", nil},
+		{"uniform", nil},
+		{"foobar", errComboValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			t.Parallel()
+			_, err := bookPolicyOption.optionFunc(tt.args)
+			assert.Equal(t, tt.want, err)
+		})
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/leonhfr/orca/chess"
 )
 
 const fenFields = 6
@@ -39,6 +41,20 @@ top:
 		if len(command) > 1 {
 			return parseCommandGo(command[index+1:])
 		}
+	case "eval":
+		return commandEval{}
+	case "savetable":
+		if len(command) > index+1 {
+			return commandSaveTable{path: strings.Join(command[index+1:], " ")}
+		}
+	case "loadtable":
+		if len(command) > index+1 {
+			return commandLoadTable{path: strings.Join(command[index+1:], " ")}
+		}
+	case "result":
+		if result, ok := gameResults[strings.Join(command[index+1:], " ")]; ok {
+			return commandResult{result}
+		}
 	case "stop":
 		return commandStop{}
 	case "quit":
@@ -54,13 +70,32 @@ top:
 	return nil
 }
 
+// gameResults maps the results of the result command to the results from White's point of view.
+var gameResults = map[string]chess.LearnResult{
+	"1-0":     chess.LearnWin,
+	"0-1":     chess.LearnLoss,
+	"1/2-1/2": chess.LearnDraw,
+}
+
 // parseCommandSetOption parses setoption UCI commands.
+//
+// Names and values may contain spaces, the value may be omitted.
 func parseCommandSetOption(command []string) commandSetOption {
 	var c commandSetOption
-	if len(command) >= 4 && command[0] == "name" && command[2] == "value" {
-		c.name = command[1]
-		c.value = command[3]
+	if len(command) < 2 || command[0] != "name" {
+		return c
 	}
+
+	name := command[1:]
+	for i, field := range name {
+		if field == "value" {
+			c.value = strings.Join(name[i+1:], " ")
+			name = name[:i]
+			break
+		}
+	}
+	c.name = strings.Join(name, " ")
+
 	return c
 }
 
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/leonhfr/orca/chess"
 )
 
 func TestParse(t *testing.T) {
@@ -23,6 +25,9 @@ func TestParse(t *testing.T) {
 		{name: "debug off", args: "debug off", want: commandDebug{on: false}},
 		{name: "isready", args: "isready", want: commandIsReady{}},
 		{name: "setoption", args: "setoption name NAME value VALUE", want: commandSetOption{name: "NAME", value: "VALUE"}},
+		{name: "setoption", args: "setoption name LONG NAME value LONG VALUE", want: commandSetOption{name: "LONG NAME", value: "LONG VALUE"}},
+		{name: "setoption", args: "setoption name NAME value", want: commandSetOption{name: "NAME"}},
+		{name: "setoption", args: "setoption name NAME", want: commandSetOption{name: "NAME"}},
 		{name: "ucinewgame", args: "ucinewgame", want: commandUCINewGame{}},
 		{name: "position", args: "position startpos", want: commandPosition{startPos: true}},
 		{name: "position", args: "position fen " + fen, want: commandPosition{fen: fen}},
@@ -62,6 +67,12 @@ func TestParse(t *testing.T) {
 				nodes: 1024,
 			},
 		},
+		{name: "eval", args: "eval", want: commandEval{}},
+		{name: "savetable", args: "savetable /tmp/orca.tt", want: commandSaveTable{path: "/tmp/orca.tt"}},
+		{name: "loadtable", args: "loadtable /tmp/my tables/orca.tt", want: commandLoadTable{path: "/tmp/my tables/orca.tt"}},
+		{name: "loadtable without path", args: "loadtable", want: nil},
+		{name: "result", args: "result 1/2-1/2", want: commandResult{chess.LearnDraw}},
+		{name: "invalid result", args: "result 2-0", want: nil},
 		{name: "stop", args: "stop", want: commandStop{}},
 		{name: "quit", args: "quit", want: commandQuit{}},
 		{name: "unknown", args: "foo bar", want: nil},
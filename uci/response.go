@@ -49,6 +49,24 @@ func (r responseBestMove) format(c *Controller) string {
 	return "bestmove " + c.moveNotation.Encode(c.position, r.move)
 }
 
+// responseInfoString represents an "info string" command.
+type responseInfoString struct {
+	text string
+}
+
+func (r responseInfoString) format(_ *Controller) string {
+	return "info string " + r.text
+}
+
+// responseEval represents the output of a non-standard "eval" command.
+type responseEval struct {
+	search.EvalTrace
+}
+
+func (r responseEval) format(_ *Controller) string {
+	return r.String()
+}
+
 // responseInfo represents an "info" command.
 type responseOutput struct {
 	search.Output
@@ -64,6 +82,9 @@ func (o responseOutput) format(c *Controller) string {
 	if o.Nodes > 0 {
 		res = append(res, "nodes", strconv.Itoa(o.Nodes))
 	}
+	if o.TBHits > 0 {
+		res = append(res, "tbhits", strconv.Itoa(o.TBHits))
+	}
 	if o.Mate != 0 {
 		res = append(res, "score mate", strconv.Itoa(o.Mate))
 	} else {
@@ -91,6 +112,7 @@ type responseOption struct {
 	Default string
 	Min     string
 	Max     string
+	Vars    []string
 }
 
 func (o responseOption) format(_ *Controller) string {
@@ -112,6 +134,20 @@ func (o responseOption) format(_ *Controller) string {
 			"option name %s type check default %s",
 			o.Name, o.Default,
 		)
+	case stringOptionType:
+		return fmt.Sprintf(
+			"option name %s type string default %s",
+			o.Name, o.Default,
+		)
+	case comboOptionType:
+		var vars string
+		for _, v := range o.Vars {
+			vars += " var " + v
+		}
+		return fmt.Sprintf(
+			"option name %s type combo default %s%s",
+			o.Name, o.Default, vars,
+		)
 	default:
 		return ""
 	}
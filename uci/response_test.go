@@ -27,6 +27,7 @@ func TestResponseFormat(t *testing.T) {
 		{name: "uciok", args: responseUCIOK{}, want: "uciok"},
 		{name: "readyok", args: responseReadyOK{}, want: "readyok"},
 		{name: "bestmove", args: responseBestMove{m1}, want: "bestmove b1a3"},
+		{name: "info string", args: responseInfoString{"table saved"}, want: "info string table saved"},
 		{
 			name: "info score positive",
 			args: responseOutput{
@@ -53,6 +54,20 @@ func TestResponseFormat(t *testing.T) {
 			},
 			want: "info depth 8 nodes 1024 score cp -3000 pv b1a3 e6e7 time 5000",
 		},
+		{
+			name: "info tablebase hits",
+			args: responseOutput{
+				search.Output{
+					Depth:  8,
+					Nodes:  1024,
+					TBHits: 12,
+					Score:  3000,
+					PV:     []chess.Move{m1, m2},
+				},
+				time.Duration(5e9),
+			},
+			want: "info depth 8 nodes 1024 tbhits 12 score cp 3000 pv b1a3 e6e7 time 5000",
+		},
 		{
 			name: "info mate positive",
 			args: responseOutput{
@@ -91,6 +106,16 @@ func TestResponseFormat(t *testing.T) {
 			args: testOptions[booleanOptionType],
 			want: "option name BOOLEAN OPTION type check default false",
 		},
+		{
+			name: "string option",
+			args: testOptions[stringOptionType],
+			want: "option name STRING OPTION type string default <empty>",
+		},
+		{
+			name: "combo option",
+			args: testOptions[comboOptionType],
+			want: "option name COMBO OPTION type combo default a var a var b",
+		},
 	}
 
 	for _, tt := range tests {
@@ -115,4 +140,15 @@ var testOptions = []responseOption{
 		Name:    "BOOLEAN OPTION",
 		Default: "false",
 	},
+	{
+		Type:    stringOptionType,
+		Name:    "STRING OPTION",
+		Default: "<empty>",
+	},
+	{
+		Type:    comboOptionType,
+		Name:    "COMBO OPTION",
+		Default: "a",
+		Vars:    []string{"a", "b"},
+	},
 }
@@ -26,11 +26,13 @@ type Controller struct {
 	author       string
 	debug        bool
 	position     *chess.Position
+	history      []chess.Hash // hashes of the positions played before the current position
 	notation     chess.Notation
 	moveNotation chess.MoveNotation
 	writer       io.Writer
 	mu           sync.Mutex
-	stop         chan struct{}
+	cancel       context.CancelFunc // cancels the running search, nil for none
+	cancelMu     sync.Mutex
 }
 
 // NewController creates a new Controller.
@@ -43,7 +45,6 @@ func NewController(name, author string, writer io.Writer) *Controller {
 		moveNotation: chess.UCI{},
 		writer:       writer,
 		mu:           sync.Mutex{},
-		stop:         make(chan struct{}),
 	}
 }
 
@@ -88,9 +89,29 @@ func (c *Controller) Run(ctx context.Context, e *search.Engine, r io.Reader) {
 	}
 }
 
+// setCancel sets the function cancelling the running search, nil for none.
+func (c *Controller) setCancel(cancel context.CancelFunc) {
+	c.cancelMu.Lock()
+	defer c.cancelMu.Unlock()
+	c.cancel = cancel
+}
+
+// stopSearch cancels the running search, if any.
+func (c *Controller) stopSearch() {
+	c.cancelMu.Lock()
+	defer c.cancelMu.Unlock()
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
 // logError logs an error to the output.
+//
+// Each line of joined errors is logged on its own info string.
 func (c *Controller) logError(err error) {
-	_, _ = fmt.Fprintln(c.writer, "info string", err.Error())
+	for _, line := range strings.Split(err.Error(), "\n") {
+		_, _ = fmt.Fprintln(c.writer, "info string", line)
+	}
 }
 
 // logDebug logs debug info to the output.
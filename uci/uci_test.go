@@ -19,13 +19,14 @@ func TestControllerRun(t *testing.T) {
 
 	r := strings.NewReader("uci\nfake command\nquit\n")
 
-	expected := concatenateResponses(c, []response{
-		responseID{name, author},
-		availableSearchOptions[0].response(),
-		availableSearchOptions[1].response(),
-		availableUCIOptions[0].response(),
-		responseUCIOK{},
-	})
+	responses := []response{responseID{name, author}}
+	for _, option := range availableSearchOptions {
+		responses = append(responses, option.response())
+	}
+	for _, option := range availableUCIOptions {
+		responses = append(responses, option.response())
+	}
+	expected := concatenateResponses(c, append(responses, responseUCIOK{}))
 
 	c.Run(context.Background(), e, r)
 	assert.Equal(t, expected, w.String())